@@ -135,6 +135,35 @@ if ! curl -fsSL "${CURL_RETRY_ARGS[@]}" -o "${TEMP_DIR}/${INSTALL_NAME}" "${DOWN
   exit 1
 fi
 
+# Verify the binary against the checksums published with the release. Older
+# releases don't have them, so only a 404 skips the verification: any other
+# failure to download the checksums fails the install.
+CHECKSUMS_NAME="roc_${VERSION}_checksums.txt"
+CHECKSUMS_URL="https://github.com/runs-on/cli/releases/download/${VERSION}/${CHECKSUMS_NAME}"
+CHECKSUMS_STATUS=$(curl -sSL "${CURL_RETRY_ARGS[@]}" -o "${TEMP_DIR}/${CHECKSUMS_NAME}" -w '%{http_code}' "${CHECKSUMS_URL}") || CHECKSUMS_STATUS="failed"
+if [ "$CHECKSUMS_STATUS" = "200" ]; then
+  EXPECTED_SUM=$(awk -v name="$BINARY_NAME" '{ file = $2; sub(/^\*/, "", file); if (file == name) print tolower($1) }' "${TEMP_DIR}/${CHECKSUMS_NAME}" | head -1)
+  if [ -z "$EXPECTED_SUM" ]; then
+    echo "Error: No checksum listed for ${BINARY_NAME} in ${CHECKSUMS_URL}"
+    exit 1
+  fi
+  if command -v sha256sum >/dev/null 2>&1; then
+    ACTUAL_SUM=$(sha256sum "${TEMP_DIR}/${INSTALL_NAME}" | cut -d' ' -f1)
+  else
+    ACTUAL_SUM=$(shasum -a 256 "${TEMP_DIR}/${INSTALL_NAME}" | cut -d' ' -f1)
+  fi
+  if [ "$ACTUAL_SUM" != "$EXPECTED_SUM" ]; then
+    echo "Error: Checksum mismatch for ${BINARY_NAME}: expected ${EXPECTED_SUM}, got ${ACTUAL_SUM}"
+    exit 1
+  fi
+  echo "Verified checksum of ${BINARY_NAME}"
+elif [ "$CHECKSUMS_STATUS" = "404" ]; then
+  echo "Warning: ${CHECKSUMS_NAME} is not published with ${VERSION}, skipping checksum verification"
+else
+  echo "Error: Failed to download ${CHECKSUMS_URL} (${CHECKSUMS_STATUS})"
+  exit 1
+fi
+
 # Make executable (for Unix systems)
 if [ "$OS" != "windows" ]; then
   chmod +x "${TEMP_DIR}/${INSTALL_NAME}"
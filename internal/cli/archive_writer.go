@@ -9,9 +9,13 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync"
 )
 
+// archiveWriter writes a zip archive. It is safe for concurrent use: each
+// entry is written whole while holding mu.
 type archiveWriter struct {
+	mu     sync.Mutex
 	file   *os.File
 	writer *zip.Writer
 	closed bool
@@ -45,6 +49,8 @@ func (a *archiveWriter) writeBytes(entryPath string, data []byte) error {
 	if err != nil {
 		return err
 	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	fileWriter, err := a.writer.Create(entryName)
 	if err != nil {
 		return err
@@ -76,6 +82,8 @@ func (a *archiveWriter) writeFile(entryPath, filePath string) error {
 	}
 	header.Name = entryName
 
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	fileWriter, err := a.writer.CreateHeader(header)
 	if err != nil {
 		return err
@@ -86,6 +94,8 @@ func (a *archiveWriter) writeFile(entryPath, filePath string) error {
 }
 
 func (a *archiveWriter) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	if a.closed {
 		return nil
 	}
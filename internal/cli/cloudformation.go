@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+type cloudFormationStacksAPI interface {
+	DescribeStacks(ctx context.Context, params *cloudformation.DescribeStacksInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeStacksOutput, error)
+}
+
+// describeStackOutputs returns the outputs of the stack, keyed by output key.
+func describeStackOutputs(ctx context.Context, client cloudFormationStacksAPI, stackName string) (map[string]string, error) {
+	stackName = strings.TrimSpace(stackName)
+	output, err := client.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
+		StackName: aws.String(stackName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describe cloudformation stack %q: %w", stackName, err)
+	}
+	if len(output.Stacks) == 0 {
+		return nil, fmt.Errorf("describe cloudformation stack %q: stack not found", stackName)
+	}
+	outputs := make(map[string]string, len(output.Stacks[0].Outputs))
+	for _, stackOutput := range output.Stacks[0].Outputs {
+		outputs[aws.ToString(stackOutput.OutputKey)] = aws.ToString(stackOutput.OutputValue)
+	}
+	return outputs, nil
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+func TestDescribeStackOutputs(t *testing.T) {
+	client := &mockCloudFormationStacksClient{
+		describeStacks: func(_ context.Context, input *cloudformation.DescribeStacksInput, _ ...func(*cloudformation.Options)) (*cloudformation.DescribeStacksOutput, error) {
+			if aws.ToString(input.StackName) != "runs-on" {
+				t.Fatalf("unexpected stack name %q", aws.ToString(input.StackName))
+			}
+			return stackWithOutputs(map[string]string{
+				"RunsOnEntryPoint":        "https://example.com/prod",
+				"RunsOnWorkflowJobsTable": "workflow-jobs",
+			}), nil
+		},
+	}
+
+	outputs, err := describeStackOutputs(context.Background(), client, " runs-on ")
+	if err != nil {
+		t.Fatalf("describeStackOutputs returned error: %v", err)
+	}
+	if outputs["RunsOnEntryPoint"] != "https://example.com/prod" || outputs["RunsOnWorkflowJobsTable"] != "workflow-jobs" {
+		t.Fatalf("unexpected outputs %v", outputs)
+	}
+}
+
+func TestDescribeStackOutputsReportsErrors(t *testing.T) {
+	client := &mockCloudFormationStacksClient{
+		describeStacks: func(context.Context, *cloudformation.DescribeStacksInput, ...func(*cloudformation.Options)) (*cloudformation.DescribeStacksOutput, error) {
+			return nil, errors.New("ValidationError: Stack with id runs-on does not exist")
+		},
+	}
+	_, err := describeStackOutputs(context.Background(), client, "runs-on")
+	if err == nil || err.Error() != `describe cloudformation stack "runs-on": ValidationError: Stack with id runs-on does not exist` {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	client.describeStacks = func(context.Context, *cloudformation.DescribeStacksInput, ...func(*cloudformation.Options)) (*cloudformation.DescribeStacksOutput, error) {
+		return &cloudformation.DescribeStacksOutput{}, nil
+	}
+	_, err = describeStackOutputs(context.Background(), client, "runs-on")
+	if err == nil || err.Error() != `describe cloudformation stack "runs-on": stack not found` {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -15,18 +15,34 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// connectTarget is the --output json|yaml form of connect: the instance a
+// session would be opened on. No session is started in that mode.
+type connectTarget struct {
+	Stack      string `json:"stack"`
+	Region     string `json:"region"`
+	JobID      string `json:"job_id"`
+	InstanceID string `json:"instance_id"`
+	Platform   string `json:"platform,omitempty"`
+}
+
 func NewConnectCmd(stack *Stack) *cobra.Command {
 	var debug bool
 	var watch bool
+	var jobName string
+	var asRun bool
 
 	cmd := &cobra.Command{
-		Use:           "connect JOB_ID|JOB_URL",
-		Short:         "Connect to the instance running a specific job via SSM",
+		Use:   "connect JOB_ID|JOB_URL|RUN_ID|RUN_URL",
+		Short: "Connect to the instance running a specific job via SSM",
+		Long: `Connect to the instance running a specific job via SSM.
+
+With --output json or yaml, the instance is resolved and printed instead of
+starting a session.`,
 		Args:          cobra.ExactArgs(1),
 		SilenceUsage:  true,
 		SilenceErrors: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			config, err := stack.getStackOutputs(cmd)
+			config, err := stack.getJobStackOutputs(cmd, args[0])
 			if err != nil {
 				return err
 			}
@@ -34,7 +50,6 @@ func NewConnectCmd(stack *Stack) *cobra.Command {
 				return err
 			}
 
-			jobID := extractJobID(args[0])
 			ctx := cmd.Context()
 
 			logger := log.New(io.Discard, "", 0)
@@ -43,6 +58,14 @@ func NewConnectCmd(stack *Stack) *cobra.Command {
 			}
 
 			jobsClient := dynamodb.NewFromConfig(config.AWSConfig)
+			selector := newWorkflowJobSelector(jobName, false)
+			selector.Run = asRun
+			resolved, err := resolveWorkflowJobs(ctx, jobsClient, config.WorkflowJobsTable, args[0], selector)
+			if err != nil {
+				return err
+			}
+			jobID := resolved.JobIDs[0]
+
 			ssmClient := ssm.NewFromConfig(config.AWSConfig)
 			facts, err := waitForWorkflowJobFacts(ctx, jobsClient, config.WorkflowJobsTable, jobID, watch, logger)
 			if err != nil {
@@ -67,10 +90,21 @@ func NewConnectCmd(stack *Stack) *cobra.Command {
 				return fmt.Errorf("instance %s is not running or not registered with SSM", instanceID)
 			}
 
-			fmt.Printf("Connecting to instance %s...\n", instanceID)
-
 			// Create session input for plugin
 			region := config.AWSConfig.Region
+			platform := describeOutput.InstanceInformationList[0].PlatformType
+
+			if structuredOutput(cmd) {
+				return writeStructured(cmd.OutOrStdout(), outputFormat(cmd), connectTarget{
+					Stack:      config.StackName,
+					Region:     region,
+					JobID:      jobID,
+					InstanceID: instanceID,
+					Platform:   string(platform),
+				})
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Connecting to instance %s...\n", instanceID)
 
 			// Start session-manager-plugin
 			awsPath, err := exec.LookPath("aws")
@@ -86,7 +120,7 @@ func NewConnectCmd(stack *Stack) *cobra.Command {
 
 			// Determine shell command based on platform type
 			shellCmd := "cd /home/runner && sudo -s bash"
-			if describeOutput.InstanceInformationList[0].PlatformType == "Windows" {
+			if platform == types.PlatformTypeWindows {
 				// will still work even if directory does not exist (defaults to C:\Windows\system32)
 				shellCmd = "cd C:\\actions-runner; powershell"
 			}
@@ -103,5 +137,7 @@ func NewConnectCmd(stack *Stack) *cobra.Command {
 
 	cmd.Flags().BoolVar(&debug, "debug", false, "Enable debug output")
 	cmd.Flags().BoolVar(&watch, "watch", false, "Wait for instance ID if not found")
+	cmd.Flags().StringVar(&jobName, "job-name", "", "When given a run, select the job with this name")
+	cmd.Flags().BoolVar(&asRun, "run", false, "Look up the argument as a workflow run ID")
 	return cmd
 }
@@ -0,0 +1,390 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+// rocConfigFile is the roc config file, by default ~/.config/roc/config.yaml.
+// It holds named contexts, each selecting a stack, an AWS profile and region,
+// and defaults for log flags.
+type rocConfigFile struct {
+	CurrentContext string                `yaml:"current-context,omitempty"`
+	Contexts       map[string]rocContext `yaml:"contexts,omitempty"`
+}
+
+type rocContext struct {
+	Stack         string `yaml:"stack,omitempty"`
+	Profile       string `yaml:"profile,omitempty"`
+	Region        string `yaml:"region,omitempty"`
+	LogFormat     string `yaml:"log-format,omitempty"`
+	WatchInterval string `yaml:"watch-interval,omitempty"`
+}
+
+// contextRecord is the --output json|yaml form of a context.
+type contextRecord struct {
+	Name          string `json:"name"`
+	Current       bool   `json:"current"`
+	Stack         string `json:"stack,omitempty"`
+	Profile       string `json:"profile,omitempty"`
+	Region        string `json:"region,omitempty"`
+	LogFormat     string `json:"log_format,omitempty"`
+	WatchInterval string `json:"watch_interval,omitempty"`
+}
+
+func newContextRecord(name string, entry rocContext, current bool) contextRecord {
+	return contextRecord{
+		Name:          name,
+		Current:       current,
+		Stack:         entry.Stack,
+		Profile:       entry.Profile,
+		Region:        entry.Region,
+		LogFormat:     entry.LogFormat,
+		WatchInterval: entry.WatchInterval,
+	}
+}
+
+// noContextAnnotation marks commands that don't talk to AWS, so the selected
+// context is not applied to them.
+const noContextAnnotation = "roc/no-context"
+
+// logCommandAnnotation marks the log streaming commands, which take their
+// --format and --watch interval defaults from the selected context.
+const logCommandAnnotation = "roc/log-command"
+
+const defaultLogWatchInterval = "5s"
+
+// bareLogWatch is the value of a --watch given without an interval, which
+// watches at the interval of the context, or defaultLogWatchInterval.
+const bareLogWatch = "true"
+
+func rocConfigPath() (string, error) {
+	if path := strings.TrimSpace(os.Getenv("ROC_CONFIG")); path != "" {
+		return path, nil
+	}
+	if dir := strings.TrimSpace(os.Getenv("XDG_CONFIG_HOME")); dir != "" {
+		return filepath.Join(dir, "roc", "config.yaml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("locate roc config file: %w", err)
+	}
+	return filepath.Join(home, ".config", "roc", "config.yaml"), nil
+}
+
+// loadRocConfigFile reads the roc config file. A missing file is not an error
+// and yields an empty config.
+func loadRocConfigFile(path string) (*rocConfigFile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &rocConfigFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read roc config file %s: %w", path, err)
+	}
+
+	var file rocConfigFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse roc config file %s: %w", path, err)
+	}
+	for name, entry := range file.Contexts {
+		if err := entry.validate(); err != nil {
+			return nil, fmt.Errorf("invalid context %q in %s: %w", name, path, err)
+		}
+	}
+	return &file, nil
+}
+
+func (c rocContext) validate() error {
+	if c.LogFormat != "" {
+		if _, err := newLogFormatter(c.LogFormat, nil); err != nil {
+			return fmt.Errorf("invalid log-format: %w", err)
+		}
+	}
+	if c.WatchInterval != "" {
+		if _, err := time.ParseDuration(c.WatchInterval); err != nil {
+			return fmt.Errorf("invalid watch-interval: %w", err)
+		}
+	}
+	return nil
+}
+
+func (f *rocConfigFile) contextNames() []string {
+	names := make([]string, 0, len(f.Contexts))
+	for name := range f.Contexts {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// selectedContextName returns the context picked by --context, ROC_CONTEXT or
+// the current-context of the config file, in that order.
+func selectedContextName(cmd *cobra.Command, file *rocConfigFile) string {
+	if flag := cmd.Flags().Lookup("context"); flag != nil && flag.Changed {
+		return strings.TrimSpace(flag.Value.String())
+	}
+	if name := strings.TrimSpace(os.Getenv("ROC_CONTEXT")); name != "" {
+		return name
+	}
+	return strings.TrimSpace(file.CurrentContext)
+}
+
+// selectedContext loads the config file and returns the selected context, if
+// any.
+func selectedContext(cmd *cobra.Command) (string, *rocContext, error) {
+	path, err := rocConfigPath()
+	if err != nil {
+		return "", nil, err
+	}
+	file, err := loadRocConfigFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	name := selectedContextName(cmd, file)
+	if name == "" {
+		return "", nil, nil
+	}
+	entry, ok := file.Contexts[name]
+	if !ok {
+		return "", nil, fmt.Errorf("context %q not found in %s", name, path)
+	}
+	return name, &entry, nil
+}
+
+// applyContextFlagDefaults fills the flags the user didn't set from the
+// context. Explicit flags and the RUNS_ON_STACK_NAME/RUNS_ON_STACK environment
+// variables take precedence.
+func applyContextFlagDefaults(cmd *cobra.Command, entry *rocContext) error {
+	if entry.Stack != "" && !cmd.Flags().Changed("stack") && envStackName() == "" {
+		if err := cmd.Flags().Set("stack", entry.Stack); err != nil {
+			return fmt.Errorf("apply context stack: %w", err)
+		}
+	}
+
+	if cmd.Annotations[logCommandAnnotation] == "" {
+		return nil
+	}
+	if entry.LogFormat != "" && !cmd.Flags().Changed("format") {
+		if err := cmd.Flags().Set("format", entry.LogFormat); err != nil {
+			return fmt.Errorf("apply context log-format: %w", err)
+		}
+	}
+	// A bare --watch takes the context's interval; an explicit one is kept.
+	if watch := cmd.Flags().Lookup("watch"); watch != nil && entry.WatchInterval != "" && watch.Changed && watch.Value.String() == bareLogWatch {
+		if err := watch.Value.Set(entry.WatchInterval); err != nil {
+			return fmt.Errorf("apply context watch-interval: %w", err)
+		}
+	}
+	return nil
+}
+
+func envStackName() string {
+	for _, envVar := range []string{"RUNS_ON_STACK_NAME", "RUNS_ON_STACK"} {
+		if stackName, ok := os.LookupEnv(envVar); ok {
+			return stackName
+		}
+	}
+	return ""
+}
+
+// setCurrentContext updates current-context in the config file, keeping the
+// rest of the file, comments included, as is.
+func setCurrentContext(path, name string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("read roc config file %s: %w", path, err)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("parse roc config file %s: %w", path, err)
+	}
+	if document.Kind == 0 {
+		document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("parse roc config file %s: expected a mapping at the top level", path)
+	}
+
+	value := &yaml.Node{Kind: yaml.ScalarNode, Value: name}
+	updated := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "current-context" {
+			root.Content[i+1] = value
+			updated = true
+			break
+		}
+	}
+	if !updated {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: "current-context"}
+		root.Content = append([]*yaml.Node{key, value}, root.Content...)
+	}
+
+	output, err := yaml.Marshal(&document)
+	if err != nil {
+		return fmt.Errorf("encode roc config file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create roc config directory: %w", err)
+	}
+	if err := os.WriteFile(path, output, 0644); err != nil {
+		return fmt.Errorf("write roc config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func NewContextCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "context",
+		Short: "Switch between named stack contexts",
+		Long: `Switch between the named contexts of the roc config file.
+
+Contexts are defined in ~/.config/roc/config.yaml ($XDG_CONFIG_HOME/roc/config.yaml,
+or the file set in ROC_CONFIG). Each context can set the stack name, the AWS
+profile and region, and defaults for the log commands:
+
+  current-context: prod
+  contexts:
+    prod:
+      stack: runs-on
+      profile: runs-on-prod
+      region: us-east-1
+    staging:
+      stack: runs-on-staging
+      profile: runs-on-staging
+      region: eu-west-1
+      log-format: short
+      watch-interval: 10s
+
+The selected context can be overridden with --context or ROC_CONTEXT. Explicit
+flags and the RUNS_ON_STACK_NAME, AWS_PROFILE and AWS_REGION environment
+variables take precedence over the context.`,
+		Annotations: map[string]string{noContextAnnotation: "true"},
+	}
+
+	cmd.AddCommand(
+		newContextListCmd(),
+		newContextCurrentCmd(),
+		newContextUseCmd(),
+	)
+
+	return cmd
+}
+
+func newContextListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:         "list",
+		Short:       "List the contexts of the roc config file",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{noContextAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := rocConfigPath()
+			if err != nil {
+				return err
+			}
+			file, err := loadRocConfigFile(path)
+			if err != nil {
+				return err
+			}
+			if len(file.Contexts) == 0 {
+				return fmt.Errorf("no contexts defined in %s", path)
+			}
+			current := selectedContextName(cmd, file)
+			if structuredOutput(cmd) {
+				records := make([]contextRecord, 0, len(file.Contexts))
+				for _, name := range file.contextNames() {
+					records = append(records, newContextRecord(name, file.Contexts[name], name == current))
+				}
+				return writeStructured(cmd.OutOrStdout(), outputFormat(cmd), records)
+			}
+			return writeContextList(cmd.OutOrStdout(), file, current)
+		},
+	}
+}
+
+func writeContextList(w io.Writer, file *rocConfigFile, current string) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "CURRENT\tNAME\tSTACK\tPROFILE\tREGION")
+	for _, name := range file.contextNames() {
+		entry := file.Contexts[name]
+		marker := ""
+		if name == current {
+			marker = "*"
+		}
+		row := []string{marker, name, entry.Stack, entry.Profile, entry.Region}
+		for i := 2; i < len(row); i++ {
+			if row[i] == "" {
+				row[i] = "-"
+			}
+		}
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	return writer.Flush()
+}
+
+func newContextCurrentCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:         "current",
+		Short:       "Print the selected context",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{noContextAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, entry, err := selectedContext(cmd)
+			if err != nil {
+				return err
+			}
+			if name == "" {
+				return fmt.Errorf("no context selected. Use 'roc context use NAME' to select one")
+			}
+			if structuredOutput(cmd) {
+				return writeStructured(cmd.OutOrStdout(), outputFormat(cmd), newContextRecord(name, *entry, true))
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), name)
+			return nil
+		},
+	}
+}
+
+func newContextUseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:         "use NAME",
+		Short:       "Make a context the current one",
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{noContextAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := rocConfigPath()
+			if err != nil {
+				return err
+			}
+			file, err := loadRocConfigFile(path)
+			if err != nil {
+				return err
+			}
+			name := strings.TrimSpace(args[0])
+			if _, ok := file.Contexts[name]; !ok {
+				return fmt.Errorf("context %q not found in %s. Available contexts: %s", name, path, strings.Join(file.contextNames(), ", "))
+			}
+			if err := setCurrentContext(path, name); err != nil {
+				return err
+			}
+			if structuredOutput(cmd) {
+				return writeStructured(cmd.OutOrStdout(), outputFormat(cmd), newContextRecord(name, file.Contexts[name], true))
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Switched to context %q\n", name)
+			return nil
+		},
+	}
+}
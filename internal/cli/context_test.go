@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testRocConfig = `# roc contexts
+current-context: prod
+contexts:
+  prod:
+    stack: runs-on
+    profile: runs-on-prod
+    region: us-east-1
+  staging:
+    stack: runs-on-staging
+    region: eu-west-1 # staging lives in Europe
+    log-format: short
+    watch-interval: 10s
+`
+
+func writeTestRocConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write roc config: %v", err)
+	}
+	t.Setenv("ROC_CONFIG", path)
+	t.Setenv("ROC_CONTEXT", "")
+	for _, envVar := range []string{"RUNS_ON_STACK_NAME", "RUNS_ON_STACK"} {
+		t.Setenv(envVar, "")
+		os.Unsetenv(envVar)
+	}
+	return path
+}
+
+func runRootCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	cmd := NewRootCmd(&Stack{})
+	var output bytes.Buffer
+	cmd.SetOut(&output)
+	cmd.SetErr(&output)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return output.String(), err
+}
+
+func TestLoadRocConfigFile(t *testing.T) {
+	path := writeTestRocConfig(t, testRocConfig)
+
+	file, err := loadRocConfigFile(path)
+	if err != nil {
+		t.Fatalf("loadRocConfigFile returned error: %v", err)
+	}
+	if file.CurrentContext != "prod" || file.Contexts["staging"].WatchInterval != "10s" || file.Contexts["prod"].Profile != "runs-on-prod" {
+		t.Fatalf("unexpected config %+v", file)
+	}
+
+	missing, err := loadRocConfigFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil || len(missing.Contexts) != 0 {
+		t.Fatalf("expected a missing file to yield an empty config, got %+v, %v", missing, err)
+	}
+
+	invalid := writeTestRocConfig(t, "contexts:\n  prod:\n    log-format: fancy\n")
+	if _, err := loadRocConfigFile(invalid); err == nil || !strings.Contains(err.Error(), `invalid context "prod"`) {
+		t.Fatalf("expected invalid log-format to be rejected, got %v", err)
+	}
+}
+
+func TestContextCommands(t *testing.T) {
+	path := writeTestRocConfig(t, testRocConfig)
+
+	output, err := runRootCommand(t, "context", "list")
+	if err != nil {
+		t.Fatalf("context list returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "*") || !strings.Contains(lines[1], "runs-on-prod") || !strings.Contains(lines[2], "staging") {
+		t.Fatalf("unexpected context list:\n%s", output)
+	}
+
+	output, err = runRootCommand(t, "context", "current")
+	if err != nil || strings.TrimSpace(output) != "prod" {
+		t.Fatalf("expected current context prod, got %q (%v)", output, err)
+	}
+	output, err = runRootCommand(t, "context", "current", "--context", "staging")
+	if err != nil || strings.TrimSpace(output) != "staging" {
+		t.Fatalf("expected --context to override the current context, got %q (%v)", output, err)
+	}
+
+	if _, err := runRootCommand(t, "context", "use", "qa"); err == nil || !strings.Contains(err.Error(), "Available contexts: prod, staging") {
+		t.Fatalf("expected unknown context error, got %v", err)
+	}
+
+	if _, err := runRootCommand(t, "context", "use", "staging"); err != nil {
+		t.Fatalf("context use returned error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read roc config: %v", err)
+	}
+	for _, want := range []string{"current-context: staging", "# roc contexts", "# staging lives in Europe", "profile: runs-on-prod"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected updated config to contain %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestContextCommandsStructuredOutput(t *testing.T) {
+	writeTestRocConfig(t, testRocConfig)
+
+	output, err := runRootCommand(t, "context", "list", "--output", "json")
+	if err != nil {
+		t.Fatalf("context list returned error: %v", err)
+	}
+	var records []contextRecord
+	if err := json.Unmarshal([]byte(output), &records); err != nil {
+		t.Fatalf("decode context list: %v\n%s", err, output)
+	}
+	if len(records) != 2 || records[0].Name != "prod" || !records[0].Current || records[1].Current || records[1].WatchInterval != "10s" {
+		t.Fatalf("unexpected context records %+v", records)
+	}
+
+	output, err = runRootCommand(t, "context", "current", "-o", "yaml")
+	if err != nil {
+		t.Fatalf("context current returned error: %v", err)
+	}
+	if !strings.HasPrefix(output, "name: prod\ncurrent: true\nstack: runs-on\n") {
+		t.Fatalf("unexpected context current output:\n%s", output)
+	}
+}
+
+func TestSetCurrentContextCreatesConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "roc", "config.yaml")
+	if err := setCurrentContext(path, "prod"); err != nil {
+		t.Fatalf("setCurrentContext returned error: %v", err)
+	}
+	file, err := loadRocConfigFile(path)
+	if err != nil || file.CurrentContext != "prod" {
+		t.Fatalf("expected new config file with current-context, got %+v, %v", file, err)
+	}
+}
+
+func TestApplyContextSetsStackAndLogDefaults(t *testing.T) {
+	writeTestRocConfig(t, testRocConfig)
+
+	root := NewRootCmd(&Stack{})
+	cmd, _, err := root.Find([]string{"stack", "logs"})
+	if err != nil {
+		t.Fatalf("find stack logs: %v", err)
+	}
+	if err := cmd.ParseFlags([]string{"--context", "staging", "--watch"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	if err := (&Stack{}).applyContext(cmd); err != nil {
+		t.Fatalf("applyContext returned error: %v", err)
+	}
+	for flag, want := range map[string]string{"stack": "[runs-on-staging]", "format": "short", "watch": "10s"} {
+		if got := cmd.Flags().Lookup(flag).Value.String(); got != want {
+			t.Fatalf("expected --%s %q from context, got %q", flag, want, got)
+		}
+	}
+
+	root = NewRootCmd(&Stack{})
+	cmd, _, err = root.Find([]string{"stack", "logs"})
+	if err != nil {
+		t.Fatalf("find stack logs: %v", err)
+	}
+	if err := cmd.ParseFlags([]string{"--context", "staging", "--watch=" + defaultLogWatchInterval}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	if err := (&Stack{}).applyContext(cmd); err != nil {
+		t.Fatalf("applyContext returned error: %v", err)
+	}
+	if got := cmd.Flags().Lookup("watch").Value.String(); got != defaultLogWatchInterval {
+		t.Fatalf("expected an explicit --watch %s to win over the context, got %q", defaultLogWatchInterval, got)
+	}
+
+	root = NewRootCmd(&Stack{})
+	cmd, _, err = root.Find([]string{"jobs", "list"})
+	if err != nil {
+		t.Fatalf("find jobs list: %v", err)
+	}
+	if err := cmd.ParseFlags([]string{"--context", "staging", "--stack", "runs-on-adhoc"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	if err := (&Stack{}).applyContext(cmd); err != nil {
+		t.Fatalf("applyContext returned error: %v", err)
+	}
+	if got := cmd.Flags().Lookup("stack").Value.String(); got != "[runs-on-adhoc]" {
+		t.Fatalf("expected explicit --stack to win over the context, got %q", got)
+	}
+	if got := cmd.Flags().Lookup("format").Value.String(); got != "table" {
+		t.Fatalf("expected log-format not to apply to jobs list, got %q", got)
+	}
+
+	if err := cmd.ParseFlags([]string{"--context", "qa"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	if err := (&Stack{}).applyContext(cmd); err == nil || !strings.Contains(err.Error(), `context "qa" not found`) {
+		t.Fatalf("expected unknown context error, got %v", err)
+	}
+}
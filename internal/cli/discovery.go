@@ -5,13 +5,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
 	tagtypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	secretstypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/spf13/cobra"
 )
 
@@ -23,6 +27,17 @@ type taggedResourcesAPI interface {
 	GetResources(ctx context.Context, params *resourcegroupstaggingapi.GetResourcesInput, optFns ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.GetResourcesOutput, error)
 }
 
+// cloudFormationLogicalIDTag is the tag CloudFormation adds to the resources
+// of a stack with their logical ID.
+const cloudFormationLogicalIDTag = "aws:cloudformation:logical-id"
+
+// Sources recorded in RunsOnConfig.Sources for each discovered value.
+const (
+	configSourceSecret         = "stack-config secret"
+	configSourceCloudFormation = "cloudformation outputs"
+	configSourceTags           = "resource tags"
+)
+
 type stackConfigSecretValue struct {
 	WorkflowJobsTable      string `json:"WorkflowJobsTable"`
 	IngressURL             string `json:"IngressURL"`
@@ -30,17 +45,242 @@ type stackConfigSecretValue struct {
 	EC2InstanceLogGroupArn string `json:"Ec2InstanceLogGroupArn"`
 }
 
+// stackConfigField describes one value of the stack config: the RunsOnConfig
+// field it ends up in and the CloudFormation output keys it may be published
+// under. The resource of a value is found among the tagged resources of the
+// stack by its CloudFormation logical ID, which is one of the output keys.
+type stackConfigField struct {
+	Name       string
+	Label      string
+	OutputKeys []string
+	value      func(*stackConfigSecretValue) *string
+	// resourceValue returns the value from the ARN of a tagged resource, or
+	// "" when the resource isn't of the expected type. It is nil for values
+	// that can't be derived from a resource.
+	resourceValue func(arn string) string
+}
+
+var stackConfigFields = []stackConfigField{
+	{
+		Name:       "IngressURL",
+		Label:      "Ingress URL",
+		OutputKeys: []string{"IngressURL", "RunsOnIngressURL", "RunsOnEntryPoint"},
+		value:      func(v *stackConfigSecretValue) *string { return &v.IngressURL },
+	},
+	{
+		Name:       "ServiceLogGroupName",
+		Label:      "Application log group",
+		OutputKeys: []string{"ServiceLogGroupName", "RunsOnServiceLogGroupName"},
+		value:      func(v *stackConfigSecretValue) *string { return &v.ServiceLogGroupName },
+		resourceValue: func(arn string) string {
+			_, name, _ := strings.Cut(normalizeCloudWatchLogGroupIdentifier(arn), ":log-group:")
+			return name
+		},
+	},
+	{
+		Name:       "EC2InstanceLogGroupArn",
+		Label:      "EC2 instance log group",
+		OutputKeys: []string{"Ec2InstanceLogGroupArn", "RunsOnEc2InstanceLogGroupArn"},
+		value:      func(v *stackConfigSecretValue) *string { return &v.EC2InstanceLogGroupArn },
+		resourceValue: func(arn string) string {
+			if !strings.Contains(arn, ":log-group:") {
+				return ""
+			}
+			return normalizeCloudWatchLogGroupIdentifier(arn)
+		},
+	},
+	{
+		Name:       "WorkflowJobsTable",
+		Label:      "Workflow jobs table",
+		OutputKeys: []string{"WorkflowJobsTable", "RunsOnWorkflowJobsTable"},
+		value:      func(v *stackConfigSecretValue) *string { return &v.WorkflowJobsTable },
+		resourceValue: func(arn string) string {
+			_, name, _ := strings.Cut(arn, ":table/")
+			return name
+		},
+	},
+}
+
+// discoveredStackConfig is the stack config along with the source each value
+// was found in, keyed by field name.
+type discoveredStackConfig struct {
+	Value   stackConfigSecretValue `json:"value"`
+	Sources map[string]string      `json:"sources,omitempty"`
+}
+
+func newDiscoveredStackConfig() *discoveredStackConfig {
+	return &discoveredStackConfig{Sources: map[string]string{}}
+}
+
+// merge fills the values that are still missing from value.
+func (d *discoveredStackConfig) merge(value stackConfigSecretValue, source string) {
+	for _, field := range stackConfigFields {
+		d.set(field, *field.value(&value), source)
+	}
+}
+
+func (d *discoveredStackConfig) set(field stackConfigField, value, source string) {
+	value = strings.TrimSpace(value)
+	target := field.value(&d.Value)
+	if value == "" || *target != "" {
+		return
+	}
+	*target = value
+	d.Sources[field.Name] = source
+}
+
+// cacheable reports whether every value comes from the stack config secret or
+// the stack outputs. Values found among the tagged resources are rediscovered
+// on each command, so a missing or unreadable secret is retried.
+func (d *discoveredStackConfig) cacheable() bool {
+	for _, source := range d.Sources {
+		if source != configSourceSecret && source != configSourceCloudFormation {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *discoveredStackConfig) count() int {
+	found := 0
+	for _, field := range stackConfigFields {
+		if *field.value(&d.Value) != "" {
+			found++
+		}
+	}
+	return found
+}
+
 func stackConfigSecretID(stackName string) string {
 	return fmt.Sprintf("/runs-on/%s/stack-config", strings.TrimSpace(stackName))
 }
 
 func (s *Stack) discoverResources(cmd *cobra.Command) (*RunsOnConfig, error) {
-	stackName := strings.TrimSpace(cmd.Flag("stack").Value.String())
-	client := secretsmanager.NewFromConfig(s.cfg)
-	return loadRunsOnConfig(cmd.Context(), client, stackName, s.cfg)
+	targets, err := s.stackTargets(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) != 1 {
+		labels := make([]string, 0, len(targets))
+		for _, target := range targets {
+			labels = append(labels, target.String())
+		}
+		return nil, fmt.Errorf("%s operates on a single stack, but %d were selected (%s)", cmd.CommandPath(), len(targets), strings.Join(labels, ", "))
+	}
+	return s.loadStackTarget(cmd.Context(), targets[0], refreshStackConfig(cmd))
+}
+
+func refreshStackConfig(cmd *cobra.Command) bool {
+	refresh, _ := cmd.Flags().GetBool("refresh-stack-config")
+	return refresh
+}
+
+func (s *Stack) loadStackTarget(ctx context.Context, target stackTarget, refresh bool) (*RunsOnConfig, error) {
+	cfg := s.cfg.Copy()
+	if target.Region != "" {
+		cfg.Region = target.Region
+	}
+	return loadCachedRunsOnConfig(ctx, stackConfigLoader{
+		secrets:  secretsmanager.NewFromConfig(cfg),
+		stacks:   cloudformation.NewFromConfig(cfg),
+		tagging:  resourcegroupstaggingapi.NewFromConfig(cfg),
+		identity: sts.NewFromConfig(cfg),
+		cache:    newStackConfigCache(),
+		refresh:  refresh,
+	}, target.StackName, cfg)
 }
 
 func loadRunsOnConfig(ctx context.Context, client stackConfigSecretAPI, stackName string, cfg aws.Config) (*RunsOnConfig, error) {
+	secret, err := loadStackConfigSecret(ctx, client, stackName, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return runsOnConfigFromSecret(stackName, cfg, *secret), nil
+}
+
+// discoverStackConfig reads the stack config secret and fills whatever it
+// doesn't provide from the CloudFormation stack outputs, then from the
+// resources tagged with the stack name. It only fails when none of the three
+// sources yields anything.
+func discoverStackConfig(ctx context.Context, loader stackConfigLoader, stackName string, cfg aws.Config) (*discoveredStackConfig, error) {
+	discovered := newDiscoveredStackConfig()
+	secret, secretErr := loadStackConfigSecret(ctx, loader.secrets, stackName, cfg)
+	if secretErr == nil {
+		discovered.merge(*secret, configSourceSecret)
+	}
+
+	var fallbackErrs []string
+	if discovered.count() < len(stackConfigFields) && loader.stacks != nil {
+		outputs, err := describeStackOutputs(ctx, loader.stacks, stackName)
+		if err != nil {
+			fallbackErrs = append(fallbackErrs, err.Error())
+		}
+		for _, field := range stackConfigFields {
+			discovered.set(field, lookupStackOutput(outputs, field.OutputKeys), configSourceCloudFormation)
+		}
+	}
+	if discovered.count() < len(stackConfigFields) && loader.tagging != nil {
+		tagged, err := discoverTaggedStackConfig(ctx, loader.tagging, stackName)
+		if err != nil {
+			fallbackErrs = append(fallbackErrs, err.Error())
+		} else {
+			discovered.merge(*tagged, configSourceTags)
+		}
+	}
+
+	if secretErr != nil && discovered.count() == 0 {
+		if len(fallbackErrs) > 0 {
+			return nil, fmt.Errorf("%w. Fallback discovery failed too: %s", secretErr, strings.Join(fallbackErrs, "; "))
+		}
+		return nil, fmt.Errorf("%w. No CloudFormation outputs or tagged resources were found for the stack either", secretErr)
+	}
+	return discovered, nil
+}
+
+func lookupStackOutput(outputs map[string]string, keys []string) string {
+	for _, key := range keys {
+		for outputKey, value := range outputs {
+			if strings.EqualFold(outputKey, key) && strings.TrimSpace(value) != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// discoverTaggedStackConfig finds the workflow jobs table and the log groups
+// among the resources tagged with the stack name, by their CloudFormation
+// logical ID. The ingress URL can't be derived from tags.
+func discoverTaggedStackConfig(ctx context.Context, client taggedResourcesAPI, stackName string) (*stackConfigSecretValue, error) {
+	resources, err := listTaggedResources(ctx, client, stackName, []string{"dynamodb:table", "logs:log-group"})
+	if err != nil {
+		return nil, fmt.Errorf("discover tagged resources for stack %q: %w", stackName, err)
+	}
+
+	var value stackConfigSecretValue
+	for _, resource := range resources {
+		logicalID := resourceTag(resource.Tags, cloudFormationLogicalIDTag)
+		for _, field := range stackConfigFields {
+			target := field.value(&value)
+			if field.resourceValue == nil || *target != "" || !slices.Contains(field.OutputKeys, logicalID) {
+				continue
+			}
+			*target = field.resourceValue(strings.TrimSpace(aws.ToString(resource.ResourceARN)))
+		}
+	}
+	return &value, nil
+}
+
+func resourceTag(tags []tagtypes.Tag, key string) string {
+	for _, tag := range tags {
+		if aws.ToString(tag.Key) == key {
+			return aws.ToString(tag.Value)
+		}
+	}
+	return ""
+}
+
+func loadStackConfigSecret(ctx context.Context, client stackConfigSecretAPI, stackName string, cfg aws.Config) (*stackConfigSecretValue, error) {
 	if client == nil {
 		return nil, fmt.Errorf("stack config client is required")
 	}
@@ -60,7 +300,7 @@ func loadRunsOnConfig(ctx context.Context, client stackConfigSecretAPI, stackNam
 		return nil, fmt.Errorf("stack config secret %s is empty", secretID)
 	}
 
-	return parseRunsOnConfig(stackName, cfg, *output.SecretString)
+	return parseStackConfigSecret(*output.SecretString)
 }
 
 func formatStackConfigSecretLoadError(secretID string, cfg aws.Config, err error) error {
@@ -77,19 +317,38 @@ func formatStackConfigSecretLoadError(secretID string, cfg aws.Config, err error
 }
 
 func parseRunsOnConfig(stackName string, cfg aws.Config, secretValue string) (*RunsOnConfig, error) {
+	secret, err := parseStackConfigSecret(secretValue)
+	if err != nil {
+		return nil, err
+	}
+	return runsOnConfigFromSecret(stackName, cfg, *secret), nil
+}
+
+func parseStackConfigSecret(secretValue string) (*stackConfigSecretValue, error) {
 	var secret stackConfigSecretValue
 	if err := json.Unmarshal([]byte(secretValue), &secret); err != nil {
 		return nil, fmt.Errorf("parse stack config: %w", err)
 	}
+	return &secret, nil
+}
 
+func runsOnConfigFromSecret(stackName string, cfg aws.Config, secret stackConfigSecretValue) *RunsOnConfig {
+	discovered := newDiscoveredStackConfig()
+	discovered.merge(secret, configSourceSecret)
+	return runsOnConfigFromDiscovery(stackName, cfg, discovered)
+}
+
+func runsOnConfigFromDiscovery(stackName string, cfg aws.Config, discovered *discoveredStackConfig) *RunsOnConfig {
+	secret := discovered.Value
 	return &RunsOnConfig{
 		StackName:              strings.TrimSpace(stackName),
 		IngressURL:             normalizeDoctorServiceURL(secret.IngressURL),
 		ServiceLogGroupName:    strings.TrimSpace(secret.ServiceLogGroupName),
 		EC2InstanceLogGroupArn: normalizeCloudWatchLogGroupIdentifier(secret.EC2InstanceLogGroupArn),
 		WorkflowJobsTable:      strings.TrimSpace(secret.WorkflowJobsTable),
+		Sources:                maps.Clone(discovered.Sources),
 		AWSConfig:              cfg,
-	}, nil
+	}
 }
 
 func normalizeCloudWatchLogGroupIdentifier(identifier string) string {
@@ -108,8 +367,47 @@ func discoverTaggedECSServiceARN(ctx context.Context, client taggedResourcesAPI,
 		return "", fmt.Errorf("stack name is required")
 	}
 
+	matches, err := listTaggedResourceARNs(ctx, client, stackName, []string{"ecs:service"})
+	if err != nil {
+		return "", fmt.Errorf("discover ecs service for stack %q: %w", stackName, err)
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("ecs service not found for stack %q", stackName)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("multiple ecs services found for stack %q", stackName)
+	}
+}
+
+// label identifies the stack and region in output that spans several stacks.
+func (c *RunsOnConfig) label() string {
+	return stackTarget{StackName: c.StackName, Region: c.AWSConfig.Region}.String()
+}
+
+// listTaggedResourceARNs returns the ARNs of the resources of the given types
+// tagged with runs-on-stack-name=<stack>.
+func listTaggedResourceARNs(ctx context.Context, client taggedResourcesAPI, stackName string, resourceTypes []string) ([]string, error) {
+	resources, err := listTaggedResources(ctx, client, stackName, resourceTypes)
+	if err != nil {
+		return nil, err
+	}
+	var arns []string
+	for _, resource := range resources {
+		if arn := strings.TrimSpace(aws.ToString(resource.ResourceARN)); arn != "" {
+			arns = append(arns, arn)
+		}
+	}
+	return arns, nil
+}
+
+// listTaggedResources returns the resources of the given types tagged with
+// runs-on-stack-name=<stack>, with all their tags.
+func listTaggedResources(ctx context.Context, client taggedResourcesAPI, stackName string, resourceTypes []string) ([]tagtypes.ResourceTagMapping, error) {
 	input := &resourcegroupstaggingapi.GetResourcesInput{
-		ResourceTypeFilters: []string{"ecs:service"},
+		ResourceTypeFilters: resourceTypes,
 		TagFilters: []tagtypes.TagFilter{
 			{
 				Key:    aws.String("runs-on-stack-name"),
@@ -118,34 +416,20 @@ func discoverTaggedECSServiceARN(ctx context.Context, client taggedResourcesAPI,
 		},
 	}
 
-	var matches []string
+	var resources []tagtypes.ResourceTagMapping
 	for {
 		output, err := client.GetResources(ctx, input)
 		if err != nil {
-			return "", fmt.Errorf("discover ecs service for stack %q: %w", stackName, err)
-		}
-		for _, resource := range output.ResourceTagMappingList {
-			arn := strings.TrimSpace(aws.ToString(resource.ResourceARN))
-			if arn != "" {
-				matches = append(matches, arn)
-			}
+			return nil, err
 		}
+		resources = append(resources, output.ResourceTagMappingList...)
 
 		token := strings.TrimSpace(aws.ToString(output.PaginationToken))
 		if token == "" {
-			break
+			return resources, nil
 		}
 		input.PaginationToken = aws.String(token)
 	}
-
-	switch len(matches) {
-	case 0:
-		return "", fmt.Errorf("ecs service not found for stack %q", stackName)
-	case 1:
-		return matches[0], nil
-	default:
-		return "", fmt.Errorf("multiple ecs services found for stack %q", stackName)
-	}
 }
 
 func (c *RunsOnConfig) validateJobLookup() error {
@@ -3,9 +3,12 @@ package cli
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cloudformationtypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
 	tagtypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
@@ -28,6 +31,44 @@ func (m *mockTaggedResourcesClient) GetResources(ctx context.Context, input *res
 	return m.getResources(ctx, input, optFns...)
 }
 
+type mockCloudFormationStacksClient struct {
+	describeStacks func(context.Context, *cloudformation.DescribeStacksInput, ...func(*cloudformation.Options)) (*cloudformation.DescribeStacksOutput, error)
+}
+
+func (m *mockCloudFormationStacksClient) DescribeStacks(ctx context.Context, input *cloudformation.DescribeStacksInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeStacksOutput, error) {
+	return m.describeStacks(ctx, input, optFns...)
+}
+
+// stackWithOutputs is a DescribeStacks response for a stack with the given
+// outputs.
+func stackWithOutputs(outputs map[string]string) *cloudformation.DescribeStacksOutput {
+	stack := cloudformationtypes.Stack{StackName: aws.String("runs-on")}
+	for key, value := range outputs {
+		stack.Outputs = append(stack.Outputs, cloudformationtypes.Output{OutputKey: aws.String(key), OutputValue: aws.String(value)})
+	}
+	return &cloudformation.DescribeStacksOutput{Stacks: []cloudformationtypes.Stack{stack}}
+}
+
+// taggedResource is a resource of the stack with the given CloudFormation
+// logical ID.
+func taggedResource(arn, logicalID string) tagtypes.ResourceTagMapping {
+	return tagtypes.ResourceTagMapping{
+		ResourceARN: aws.String(arn),
+		Tags: []tagtypes.Tag{
+			{Key: aws.String("runs-on-stack-name"), Value: aws.String("runs-on")},
+			{Key: aws.String(cloudFormationLogicalIDTag), Value: aws.String(logicalID)},
+		},
+	}
+}
+
+func missingStackConfigSecretClient() *mockStackConfigSecretsClient {
+	return &mockStackConfigSecretsClient{
+		getSecretValue: func(context.Context, *secretsmanager.GetSecretValueInput, ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+			return nil, &secretstypes.ResourceNotFoundException{Message: aws.String("Secrets Manager can't find the specified secret.")}
+		},
+	}
+}
+
 func TestLoadRunsOnConfigFromStackSecret(t *testing.T) {
 	t.Parallel()
 
@@ -181,3 +222,142 @@ func TestDiscoverTaggedECSServiceARNPropagatesLookupErrors(t *testing.T) {
 		t.Fatalf("unexpected error %v", err)
 	}
 }
+
+func TestDiscoverStackConfigFallsBackToStackOutputsAndTags(t *testing.T) {
+	t.Parallel()
+
+	loader := stackConfigLoader{
+		secrets: missingStackConfigSecretClient(),
+		stacks: &mockCloudFormationStacksClient{
+			describeStacks: func(_ context.Context, input *cloudformation.DescribeStacksInput, _ ...func(*cloudformation.Options)) (*cloudformation.DescribeStacksOutput, error) {
+				if aws.ToString(input.StackName) != "runs-on" {
+					t.Fatalf("unexpected stack name %q", aws.ToString(input.StackName))
+				}
+				return stackWithOutputs(map[string]string{
+					"RunsOnEntryPoint":        "https://example.execute-api.us-east-1.amazonaws.com/prod",
+					"RunsOnWorkflowJobsTable": "runs-on-workflow-jobs",
+				}), nil
+			},
+		},
+		tagging: &mockTaggedResourcesClient{
+			getResources: func(_ context.Context, input *resourcegroupstaggingapi.GetResourcesInput, _ ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.GetResourcesOutput, error) {
+				return &resourcegroupstaggingapi.GetResourcesOutput{
+					ResourceTagMappingList: []tagtypes.ResourceTagMapping{
+						taggedResource("arn:aws:dynamodb:us-east-1:123456789012:table/runs-on-other-workflow-jobs", "RunsOnWorkflowJobsTable"),
+						taggedResource("arn:aws:logs:us-east-1:123456789012:log-group:/aws/ecs/runs-on/other:*", "RunsOnAccessLogGroup"),
+						taggedResource("arn:aws:logs:us-east-1:123456789012:log-group:/aws/ecs/runs-on/flexd:*", "RunsOnServiceLogGroupName"),
+						taggedResource("arn:aws:logs:us-east-1:123456789012:log-group:runs-on/ec2/instances", "RunsOnEc2InstanceLogGroupArn"),
+					},
+				}, nil
+			},
+		},
+	}
+
+	config, err := loadCachedRunsOnConfig(context.Background(), loader, "runs-on", aws.Config{Region: "us-east-1"})
+	if err != nil {
+		t.Fatalf("loadCachedRunsOnConfig returned error: %v", err)
+	}
+	if config.WorkflowJobsTable != "runs-on-workflow-jobs" || config.IngressURL != "https://example.execute-api.us-east-1.amazonaws.com/prod" {
+		t.Fatalf("expected stack outputs to win over tags, got %+v", config)
+	}
+	if config.ServiceLogGroupName != "/aws/ecs/runs-on/flexd" || config.EC2InstanceLogGroupArn != "arn:aws:logs:us-east-1:123456789012:log-group:runs-on/ec2/instances" {
+		t.Fatalf("expected log groups from tags, got %+v", config)
+	}
+	want := map[string]string{
+		"IngressURL":             configSourceCloudFormation,
+		"WorkflowJobsTable":      configSourceCloudFormation,
+		"ServiceLogGroupName":    configSourceTags,
+		"EC2InstanceLogGroupArn": configSourceTags,
+	}
+	for name, source := range want {
+		if config.Sources[name] != source {
+			t.Fatalf("expected %s to come from %s, got %v", name, source, config.Sources)
+		}
+	}
+}
+
+func TestDiscoverTaggedStackConfigMatchesLogicalIDs(t *testing.T) {
+	t.Parallel()
+
+	client := &mockTaggedResourcesClient{
+		getResources: func(context.Context, *resourcegroupstaggingapi.GetResourcesInput, ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.GetResourcesOutput, error) {
+			return &resourcegroupstaggingapi.GetResourcesOutput{
+				ResourceTagMappingList: []tagtypes.ResourceTagMapping{
+					{ResourceARN: aws.String("arn:aws:dynamodb:us-east-1:123456789012:table/runs-on-workflow-jobs-backup")},
+					taggedResource("arn:aws:dynamodb:us-east-1:123456789012:table/runs-on-workflow-jobs-archive", "WorkflowJobsArchiveTable"),
+					taggedResource("arn:aws:logs:us-east-1:123456789012:log-group:/aws/ecs/runs-on/flexd-debug", "DebugLogGroup"),
+					taggedResource("arn:aws:logs:us-east-1:123456789012:log-group:/aws/lambda/runs-on", "WorkflowJobsTable"),
+					taggedResource("arn:aws:dynamodb:us-east-1:123456789012:table/runs-on-jobs", "WorkflowJobsTable"),
+				},
+			}, nil
+		},
+	}
+
+	value, err := discoverTaggedStackConfig(context.Background(), client, "runs-on")
+	if err != nil {
+		t.Fatalf("discoverTaggedStackConfig returned error: %v", err)
+	}
+	if value.WorkflowJobsTable != "runs-on-jobs" {
+		t.Fatalf("expected the table with the WorkflowJobsTable logical ID, got %q", value.WorkflowJobsTable)
+	}
+	if value.ServiceLogGroupName != "" || value.EC2InstanceLogGroupArn != "" {
+		t.Fatalf("expected log groups without a known logical ID to be ignored, got %+v", value)
+	}
+}
+
+func TestDiscoverStackConfigSkipsFallbacksForCompleteSecret(t *testing.T) {
+	t.Parallel()
+
+	loader := stackConfigLoader{
+		secrets: &mockStackConfigSecretsClient{
+			getSecretValue: func(context.Context, *secretsmanager.GetSecretValueInput, ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+				secret := `{"WorkflowJobsTable":"workflow-jobs","IngressURL":"example.com","ServiceLogGroupName":"/aws/ecs/runs-on/flexd","Ec2InstanceLogGroupArn":"arn:aws:logs:us-east-1:123456789012:log-group:runs-on/ec2/instances"}`
+				return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(secret)}, nil
+			},
+		},
+		stacks: &mockCloudFormationStacksClient{
+			describeStacks: func(context.Context, *cloudformation.DescribeStacksInput, ...func(*cloudformation.Options)) (*cloudformation.DescribeStacksOutput, error) {
+				t.Fatal("expected no DescribeStacks call")
+				return nil, nil
+			},
+		},
+	}
+
+	discovered, err := discoverStackConfig(context.Background(), loader, "runs-on", aws.Config{Region: "us-east-1"})
+	if err != nil {
+		t.Fatalf("discoverStackConfig returned error: %v", err)
+	}
+	for _, field := range stackConfigFields {
+		if discovered.Sources[field.Name] != configSourceSecret {
+			t.Fatalf("expected every value from the secret, got %v", discovered.Sources)
+		}
+	}
+}
+
+func TestDiscoverStackConfigReportsEveryFailedSource(t *testing.T) {
+	t.Parallel()
+
+	loader := stackConfigLoader{
+		secrets: missingStackConfigSecretClient(),
+		stacks: &mockCloudFormationStacksClient{
+			describeStacks: func(context.Context, *cloudformation.DescribeStacksInput, ...func(*cloudformation.Options)) (*cloudformation.DescribeStacksOutput, error) {
+				return nil, errors.New("access denied")
+			},
+		},
+		tagging: &mockTaggedResourcesClient{
+			getResources: func(context.Context, *resourcegroupstaggingapi.GetResourcesInput, ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.GetResourcesOutput, error) {
+				return &resourcegroupstaggingapi.GetResourcesOutput{}, nil
+			},
+		},
+	}
+
+	_, err := discoverStackConfig(context.Background(), loader, "runs-on", aws.Config{Region: "us-east-1"})
+	if err == nil {
+		t.Fatal("expected discovery to fail")
+	}
+	for _, want := range []string{"/runs-on/runs-on/stack-config couldn't be found", `describe cloudformation stack "runs-on": access denied`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error to contain %q, got %v", want, err)
+		}
+	}
+}
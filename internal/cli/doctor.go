@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -9,10 +10,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
 	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
 	"github.com/spf13/cobra"
@@ -28,7 +31,9 @@ type DoctorCheck struct {
 type DoctorResult struct {
 	Timestamp time.Time     `json:"timestamp"`
 	StackName string        `json:"stack_name"`
+	Region    string        `json:"region,omitempty"`
 	Checks    []DoctorCheck `json:"checks"`
+	Archive   string        `json:"archive,omitempty"`
 }
 
 type doctorReadinessResponse struct {
@@ -46,6 +51,10 @@ type StackDoctor struct {
 	result     *DoctorResult
 	workDir    string
 	serviceARN string
+	out        io.Writer
+	// label names the stack and region in the archive file name when several
+	// stacks are diagnosed at once.
+	label string
 }
 
 func NewStackDoctor(config *RunsOnConfig) *StackDoctor {
@@ -58,9 +67,11 @@ func NewStackDoctor(config *RunsOnConfig) *StackDoctor {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		out: os.Stdout,
 		result: &DoctorResult{
 			Timestamp: time.Now(),
 			StackName: config.StackName,
+			Region:    config.AWSConfig.Region,
 			Checks:    []DoctorCheck{},
 		},
 	}
@@ -80,9 +91,9 @@ func (d *StackDoctor) addCheck(name, status, result string, err error) {
 
 func (d *StackDoctor) printCheckResult(status, details string) {
 	if details != "" {
-		fmt.Printf(" %s (%s)\n", status, details)
+		fmt.Fprintf(d.out, " %s (%s)\n", status, details)
 	} else {
-		fmt.Printf(" %s\n", status)
+		fmt.Fprintf(d.out, " %s\n", status)
 	}
 }
 
@@ -136,18 +147,18 @@ func (d *StackDoctor) checkService(ctx context.Context) error {
 func (d *StackDoctor) checkECSService(ctx context.Context, checkName string) error {
 	serviceArn, err := d.discoverServiceARN(ctx)
 	if err != nil {
-		fmt.Print("Checking service...")
+		fmt.Fprint(d.out, "Checking service...")
 		return d.failCheck(checkName, "Service ARN not found", err)
 	}
 
 	clusterName, serviceName, ok := parseDoctorECSServiceARN(serviceArn)
 	if !ok {
-		fmt.Print("Checking service...")
+		fmt.Fprint(d.out, "Checking service...")
 		return d.failCheck(checkName, "Invalid ECS service ARN", fmt.Errorf("parse ecs service ARN %q", serviceArn))
 	}
 
 	consoleURL := fmt.Sprintf("https://%s.console.aws.amazon.com/ecs/v2/clusters/%s/services/%s/configuration/overview", d.cfg.Region, clusterName, serviceName)
-	fmt.Printf("Checking service (%s)...", consoleURL)
+	fmt.Fprintf(d.out, "Checking service (%s)...", consoleURL)
 
 	output, err := d.ecs.DescribeServices(ctx, &ecs.DescribeServicesInput{
 		Cluster:  aws.String(clusterName),
@@ -191,11 +202,11 @@ func parseDoctorECSServiceARN(arn string) (string, string, bool) {
 func (d *StackDoctor) checkEndpointAccessibility() error {
 	entryPoint, err := d.getServiceURL()
 	if err != nil {
-		fmt.Print("Checking service endpoint...")
+		fmt.Fprint(d.out, "Checking service endpoint...")
 		return d.failCheck("Service endpoint accessible", "Failed to get service URL", err)
 	}
 
-	fmt.Printf("Checking service endpoint (%s)...", entryPoint)
+	fmt.Fprintf(d.out, "Checking service endpoint (%s)...", entryPoint)
 
 	// Check if endpoint is accessible
 	resp, err := d.httpClient.Get(entryPoint)
@@ -219,7 +230,7 @@ func (d *StackDoctor) checkEndpointAccessibility() error {
 }
 
 func (d *StackDoctor) checkReadiness() error {
-	fmt.Print("Checking service readiness...")
+	fmt.Fprint(d.out, "Checking service readiness...")
 
 	serviceURL, err := d.getServiceURL()
 	if err != nil {
@@ -275,21 +286,17 @@ func (d *StackDoctor) fetchLogsFromGroup(ctx context.Context, logGroupIdentifier
 	}
 	defer logFile.Close()
 
-	paginator := cloudwatchlogs.NewFilterLogEventsPaginator(d.cwl, input)
-	for paginator.HasMorePages() {
-		output, err := paginator.NextPage(ctx)
-		if err != nil {
-			return 0, err
-		}
-
-		for _, event := range output.Events {
-			timestamp := time.UnixMilli(*event.Timestamp).Format("2006-01-02T15:04:05.000Z")
-			line := fmt.Sprintf("%s [%s] %s\n", timestamp, *event.LogStreamName, *event.Message)
-			if _, err := logFile.WriteString(line); err != nil {
-				return 0, err
-			}
-			totalLines++
+	err = newLogFetcher(d.cwl, defaultLogConcurrency).fetch(ctx, input, func(event cwltypes.FilteredLogEvent) error {
+		timestamp := time.UnixMilli(*event.Timestamp).Format("2006-01-02T15:04:05.000Z")
+		line := fmt.Sprintf("%s [%s] %s\n", timestamp, *event.LogStreamName, *event.Message)
+		if _, err := logFile.WriteString(line); err != nil {
+			return err
 		}
+		totalLines++
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	return totalLines, nil
@@ -310,7 +317,7 @@ func (d *StackDoctor) fetchLogs(ctx context.Context, since time.Duration) (int,
 		return 0, nil
 	}
 
-	fmt.Printf("Fetching application logs (since %s)...", since)
+	fmt.Fprintf(d.out, "Fetching application logs (since %s)...", since)
 	appLines, err := d.fetchLogsFromGroup(ctx, serviceLogGroup, "application", since)
 	if err != nil {
 		return 0, d.failCheck("Application logs fetched", "Failed to fetch application logs", err)
@@ -340,6 +347,9 @@ func (d *StackDoctor) saveResults() error {
 func (d *StackDoctor) createZipFile() (string, error) {
 	timestamp := time.Now().Format("2006-01-02-15-04-05")
 	zipFileName := fmt.Sprintf("roc-doctor-%s.zip", timestamp)
+	if d.label != "" {
+		zipFileName = fmt.Sprintf("roc-doctor-%s-%s.zip", strings.ReplaceAll(d.label, "/", "-"), timestamp)
+	}
 
 	archive, err := newArchiveWriter(zipFileName)
 	if err != nil {
@@ -417,8 +427,42 @@ func (d *StackDoctor) Run(ctx context.Context, since time.Duration) error {
 		absPath = zipFileName
 	}
 
-	fmt.Printf("\nFull results exported to: %s\n", absPath)
+	d.result.Archive = absPath
+	fmt.Fprintf(d.out, "\nFull results exported to: %s\n", absPath)
+
+	return nil
+}
 
+// runStackDoctors diagnoses several stacks concurrently. The output of each
+// doctor is buffered and printed under a stack/region header once all of them
+// are done, so that checks of different stacks don't interleave.
+func runStackDoctors(ctx context.Context, out io.Writer, doctors []*StackDoctor, since time.Duration) error {
+	outputs := make([]bytes.Buffer, len(doctors))
+	errs := make([]error, len(doctors))
+	var wg sync.WaitGroup
+	for i, doctor := range doctors {
+		doctor.out = &outputs[i]
+		wg.Go(func() {
+			errs[i] = doctor.Run(ctx, since)
+		})
+	}
+	wg.Wait()
+
+	var failed []string
+	for i, doctor := range doctors {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "==> %s\n", doctor.label)
+		_, _ = outputs[i].WriteTo(out)
+		if errs[i] != nil {
+			fmt.Fprintf(out, "Error: %v\n", errs[i])
+			failed = append(failed, doctor.label)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("doctor failed for %s", strings.Join(failed, ", "))
+	}
 	return nil
 }
 
@@ -437,23 +481,53 @@ This command performs comprehensive health checks on your RunsOn stack:
 - Fetches application logs
 
 Results are exported as a timestamped ZIP file containing checks.json and logs.
+With --output json or yaml, the progress is printed on stderr and the results
+of each stack are written to stdout.
 
-The stack name can be overridden using the RUNS_ON_STACK_NAME or RUNS_ON_STACK environment variable.`,
+The stack name can be overridden using the RUNS_ON_STACK_NAME or RUNS_ON_STACK environment variable.
+When several stacks or regions are selected, every stack is diagnosed and one
+ZIP file is written per stack and region.`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			config, err := stack.getStackOutputs(cmd)
-			if err != nil {
-				return err
-			}
-
 			// Parse since duration
 			duration, err := time.ParseDuration(since)
 			if err != nil {
 				return fmt.Errorf("invalid --since value: %w", err)
 			}
 
-			doctor := NewStackDoctor(config)
-			return doctor.Run(cmd.Context(), duration)
+			configs, err := stack.getAllStackOutputs(cmd)
+			if err != nil {
+				return err
+			}
+			progress := cmd.OutOrStdout()
+			if structuredOutput(cmd) {
+				progress = cmd.ErrOrStderr()
+			}
+
+			doctors := make([]*StackDoctor, 0, len(configs))
+			for _, config := range configs {
+				doctor := NewStackDoctor(config)
+				doctor.out = progress
+				if len(configs) > 1 {
+					doctor.label = config.label()
+				}
+				doctors = append(doctors, doctor)
+			}
+			if len(doctors) == 1 {
+				err = doctors[0].Run(cmd.Context(), duration)
+			} else {
+				err = runStackDoctors(cmd.Context(), progress, doctors, duration)
+			}
+
+			if structuredOutput(cmd) {
+				events := newEventWriter(cmd.OutOrStdout(), outputFormat(cmd))
+				for _, doctor := range doctors {
+					if writeErr := events.write(doctor.result); writeErr != nil {
+						return writeErr
+					}
+				}
+			}
+			return err
 		},
 	}
 
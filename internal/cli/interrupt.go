@@ -51,19 +51,83 @@ const (
 	fisTargetLimit = 5
 )
 
+// interruptEvent is a progress event of roc interrupt, written as NDJSON or
+// YAML documents with --output json|yaml.
+type interruptEvent struct {
+	Time         time.Time `json:"time"`
+	Event        string    `json:"event"`
+	JobID        string    `json:"job_id,omitempty"`
+	InstanceIDs  []string  `json:"instance_ids,omitempty"`
+	ExperimentID string    `json:"experiment_id,omitempty"`
+	Status       string    `json:"status,omitempty"`
+	Region       string    `json:"region,omitempty"`
+	DelaySeconds int       `json:"delay_seconds,omitempty"`
+	Message      string    `json:"message"`
+}
+
+// interruptReporter reports the progress of an interruption: as text on out,
+// or as events when events is set.
+type interruptReporter struct {
+	out    io.Writer
+	events *eventWriter
+	logger *log.Logger
+	now    func() time.Time
+}
+
+func newInterruptReporter(cmd *cobra.Command, logger *log.Logger) *interruptReporter {
+	reporter := &interruptReporter{out: cmd.OutOrStdout(), logger: logger, now: time.Now}
+	if structuredOutput(cmd) {
+		reporter.events = newEventWriter(cmd.OutOrStdout(), outputFormat(cmd))
+	}
+	return reporter
+}
+
+// step reports a step that is always shown.
+func (r *interruptReporter) step(event interruptEvent) {
+	if r.events == nil {
+		fmt.Fprintln(r.out, event.Message)
+		return
+	}
+	r.emit(event)
+}
+
+// progress reports a detail that the text output only shows with --debug.
+func (r *interruptReporter) progress(icon string, event interruptEvent) {
+	if r.events == nil {
+		r.logger.Printf("%s %s\n", icon, event.Message)
+		return
+	}
+	r.emit(event)
+}
+
+func (r *interruptReporter) emit(event interruptEvent) {
+	event.Time = r.now().UTC()
+	if err := r.events.write(event); err != nil {
+		r.logger.Printf("write interrupt event: %v\n", err)
+	}
+}
+
 func NewInterruptCmd(stack *Stack) *cobra.Command {
 	var debug bool
 	var wait bool
 	var delay time.Duration
+	var jobName string
+	var asRun bool
+	var allJobs bool
 
 	cmd := &cobra.Command{
-		Use:           "interrupt JOB_ID|JOB_URL",
-		Short:         "Trigger a spot interruption on the instance running a specific job",
+		Use:   "interrupt JOB_ID|JOB_URL|RUN_ID|RUN_URL",
+		Short: "Trigger a spot interruption on the instance running a specific job",
+		Long: `Trigger a spot interruption on the instance running a specific job, using
+AWS Fault Injection Service.
+
+With --output json or yaml, the progress is written as one event per line
+(NDJSON) or per YAML document, each with an event type and a message.`,
 		Args:          cobra.ExactArgs(1),
 		SilenceUsage:  true,
 		SilenceErrors: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			config, err := stack.getStackOutputs(cmd)
+			config, err := stack.getSingleJobStackOutputs(cmd, args[0])
 			if err != nil {
 				return err
 			}
@@ -71,26 +135,40 @@ func NewInterruptCmd(stack *Stack) *cobra.Command {
 				return err
 			}
 
-			jobID := extractJobID(args[0])
 			ctx := cmd.Context()
 
 			logger := log.New(io.Discard, "", 0)
 			if debug {
 				logger.SetOutput(cmd.OutOrStderr())
 			}
+			reporter := newInterruptReporter(cmd, logger)
 
 			ec2Client := ec2.NewFromConfig(config.AWSConfig)
 			jobsClient := dynamodb.NewFromConfig(config.AWSConfig)
-			facts, err := waitForWorkflowJobFacts(ctx, jobsClient, config.WorkflowJobsTable, jobID, wait, logger)
+			selector := newWorkflowJobSelector(jobName, allJobs)
+			selector.Run = asRun
+			resolved, err := resolveWorkflowJobs(ctx, jobsClient, config.WorkflowJobsTable, args[0], selector)
 			if err != nil {
-				if !wait {
-					return fmt.Errorf("%w. Use -w to wait for instance", err)
-				}
 				return err
 			}
-			instanceID := facts.CurrentInstanceID
 
-			fmt.Printf("Found instance %s for job %s\n", instanceID, jobID)
+			var instanceIDs []string
+			for _, jobID := range resolved.JobIDs {
+				facts, err := waitForWorkflowJobFacts(ctx, jobsClient, config.WorkflowJobsTable, jobID, wait, logger)
+				if err != nil {
+					if !wait {
+						return fmt.Errorf("%w. Use -w to wait for instance", err)
+					}
+					return err
+				}
+				reporter.step(interruptEvent{
+					Event:       "instance_found",
+					JobID:       jobID,
+					InstanceIDs: []string{facts.CurrentInstanceID},
+					Message:     fmt.Sprintf("Found instance %s for job %s", facts.CurrentInstanceID, jobID),
+				})
+				instanceIDs = append(instanceIDs, facts.CurrentInstanceID)
+			}
 
 			// Log region for debugging
 			region := config.AWSConfig.Region
@@ -125,51 +203,48 @@ func NewInterruptCmd(stack *Stack) *cobra.Command {
 			}
 
 			// Check EC2 instance details
-			logger.Printf("Verifying instance %s details...\n", instanceID)
-
-			instanceResp, err := ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
-				InstanceIds: []string{instanceID},
-			})
-			if err != nil {
-				return fmt.Errorf("failed to describe instance %s: %w", instanceID, err)
-			}
-
-			if len(instanceResp.Reservations) == 0 || len(instanceResp.Reservations[0].Instances) == 0 {
-				return fmt.Errorf("instance %s not found", instanceID)
-			}
-
-			instance := instanceResp.Reservations[0].Instances[0]
-			logger.Printf("Instance lifecycle: %v, state: %v\n", instance.InstanceLifecycle, instance.State.Name)
-
-			if instance.InstanceLifecycle != "spot" {
-				return fmt.Errorf("instance %s is not a spot instance (lifecycle: %v). Spot interruptions can only be triggered on spot instances", instanceID, instance.InstanceLifecycle)
-			}
-
-			if instance.State.Name != "running" {
-				return fmt.Errorf("instance %s is not running (state: %v). Instance must be running to trigger spot interruption", instanceID, instance.State.Name)
+			for _, instanceID := range instanceIDs {
+				if err := verifySpotInstanceRunning(ctx, ec2Client, instanceID, logger); err != nil {
+					return err
+				}
 			}
 
-			logger.Printf("✓ Instance %s is a running spot instance\n", instanceID)
-
 			// Create AWS clients
 			iamClient := iam.NewFromConfig(config.AWSConfig)
 
 			// Trigger spot interruption
-			fmt.Printf("Triggering spot interruption on instance %s with %v delay in region %s...\n", instanceID, delay, region)
+			instances := strings.Join(instanceIDs, ", ")
+			reporter.step(interruptEvent{
+				Event:        "triggering",
+				InstanceIDs:  instanceIDs,
+				Region:       region,
+				DelaySeconds: int(delay.Seconds()),
+				Message:      fmt.Sprintf("Triggering spot interruption on instance %s with %v delay in region %s...", instances, delay, region),
+			})
 
-			experiment, err := createSpotInterruption(ctx, fisClient, iamClient, stsClient, []string{instanceID}, delay, region, logger)
+			experiment, err := createSpotInterruption(ctx, fisClient, iamClient, stsClient, instanceIDs, delay, region, logger)
 			if err != nil {
-				return fmt.Errorf("failed to trigger spot interruption in region %s: %w\n\nTroubleshooting:\n1. Ensure AWS FIS is available in your region\n2. Check IAM permissions for FIS, EC2, and IAM services\n3. Verify the instance %s exists and is a spot instance", region, err, instanceID)
+				return fmt.Errorf("failed to trigger spot interruption in region %s: %w\n\nTroubleshooting:\n1. Ensure AWS FIS is available in your region\n2. Check IAM permissions for FIS, EC2, and IAM services\n3. Verify the instance %s exists and is a spot instance", region, err, instances)
 			}
 
-			fmt.Printf("Started FIS experiment: %s\n", *experiment.Id)
+			experimentID := aws.ToString(experiment.Id)
+			reporter.step(interruptEvent{
+				Event:        "experiment_started",
+				ExperimentID: experimentID,
+				Message:      fmt.Sprintf("Started FIS experiment: %s", experimentID),
+			})
 
 			// Monitor experiment
-			if err := monitorExperiment(ctx, fisClient, experiment, delay, true, logger); err != nil {
+			if err := monitorExperiment(ctx, fisClient, experiment, delay, true, reporter); err != nil {
 				return fmt.Errorf("error monitoring experiment: %w", err)
 			}
 
-			fmt.Printf("Spot interruption completed for instance %s\n", instanceID)
+			reporter.step(interruptEvent{
+				Event:        "completed",
+				ExperimentID: experimentID,
+				InstanceIDs:  instanceIDs,
+				Message:      fmt.Sprintf("Spot interruption completed for instance %s", instances),
+			})
 			return nil
 		},
 	}
@@ -177,10 +252,42 @@ func NewInterruptCmd(stack *Stack) *cobra.Command {
 	cmd.Flags().BoolVar(&debug, "debug", false, "Enable debug output")
 	cmd.Flags().BoolVarP(&wait, "wait", "w", false, "Wait for instance ID if not found")
 	cmd.Flags().DurationVar(&delay, "delay", 5*time.Second, "Delay before interruption (e.g., 2m, 30s)")
+	cmd.Flags().StringVar(&jobName, "job-name", "", "When given a run, select the job with this name")
+	cmd.Flags().BoolVar(&asRun, "run", false, "Look up the argument as a workflow run ID")
+	cmd.Flags().BoolVar(&allJobs, "all", false, "When given a run, interrupt the instances of every job in the run")
 
 	return cmd
 }
 
+func verifySpotInstanceRunning(ctx context.Context, ec2Client *ec2.Client, instanceID string, logger *log.Logger) error {
+	logger.Printf("Verifying instance %s details...\n", instanceID)
+
+	instanceResp, err := ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: []string{instanceID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe instance %s: %w", instanceID, err)
+	}
+
+	if len(instanceResp.Reservations) == 0 || len(instanceResp.Reservations[0].Instances) == 0 {
+		return fmt.Errorf("instance %s not found", instanceID)
+	}
+
+	instance := instanceResp.Reservations[0].Instances[0]
+	logger.Printf("Instance lifecycle: %v, state: %v\n", instance.InstanceLifecycle, instance.State.Name)
+
+	if instance.InstanceLifecycle != "spot" {
+		return fmt.Errorf("instance %s is not a spot instance (lifecycle: %v). Spot interruptions can only be triggered on spot instances", instanceID, instance.InstanceLifecycle)
+	}
+
+	if instance.State.Name != "running" {
+		return fmt.Errorf("instance %s is not running (state: %v). Instance must be running to trigger spot interruption", instanceID, instance.State.Name)
+	}
+
+	logger.Printf("✓ Instance %s is a running spot instance\n", instanceID)
+	return nil
+}
+
 func createSpotInterruption(ctx context.Context, fisClient *fis.Client, iamClient *iam.Client, stsClient *sts.Client, instanceIDs []string, delay time.Duration, region string, logger *log.Logger) (*types.Experiment, error) {
 	// Get account ID
 	identity, err := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
@@ -297,8 +404,10 @@ func instanceIDsToARNs(instanceIDs []string, region string, accountID string) []
 	return arns
 }
 
-func monitorExperiment(ctx context.Context, fisClient *fis.Client, experiment *types.Experiment, delay time.Duration, clean bool, logger *log.Logger) error {
-	logger.Printf("✅ Rebalance Recommendation sent\n")
+func monitorExperiment(ctx context.Context, fisClient *fis.Client, experiment *types.Experiment, delay time.Duration, clean bool, reporter *interruptReporter) error {
+	logger := reporter.logger
+	experimentID := aws.ToString(experiment.Id)
+	reporter.progress("✅", interruptEvent{Event: "rebalance_recommendation_sent", ExperimentID: experimentID, Message: "Rebalance Recommendation sent"})
 
 	if clean {
 		defer func() {
@@ -314,7 +423,12 @@ func monitorExperiment(ctx context.Context, fisClient *fis.Client, experiment *t
 	// Wait for experiment delay
 	if experiment.StartTime != nil && time.Until(*experiment.StartTime) < delay {
 		timeUntilStart := delay - time.Until(*experiment.StartTime)
-		logger.Printf("⏳ Interruption will be sent in %d seconds\n", int(timeUntilStart.Seconds()))
+		reporter.progress("⏳", interruptEvent{
+			Event:        "waiting",
+			ExperimentID: experimentID,
+			DelaySeconds: int(timeUntilStart.Seconds()),
+			Message:      fmt.Sprintf("Interruption will be sent in %d seconds", int(timeUntilStart.Seconds())),
+		})
 		time.Sleep(timeUntilStart)
 	}
 
@@ -329,22 +443,27 @@ func monitorExperiment(ctx context.Context, fisClient *fis.Client, experiment *t
 				return fmt.Errorf("failed to get experiment status: %w", err)
 			}
 
-			switch experimentUpdate.Experiment.State.Status {
+			status := experimentUpdate.Experiment.State.Status
+			statusEvent := interruptEvent{Event: "status", ExperimentID: experimentID, Status: string(status)}
+			switch status {
 			case types.ExperimentStatusPending:
-				logger.Printf("⏰ Interruption Experiment is pending\n")
+				statusEvent.Message = "Interruption Experiment is pending"
+				reporter.progress("⏰", statusEvent)
 			case types.ExperimentStatusInitiating:
-				logger.Printf("🔧 Interruption Experiment is initializing\n")
+				statusEvent.Message = "Interruption Experiment is initializing"
+				reporter.progress("🔧", statusEvent)
 			case types.ExperimentStatusRunning:
-				logger.Printf("🚀 Interruption Experiment is running\n")
+				statusEvent.Message = "Interruption Experiment is running"
+				reporter.progress("🚀", statusEvent)
 			case types.ExperimentStatusFailed, types.ExperimentStatusStopped:
 				if experimentUpdate.Experiment.State.Reason != nil {
 					return fmt.Errorf("experiment failed: %s", *experimentUpdate.Experiment.State.Reason)
 				}
 				return fmt.Errorf("experiment failed with status: %s", experimentUpdate.Experiment.State.Status)
 			case types.ExperimentStatusCompleted:
-				logger.Printf("✅ Spot 2-minute Interruption Notification sent\n")
+				reporter.progress("✅", interruptEvent{Event: "interruption_notice_sent", ExperimentID: experimentID, Message: "Spot 2-minute Interruption Notification sent"})
 				time.Sleep(2 * time.Minute)
-				logger.Printf("✅ Spot Instance Shutdown sent\n")
+				reporter.progress("✅", interruptEvent{Event: "shutdown_sent", ExperimentID: experimentID, Message: "Spot Instance Shutdown sent"})
 				return nil
 			}
 		case <-ctx.Done():
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInterruptReporterText(t *testing.T) {
+	var output, debug bytes.Buffer
+	reporter := &interruptReporter{out: &output, logger: log.New(&debug, "", 0), now: time.Now}
+
+	reporter.step(interruptEvent{Event: "experiment_started", ExperimentID: "EXP1", Message: "Started FIS experiment: EXP1"})
+	reporter.progress("🚀", interruptEvent{Event: "status", Status: "running", Message: "Interruption Experiment is running"})
+
+	if output.String() != "Started FIS experiment: EXP1\n" {
+		t.Fatalf("unexpected step output %q", output.String())
+	}
+	if debug.String() != "🚀 Interruption Experiment is running\n" {
+		t.Fatalf("unexpected debug output %q", debug.String())
+	}
+}
+
+func TestInterruptReporterEvents(t *testing.T) {
+	now := time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC)
+	var output bytes.Buffer
+	reporter := &interruptReporter{
+		out:    &output,
+		events: newEventWriter(&output, outputJSON),
+		logger: log.New(io.Discard, "", 0),
+		now:    func() time.Time { return now },
+	}
+
+	reporter.step(interruptEvent{Event: "instance_found", JobID: "42", InstanceIDs: []string{"i-123"}, Message: "Found instance i-123 for job 42"})
+	reporter.progress("🚀", interruptEvent{Event: "status", ExperimentID: "EXP1", Status: "running", Message: "Interruption Experiment is running"})
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected two events, got:\n%s", output.String())
+	}
+	var event interruptEvent
+	if err := json.Unmarshal([]byte(lines[1]), &event); err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	if event.Event != "status" || event.Status != "running" || !event.Time.Equal(now) || strings.Contains(lines[1], "🚀") {
+		t.Fatalf("unexpected status event %s", lines[1])
+	}
+	if !strings.Contains(lines[0], `"instance_ids":["i-123"]`) || !strings.Contains(lines[0], `"job_id":"42"`) {
+		t.Fatalf("unexpected instance event %s", lines[0])
+	}
+}
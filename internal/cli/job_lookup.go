@@ -24,23 +24,30 @@ type workflowJobsAPI interface {
 type workflowJobFacts struct {
 	JobID                int64
 	RunID                int64
+	JobName              string
 	Status               string
+	Conclusion           string
 	SchedulingState      string
 	CurrentInstanceID    string
 	AttemptedInstanceIDs []string
 	CreatedAt            time.Time
 	CreatedAtSource      string
+	CompletedAt          time.Time
 	rawItem              map[string]dynamodbtypes.AttributeValue
 }
 
 type workflowJobFactsRecord struct {
 	JobID           int64      `dynamodbav:"job_id"`
 	RunID           int64      `dynamodbav:"run_id"`
+	JobName         string     `dynamodbav:"job_name"`
 	RunnerName      string     `dynamodbav:"runner_name"`
 	Status          string     `dynamodbav:"status"`
+	Conclusion      string     `dynamodbav:"conclusion"`
 	SchedulingState string     `dynamodbav:"scheduling_state"`
 	CreatedAt       *time.Time `dynamodbav:"created_at"`
 	CreatedAtUnix   int64      `dynamodbav:"created_at_unix"`
+	CompletedAt     *time.Time `dynamodbav:"completed_at"`
+	CompletedAtUnix int64      `dynamodbav:"completed_at_unix"`
 	ActiveAttempt   *struct {
 		InstanceID string `dynamodbav:"instance_id"`
 	} `dynamodbav:"active_attempt"`
@@ -64,6 +71,26 @@ func extractJobID(input string) string {
 	return input
 }
 
+func extractRunID(input string) string {
+	input = strings.TrimSpace(input)
+	parsed, err := url.Parse(input)
+	if err != nil || parsed.Scheme != "https" {
+		return input
+	}
+	// Extract run ID from URLs like:
+	//
+	// - https://github.com/runs-on/runs-on/actions/runs/12312372848
+	// - https://github.com/runs-on/runs-on/actions/runs/12312372848/job/34368864490
+	// - https://github.com/runs-on/runs-on/actions/runs/12312372848/attempts/2
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	for i := 0; i < len(parts)-1; i++ {
+		if parts[i] == "runs" {
+			return parts[i+1]
+		}
+	}
+	return input
+}
+
 func findWorkflowJobFacts(ctx context.Context, jobsClient workflowJobsAPI, tableName, jobRef string) (*workflowJobFacts, error) {
 	if jobsClient == nil {
 		return nil, fmt.Errorf("workflow jobs client is required")
@@ -185,12 +212,15 @@ func workflowJobFactsFromRecord(record workflowJobFactsRecord, rawItem map[strin
 	return &workflowJobFacts{
 		JobID:                record.JobID,
 		RunID:                record.RunID,
+		JobName:              record.JobName,
 		Status:               record.Status,
+		Conclusion:           record.Conclusion,
 		SchedulingState:      record.SchedulingState,
 		CurrentInstanceID:    workflowJobCurrentInstanceID(record),
 		AttemptedInstanceIDs: workflowJobAttemptedInstanceIDs(record),
 		CreatedAt:            createdAt,
 		CreatedAtSource:      createdAtSource,
+		CompletedAt:          workflowJobCompletedAtFromRecord(record),
 		rawItem:              rawItem,
 	}
 }
@@ -205,6 +235,16 @@ func workflowJobCreatedAtFromRecord(record workflowJobFactsRecord) (time.Time, s
 	return time.Time{}, ""
 }
 
+func workflowJobCompletedAtFromRecord(record workflowJobFactsRecord) time.Time {
+	if record.CompletedAt != nil && !record.CompletedAt.IsZero() {
+		return record.CompletedAt.UTC()
+	}
+	if record.CompletedAtUnix > 0 {
+		return time.Unix(record.CompletedAtUnix, 0).UTC()
+	}
+	return time.Time{}
+}
+
 func (f *workflowJobFacts) createdAtOrError() (time.Time, error) {
 	if f == nil {
 		return time.Time{}, fmt.Errorf("workflow job facts are required")
@@ -170,6 +170,19 @@ func TestWorkflowJobFactsCreatedAtFallsBackToUnix(t *testing.T) {
 	}
 }
 
+func TestWorkflowJobFactsCompletedAt(t *testing.T) {
+	completedAt := time.Date(2026, 5, 8, 14, 0, 0, 0, time.UTC)
+	facts := workflowJobFactsFromRecord(workflowJobFactsRecord{JobID: 43, CompletedAt: &completedAt}, nil)
+	if !facts.CompletedAt.Equal(completedAt) {
+		t.Fatalf("expected completed_at %s, got %s", completedAt, facts.CompletedAt)
+	}
+
+	facts = workflowJobFactsFromRecord(workflowJobFactsRecord{JobID: 43, CompletedAtUnix: completedAt.Unix()}, nil)
+	if !facts.CompletedAt.Equal(completedAt) {
+		t.Fatalf("expected completed_at_unix %s, got %s", completedAt, facts.CompletedAt)
+	}
+}
+
 func TestFindWorkflowJobFactsMissingRow(t *testing.T) {
 	client := &mockWorkflowJobsClient{
 		getItem: func(context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
@@ -0,0 +1,415 @@
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/spf13/cobra"
+)
+
+type workflowJobsScanAPI interface {
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+}
+
+type workflowJobFilter struct {
+	Statuses         []string
+	SchedulingStates []string
+	RunID            int64
+	InstanceID       string
+	Since            time.Time
+	Until            time.Time
+	Limit            int
+}
+
+type workflowJobListEntry struct {
+	Stack           string    `json:"stack,omitempty"`
+	Region          string    `json:"region,omitempty"`
+	JobID           int64     `json:"job_id"`
+	RunID           int64     `json:"run_id,omitempty"`
+	Status          string    `json:"status,omitempty"`
+	SchedulingState string    `json:"scheduling_state,omitempty"`
+	InstanceID      string    `json:"instance_id,omitempty"`
+	Instances       []string  `json:"attempted_instances,omitempty"`
+	CreatedAt       time.Time `json:"created_at,omitzero"`
+}
+
+func NewJobsCmd(stack *Stack) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "Inspect workflow jobs tracked by the RunsOn stack",
+		Long: `Inspect the workflow jobs recorded in the RunsOn workflow jobs DynamoDB table.
+
+The table is discovered from the stack config secret of the selected stack.`,
+	}
+
+	cmd.AddCommand(
+		NewJobsListCmd(stack),
+		NewJobsShowCmd(stack),
+	)
+
+	return cmd
+}
+
+func NewJobsListCmd(stack *Stack) *cobra.Command {
+	var (
+		statuses         []string
+		schedulingStates []string
+		runRef           string
+		instanceID       string
+		since            string
+		until            string
+		format           string
+		limit            int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List workflow jobs with optional filters",
+		Long: `List workflow jobs from the RunsOn workflow jobs table.
+
+Status, scheduling state and run ID filters are evaluated by DynamoDB. Time
+range and instance ID filters are applied locally, since the creation time and
+attempted instances can be stored in several attributes of a job record.
+
+Jobs are printed newest first. When several stacks or regions are selected,
+they are all queried and each job is labeled with its stack and region.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateTableFormat(format); err != nil {
+				return err
+			}
+			structured := !cmd.Flags().Changed("format") && structuredOutput(cmd)
+
+			now := time.Now()
+			filter := workflowJobFilter{
+				Statuses:         statuses,
+				SchedulingStates: schedulingStates,
+				InstanceID:       strings.TrimSpace(instanceID),
+				Limit:            limit,
+			}
+			var err error
+			if filter.Since, err = parseTimeFlag("since", since, now); err != nil {
+				return err
+			}
+			if filter.Until, err = parseTimeFlag("until", until, now); err != nil {
+				return err
+			}
+			if !filter.Since.IsZero() && !filter.Until.IsZero() && filter.Until.Before(filter.Since) {
+				return fmt.Errorf("--until must not be before --since")
+			}
+			if runRef != "" {
+				runID, err := strconv.ParseInt(extractRunID(runRef), 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid --run-id value %q: %w", runRef, err)
+				}
+				filter.RunID = runID
+			}
+
+			configs, err := stack.getAllStackOutputs(cmd)
+			if err != nil {
+				return err
+			}
+			for _, config := range configs {
+				if err := config.validateJobLookup(); err != nil {
+					return err
+				}
+			}
+
+			entries, err := listStacksWorkflowJobs(cmd.Context(), configs, func(config *RunsOnConfig) workflowJobsScanAPI {
+				return dynamodb.NewFromConfig(config.AWSConfig)
+			}, filter)
+			if err != nil {
+				return err
+			}
+			if structured {
+				if entries == nil {
+					entries = []workflowJobListEntry{}
+				}
+				return writeStructured(cmd.OutOrStdout(), outputFormat(cmd), entries)
+			}
+			return writeWorkflowJobEntries(cmd.OutOrStdout(), entries, format, len(configs) > 1)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&statuses, "status", nil, "Only show jobs with these statuses (e.g. queued,in_progress,completed)")
+	cmd.Flags().StringSliceVar(&schedulingStates, "scheduling-state", nil, "Only show jobs with these scheduling states")
+	cmd.Flags().StringVar(&runRef, "run-id", "", "Only show jobs of this workflow run ID or run URL")
+	cmd.Flags().StringVar(&instanceID, "instance-id", "", "Only show jobs that attempted this EC2 instance")
+	cmd.Flags().StringVar(&since, "since", "", "Only show jobs created after this duration ago or RFC3339 time (e.g. 2h, 2026-05-08T12:00:00Z)")
+	cmd.Flags().StringVar(&until, "until", "", "Only show jobs created before this duration ago or RFC3339 time")
+	cmd.Flags().IntVar(&limit, "limit", 0, "Maximum number of jobs to print (0 for no limit)")
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format: table, json, or csv (--output json|yaml applies when unset)")
+
+	return cmd
+}
+
+// validateTableFormat checks the --format of the commands printing a table.
+func validateTableFormat(format string) error {
+	switch format {
+	case "table", "json", "csv":
+		return nil
+	default:
+		return fmt.Errorf("invalid format %q (valid: table, json, csv)", format)
+	}
+}
+
+// parseTimeFlag accepts either a duration, interpreted as that long before
+// now, or an absolute RFC3339 timestamp. Empty values return the zero time.
+func parseTimeFlag(name, value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --%s value %q: expected a duration (e.g. 2h) or an RFC3339 time", name, value)
+}
+
+func workflowJobsScanInput(tableName string, filter workflowJobFilter) *dynamodb.ScanInput {
+	input := &dynamodb.ScanInput{
+		TableName: aws.String(tableName),
+	}
+
+	names := map[string]string{}
+	values := map[string]dynamodbtypes.AttributeValue{}
+	var conditions []string
+
+	addIn := func(attribute, placeholder string, options []string) {
+		var terms []string
+		for i, option := range options {
+			option = strings.TrimSpace(option)
+			if option == "" {
+				continue
+			}
+			valueKey := fmt.Sprintf(":%s%d", placeholder, i)
+			values[valueKey] = &dynamodbtypes.AttributeValueMemberS{Value: option}
+			terms = append(terms, valueKey)
+		}
+		if len(terms) == 0 {
+			return
+		}
+		nameKey := "#" + placeholder
+		names[nameKey] = attribute
+		conditions = append(conditions, fmt.Sprintf("%s IN (%s)", nameKey, strings.Join(terms, ", ")))
+	}
+
+	addIn("status", "status", filter.Statuses)
+	addIn("scheduling_state", "scheduling_state", filter.SchedulingStates)
+	if filter.RunID != 0 {
+		names["#run_id"] = "run_id"
+		values[":run_id"] = &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatInt(filter.RunID, 10)}
+		conditions = append(conditions, "#run_id = :run_id")
+	}
+
+	if len(conditions) > 0 {
+		input.FilterExpression = aws.String(strings.Join(conditions, " AND "))
+		input.ExpressionAttributeNames = names
+		input.ExpressionAttributeValues = values
+	}
+	return input
+}
+
+func listWorkflowJobs(ctx context.Context, client workflowJobsScanAPI, tableName string, filter workflowJobFilter) ([]*workflowJobFacts, error) {
+	if client == nil {
+		return nil, fmt.Errorf("workflow jobs client is required")
+	}
+	if tableName == "" {
+		return nil, fmt.Errorf("workflow jobs table is not configured")
+	}
+
+	var jobs []*workflowJobFacts
+	paginator := dynamodb.NewScanPaginator(client, workflowJobsScanInput(tableName, filter))
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan workflow jobs table: %w", err)
+		}
+		for _, item := range output.Items {
+			var record workflowJobFactsRecord
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal workflow job record: %w", err)
+			}
+			facts := workflowJobFactsFromRecord(record, item)
+			if filter.matches(facts) {
+				jobs = append(jobs, facts)
+			}
+		}
+	}
+
+	sort.SliceStable(jobs, func(i, j int) bool {
+		if !jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+		}
+		return jobs[i].JobID > jobs[j].JobID
+	})
+	if filter.Limit > 0 && len(jobs) > filter.Limit {
+		jobs = jobs[:filter.Limit]
+	}
+	return jobs, nil
+}
+
+// matches applies the filters that DynamoDB cannot evaluate reliably.
+func (f workflowJobFilter) matches(facts *workflowJobFacts) bool {
+	if facts == nil {
+		return false
+	}
+	if !f.Since.IsZero() && (facts.CreatedAt.IsZero() || facts.CreatedAt.Before(f.Since)) {
+		return false
+	}
+	if !f.Until.IsZero() && (facts.CreatedAt.IsZero() || facts.CreatedAt.After(f.Until)) {
+		return false
+	}
+	if f.InstanceID != "" && facts.CurrentInstanceID != f.InstanceID && !slices.Contains(facts.AttemptedInstanceIDs, f.InstanceID) {
+		return false
+	}
+	return true
+}
+
+func workflowJobListEntryFromFacts(facts *workflowJobFacts) workflowJobListEntry {
+	return workflowJobListEntry{
+		JobID:           facts.JobID,
+		RunID:           facts.RunID,
+		Status:          facts.Status,
+		SchedulingState: facts.SchedulingState,
+		InstanceID:      facts.CurrentInstanceID,
+		Instances:       facts.AttemptedInstanceIDs,
+		CreatedAt:       facts.CreatedAt,
+	}
+}
+
+// listStacksWorkflowJobs queries the workflow jobs table of every stack
+// concurrently and merges the results newest first. The limit applies to the
+// merged list.
+func listStacksWorkflowJobs(ctx context.Context, configs []*RunsOnConfig, clientFor func(*RunsOnConfig) workflowJobsScanAPI, filter workflowJobFilter) ([]workflowJobListEntry, error) {
+	results := make([][]*workflowJobFacts, len(configs))
+	errs := make([]error, len(configs))
+	var wg sync.WaitGroup
+	for i, config := range configs {
+		wg.Go(func() {
+			results[i], errs[i] = listWorkflowJobs(ctx, clientFor(config), config.WorkflowJobsTable, filter)
+		})
+	}
+	wg.Wait()
+
+	var entries []workflowJobListEntry
+	for i, config := range configs {
+		if errs[i] != nil {
+			if len(configs) == 1 {
+				return nil, errs[i]
+			}
+			return nil, fmt.Errorf("%s: %w", config.label(), errs[i])
+		}
+		for _, job := range results[i] {
+			entry := workflowJobListEntryFromFacts(job)
+			entry.Stack = config.StackName
+			entry.Region = config.AWSConfig.Region
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+	return entries, nil
+}
+
+func writeWorkflowJobList(w io.Writer, jobs []*workflowJobFacts, format string) error {
+	entries := make([]workflowJobListEntry, 0, len(jobs))
+	for _, job := range jobs {
+		entries = append(entries, workflowJobListEntryFromFacts(job))
+	}
+	return writeWorkflowJobEntries(w, entries, format, false)
+}
+
+// writeWorkflowJobEntries prints the job list. labeled adds stack and region
+// columns, for lists that span several stacks.
+func writeWorkflowJobEntries(w io.Writer, entries []workflowJobListEntry, format string, labeled bool) error {
+	if entries == nil {
+		entries = []workflowJobListEntry{}
+	}
+	row := func(entry workflowJobListEntry) []string {
+		if labeled {
+			return append([]string{entry.Stack, entry.Region}, workflowJobListRow(entry)...)
+		}
+		return workflowJobListRow(entry)
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(entries); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	case "csv":
+		writer := csv.NewWriter(w)
+		header := []string{"job_id", "run_id", "status", "scheduling_state", "instance_id", "created_at"}
+		if labeled {
+			header = append([]string{"stack", "region"}, header...)
+		}
+		_ = writer.Write(header)
+		for _, entry := range entries {
+			_ = writer.Write(row(entry))
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		header := "JOB ID\tRUN ID\tSTATUS\tSCHEDULING STATE\tINSTANCE ID\tCREATED AT"
+		if labeled {
+			header = "STACK\tREGION\t" + header
+		}
+		fmt.Fprintln(writer, header)
+		for _, entry := range entries {
+			row := row(entry)
+			for i, value := range row {
+				if value == "" {
+					row[i] = "-"
+				}
+			}
+			fmt.Fprintln(writer, strings.Join(row, "\t"))
+		}
+		return writer.Flush()
+	}
+}
+
+func workflowJobListRow(entry workflowJobListEntry) []string {
+	runID := ""
+	if entry.RunID != 0 {
+		runID = strconv.FormatInt(entry.RunID, 10)
+	}
+	createdAt := ""
+	if !entry.CreatedAt.IsZero() {
+		createdAt = entry.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		strconv.FormatInt(entry.JobID, 10),
+		runID,
+		entry.Status,
+		entry.SchedulingState,
+		entry.InstanceID,
+		createdAt,
+	}
+}
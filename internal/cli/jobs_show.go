@@ -0,0 +1,320 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/spf13/cobra"
+)
+
+type workflowJobTimelineEvent struct {
+	Time       time.Time     `json:"time,omitzero"`
+	Event      string        `json:"event"`
+	InstanceID string        `json:"instance_id,omitempty"`
+	Duration   time.Duration `json:"-"`
+	DurationS  float64       `json:"duration_seconds,omitempty"`
+}
+
+// workflowJobDetails is what roc jobs show prints about a job. It is built
+// from the same attributes as workflowJobFacts, with the labels and the
+// timestamps of the attempts when the item has them. Attributes holds every
+// attribute of the item, including those roc doesn't know about.
+type workflowJobDetails struct {
+	JobID              int64                      `json:"job_id"`
+	RunID              int64                      `json:"run_id,omitempty"`
+	JobName            string                     `json:"job_name,omitempty"`
+	RunnerName         string                     `json:"runner_name,omitempty"`
+	Labels             []string                   `json:"labels,omitempty"`
+	Status             string                     `json:"status,omitempty"`
+	Conclusion         string                     `json:"conclusion,omitempty"`
+	SchedulingState    string                     `json:"scheduling_state,omitempty"`
+	CreatedAt          time.Time                  `json:"created_at,omitzero"`
+	CompletedAt        time.Time                  `json:"completed_at,omitzero"`
+	CurrentInstanceID  string                     `json:"current_instance_id,omitempty"`
+	AttemptedInstances []string                   `json:"attempted_instances,omitempty"`
+	Timeline           []workflowJobTimelineEvent `json:"timeline"`
+	TotalDuration      float64                    `json:"total_duration_seconds,omitempty"`
+	Attributes         map[string]any             `json:"attributes"`
+}
+
+func NewJobsShowCmd(stack *Stack) *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "show JOB_ID|JOB_URL",
+		Short: "Show the lifecycle timeline of a workflow job",
+		Long: `Show everything the RunsOn workflow jobs table knows about a job, as a timeline.
+
+The timeline lists when the job was queued, each instance the job was
+attempted on, with the times the item records for it, and when the job
+completed. Each timed event shows how long it took until the next one. --json
+also prints every attribute of the item.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := stack.getJobStackOutputs(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			if err := config.validateJobLookup(); err != nil {
+				return err
+			}
+
+			jobID := extractJobID(args[0])
+			facts, err := findWorkflowJobFacts(cmd.Context(), dynamodb.NewFromConfig(config.AWSConfig), config.WorkflowJobsTable, jobID)
+			if err != nil {
+				return err
+			}
+			if facts == nil {
+				return fmt.Errorf("job %s not found in workflow jobs table", jobID)
+			}
+
+			details, err := workflowJobDetailsFromItem(facts.rawItem)
+			if err != nil {
+				return err
+			}
+			if details.JobID == 0 {
+				details.JobID = facts.JobID
+			}
+
+			if structuredOutput(cmd) && !jsonOutput {
+				return writeStructured(cmd.OutOrStdout(), outputFormat(cmd), details)
+			}
+			if jsonOutput {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(details); err != nil {
+					return fmt.Errorf("failed to encode JSON: %w", err)
+				}
+				return nil
+			}
+			return writeWorkflowJobDetails(cmd.OutOrStdout(), details)
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the decoded job record, its raw attributes and timeline as JSON (same as --output json)")
+
+	return cmd
+}
+
+func workflowJobDetailsFromItem(item map[string]dynamodbtypes.AttributeValue) (*workflowJobDetails, error) {
+	var record workflowJobFactsRecord
+	if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal workflow job record: %w", err)
+	}
+	attributes := make(map[string]any)
+	if err := attributevalue.UnmarshalMap(item, &attributes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal workflow job attributes: %w", err)
+	}
+	facts := workflowJobFactsFromRecord(record, item)
+
+	details := &workflowJobDetails{
+		JobID:              facts.JobID,
+		RunID:              facts.RunID,
+		JobName:            facts.JobName,
+		RunnerName:         record.RunnerName,
+		Labels:             workflowJobLabels(attributes["labels"]),
+		Status:             facts.Status,
+		Conclusion:         facts.Conclusion,
+		SchedulingState:    facts.SchedulingState,
+		CreatedAt:          facts.CreatedAt,
+		CompletedAt:        facts.CompletedAt,
+		CurrentInstanceID:  facts.CurrentInstanceID,
+		AttemptedInstances: facts.AttemptedInstanceIDs,
+		Timeline:           workflowJobTimeline(record, attributes, facts),
+		Attributes:         attributes,
+	}
+	if start, end := facts.CreatedAt, facts.CompletedAt; !start.IsZero() && end.After(start) {
+		details.TotalDuration = end.Sub(start).Seconds()
+	}
+	return details, nil
+}
+
+// workflowJobLabels returns the labels attribute of a job, a list or a set of
+// strings.
+func workflowJobLabels(value any) []string {
+	var labels []string
+	switch value := value.(type) {
+	case []string:
+		labels = value
+	case []any:
+		for _, label := range value {
+			if label, ok := label.(string); ok {
+				labels = append(labels, label)
+			}
+		}
+	}
+	return labels
+}
+
+// workflowJobAttempts returns the attempts of a job in the order they were
+// made: the attempt history, then the active attempt unless the history
+// already has its instance. Without attempts, the instance of the runner name
+// is the only attempt.
+func workflowJobAttempts(record workflowJobFactsRecord, attributes map[string]any) []map[string]any {
+	var attempts []map[string]any
+	seen := make(map[string]bool)
+	if history, ok := attributes["attempt_history"].([]any); ok {
+		for _, attempt := range history {
+			if attempt, ok := attempt.(map[string]any); ok {
+				instanceID, _ := attempt["instance_id"].(string)
+				seen[strings.TrimSpace(instanceID)] = true
+				attempts = append(attempts, attempt)
+			}
+		}
+	}
+	if active, ok := attributes["active_attempt"].(map[string]any); ok {
+		if instanceID, _ := active["instance_id"].(string); !seen[strings.TrimSpace(instanceID)] {
+			attempts = append(attempts, active)
+		}
+	}
+	if len(attempts) == 0 {
+		if instanceID := parseRunnerNameInstanceID(record.RunnerName); instanceID != "" {
+			attempts = append(attempts, map[string]any{"instance_id": instanceID})
+		}
+	}
+	return attempts
+}
+
+// workflowJobTimeline lists the lifecycle of the job: queued, the attempts
+// and completion. An attempt is listed with each time the item records for
+// it, e.g. launched_at, or once without a time. Each timed event lasts until
+// the next timed one, so durations skip the events without a time.
+func workflowJobTimeline(record workflowJobFactsRecord, attributes map[string]any, facts *workflowJobFacts) []workflowJobTimelineEvent {
+	events := []workflowJobTimelineEvent{{Time: facts.CreatedAt, Event: "queued"}}
+	attempts := workflowJobAttempts(record, attributes)
+	for i, attempt := range attempts {
+		events = append(events, workflowJobAttemptEvents(i+1, i < len(attempts)-1, attempt)...)
+	}
+	if !facts.CompletedAt.IsZero() || facts.Status == "completed" {
+		completed := "completed"
+		if facts.Conclusion != "" {
+			completed += fmt.Sprintf(" (%s)", facts.Conclusion)
+		}
+		events = append(events, workflowJobTimelineEvent{Time: facts.CompletedAt, Event: completed})
+	}
+
+	for i := range events {
+		if events[i].Time.IsZero() {
+			continue
+		}
+		for j := i + 1; j < len(events); j++ {
+			if events[j].Time.IsZero() {
+				continue
+			}
+			if events[j].Time.After(events[i].Time) {
+				events[i].Duration = events[j].Time.Sub(events[i].Time)
+				events[i].DurationS = events[i].Duration.Seconds()
+			}
+			break
+		}
+	}
+	return events
+}
+
+// workflowJobAttemptEvents returns the events of an attempt: one per
+// attribute of the attempt ending in _at, by time, or a single untimed event.
+// retried marks the attempts followed by another one.
+func workflowJobAttemptEvents(number int, retried bool, attempt map[string]any) []workflowJobTimelineEvent {
+	instanceID, _ := attempt["instance_id"].(string)
+	instanceID = strings.TrimSpace(instanceID)
+	label := fmt.Sprintf("attempt %d", number)
+	var events []workflowJobTimelineEvent
+	for key, value := range attempt {
+		text, ok := value.(string)
+		if !ok || !strings.HasSuffix(key, "_at") {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339Nano, text)
+		if err != nil {
+			continue
+		}
+		name := strings.ReplaceAll(strings.TrimSuffix(key, "_at"), "_", " ")
+		events = append(events, workflowJobTimelineEvent{Time: at, Event: fmt.Sprintf("%s: %s", label, name), InstanceID: instanceID})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Time.Equal(events[j].Time) {
+			return events[i].Event < events[j].Event
+		}
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	if len(events) == 0 {
+		event := fmt.Sprintf("%s: instance assigned", label)
+		if retried {
+			event = fmt.Sprintf("%s: instance replaced, retried", label)
+		}
+		return []workflowJobTimelineEvent{{Event: event, InstanceID: instanceID}}
+	}
+	last := &events[len(events)-1]
+	if reason, _ := attempt["end_reason"].(string); reason != "" {
+		last.Event += fmt.Sprintf(" (%s)", reason)
+	}
+	if retried {
+		last.Event += ", retried"
+	}
+	return events
+}
+
+func writeWorkflowJobDetails(w io.Writer, details *workflowJobDetails) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	field := func(name, value string) {
+		if strings.TrimSpace(value) != "" {
+			fmt.Fprintf(writer, "%s:\t%s\n", name, value)
+		}
+	}
+
+	field("Job", fmt.Sprintf("%d", details.JobID))
+	if details.RunID != 0 {
+		field("Run", fmt.Sprintf("%d", details.RunID))
+	}
+	field("Job name", details.JobName)
+	status := details.Status
+	if details.Conclusion != "" {
+		status += fmt.Sprintf(" (%s)", details.Conclusion)
+	}
+	field("Status", status)
+	field("Scheduling state", details.SchedulingState)
+	field("Runner", details.RunnerName)
+	field("Labels", strings.Join(details.Labels, ", "))
+	field("Instances", strings.Join(details.AttemptedInstances, ", "))
+	if details.TotalDuration > 0 {
+		field("Total duration", formatTimelineDuration(time.Duration(details.TotalDuration*float64(time.Second))))
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "\nTimeline:")
+	writer = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, event := range details.Timeline {
+		at := "unknown time"
+		if !event.Time.IsZero() {
+			at = event.Time.Local().Format("2006-01-02T15:04:05Z07:00")
+		}
+		instance := event.InstanceID
+		if instance == "" {
+			instance = "-"
+		}
+		duration := ""
+		if event.Duration > 0 {
+			duration = "+" + formatTimelineDuration(event.Duration)
+		}
+		fmt.Fprintf(writer, "  %s\t%s\t%s\t%s\n", at, event.Event, instance, duration)
+	}
+	return writer.Flush()
+}
+
+func formatTimelineDuration(duration time.Duration) string {
+	if duration >= time.Minute {
+		return duration.Round(time.Second).String()
+	}
+	return duration.Round(100 * time.Millisecond).String()
+}
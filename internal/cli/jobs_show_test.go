@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+)
+
+func TestWorkflowJobDetailsTimelineCoversRetriesAndCompletion(t *testing.T) {
+	createdAt := time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC)
+	completedAt := createdAt.Add(20 * time.Minute)
+	item, err := attributevalue.MarshalMap(workflowJobFactsRecord{
+		JobID:       42,
+		RunID:       1234,
+		JobName:     "test (linux)",
+		RunnerName:  "runs-on--i-second--job",
+		Status:      "completed",
+		Conclusion:  "success",
+		CreatedAt:   &createdAt,
+		CompletedAt: &completedAt,
+		ActiveAttempt: &struct {
+			InstanceID string `dynamodbav:"instance_id"`
+		}{InstanceID: "i-second"},
+		AttemptHistory: []struct {
+			InstanceID string `dynamodbav:"instance_id"`
+		}{
+			{InstanceID: "i-first"},
+			{InstanceID: "i-second"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("marshal workflow job record: %v", err)
+	}
+
+	details, err := workflowJobDetailsFromItem(item)
+	if err != nil {
+		t.Fatalf("workflowJobDetailsFromItem returned error: %v", err)
+	}
+
+	var got []string
+	for _, event := range details.Timeline {
+		got = append(got, event.Event+" "+event.InstanceID)
+	}
+	want := []string{
+		"queued ",
+		"attempt 1: instance replaced, retried i-first",
+		"attempt 2: instance assigned i-second",
+		"completed (success) ",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected timeline:\nwant: %q\n got: %q", want, got)
+	}
+	if details.TotalDuration != (20 * time.Minute).Seconds() {
+		t.Fatalf("expected total duration of 20m, got %fs", details.TotalDuration)
+	}
+	if got := strings.Join(details.AttemptedInstances, ","); got != "i-first,i-second" {
+		t.Fatalf("unexpected attempted instances %q", got)
+	}
+
+	var text bytes.Buffer
+	if err := writeWorkflowJobDetails(&text, details); err != nil {
+		t.Fatalf("writeWorkflowJobDetails returned error: %v", err)
+	}
+	for _, want := range []string{"Status:", "completed (success)", "Job name:", "test (linux)", "Runner:", "Timeline:", "Total duration:", "20m0s"} {
+		if !strings.Contains(text.String(), want) {
+			t.Fatalf("expected text output to contain %q:\n%s", want, text.String())
+		}
+	}
+
+	data, err := json.Marshal(details)
+	if err != nil {
+		t.Fatalf("marshal details: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal details: %v", err)
+	}
+	if decoded["job_id"] != float64(42) || decoded["conclusion"] != "success" || decoded["completed_at"] != "2026-05-08T12:20:00Z" {
+		t.Fatalf("expected record fields at the top level of the JSON output: %s", data)
+	}
+	if timeline, ok := decoded["timeline"].([]any); !ok || len(timeline) != len(want) {
+		t.Fatalf("expected timeline in JSON output: %s", data)
+	}
+}
+
+func TestWorkflowJobDetailsFallsBackToCreatedAtUnixAndRunnerName(t *testing.T) {
+	createdAt := time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC)
+	item, err := attributevalue.MarshalMap(workflowJobFactsRecord{
+		JobID:         43,
+		Status:        "in_progress",
+		CreatedAtUnix: createdAt.Unix(),
+		RunnerName:    "runs-on--i-runner--job",
+	})
+	if err != nil {
+		t.Fatalf("marshal workflow job record: %v", err)
+	}
+
+	details, err := workflowJobDetailsFromItem(item)
+	if err != nil {
+		t.Fatalf("workflowJobDetailsFromItem returned error: %v", err)
+	}
+	if !details.CreatedAt.Equal(createdAt) {
+		t.Fatalf("expected created_at_unix fallback, got %v", details.CreatedAt)
+	}
+	if len(details.Timeline) != 2 || !details.Timeline[1].Time.IsZero() || details.Timeline[1].InstanceID != "i-runner" {
+		t.Fatalf("expected an untimed attempt on the runner instance after the queued event, got %+v", details.Timeline)
+	}
+	if details.TotalDuration != 0 {
+		t.Fatalf("expected no total duration for a running job, got %f", details.TotalDuration)
+	}
+}
+
+func TestWorkflowJobDetailsShowsLabelsAttemptTimesAndRawAttributes(t *testing.T) {
+	item, err := attributevalue.MarshalMap(map[string]any{
+		"job_id":       44,
+		"status":       "completed",
+		"conclusion":   "failure",
+		"created_at":   "2026-05-08T12:00:00Z",
+		"completed_at": "2026-05-08T12:10:00Z",
+		"labels":       []string{"runs-on", "runner=2cpu-linux-x64"},
+		"attempt_history": []map[string]any{
+			{"instance_id": "i-first", "instance_type": "m7a.large"},
+			{"instance_id": "i-second", "launched_at": "2026-05-08T12:04:00Z", "ended_at": "2026-05-08T12:09:00Z", "end_reason": "job completed"},
+		},
+		"future_attribute": "kept",
+	})
+	if err != nil {
+		t.Fatalf("marshal workflow job item: %v", err)
+	}
+
+	details, err := workflowJobDetailsFromItem(item)
+	if err != nil {
+		t.Fatalf("workflowJobDetailsFromItem returned error: %v", err)
+	}
+	if got := strings.Join(details.Labels, ","); got != "runs-on,runner=2cpu-linux-x64" {
+		t.Fatalf("unexpected labels %q", got)
+	}
+
+	var got []string
+	for _, event := range details.Timeline {
+		got = append(got, fmt.Sprintf("%s %s", event.Event, event.Duration))
+	}
+	want := []string{
+		"queued 4m0s",
+		"attempt 1: instance replaced, retried 0s",
+		"attempt 2: launched 5m0s",
+		"attempt 2: ended (job completed) 1m0s",
+		"completed (failure) 0s",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected timeline:\nwant: %q\n got: %q", want, got)
+	}
+
+	var text bytes.Buffer
+	if err := writeWorkflowJobDetails(&text, details); err != nil {
+		t.Fatalf("writeWorkflowJobDetails returned error: %v", err)
+	}
+	if !strings.Contains(text.String(), "runs-on, runner=2cpu-linux-x64") {
+		t.Fatalf("expected labels in text output:\n%s", text.String())
+	}
+
+	data, err := json.Marshal(details)
+	if err != nil {
+		t.Fatalf("marshal details: %v", err)
+	}
+	var decoded struct {
+		Attributes map[string]any `json:"attributes"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal details: %v", err)
+	}
+	history, _ := decoded.Attributes["attempt_history"].([]any)
+	if decoded.Attributes["future_attribute"] != "kept" || len(history) != 2 || history[0].(map[string]any)["instance_type"] != "m7a.large" {
+		t.Fatalf("expected every attribute of the item in the JSON output: %s", data)
+	}
+}
@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type mockWorkflowJobsScanClient struct {
+	inputs []*dynamodb.ScanInput
+	pages  [][]map[string]dynamodbtypes.AttributeValue
+}
+
+func (m *mockWorkflowJobsScanClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	m.inputs = append(m.inputs, params)
+	page := len(m.inputs) - 1
+	output := &dynamodb.ScanOutput{}
+	if page < len(m.pages) {
+		output.Items = m.pages[page]
+	}
+	if page+1 < len(m.pages) {
+		output.LastEvaluatedKey = map[string]dynamodbtypes.AttributeValue{
+			"job_id": &dynamodbtypes.AttributeValueMemberN{Value: "1"},
+		}
+	}
+	return output, nil
+}
+
+func TestWorkflowJobsScanInputBuildsServerSideFilters(t *testing.T) {
+	input := workflowJobsScanInput("workflow-jobs", workflowJobFilter{
+		Statuses:         []string{"queued", "in_progress"},
+		SchedulingStates: []string{"launching"},
+		RunID:            1234,
+	})
+
+	if got := aws.ToString(input.TableName); got != "workflow-jobs" {
+		t.Fatalf("unexpected table name %q", got)
+	}
+	want := "#status IN (:status0, :status1) AND #scheduling_state IN (:scheduling_state0) AND #run_id = :run_id"
+	if got := aws.ToString(input.FilterExpression); got != want {
+		t.Fatalf("unexpected filter expression:\nwant: %s\n got: %s", want, got)
+	}
+	if got := input.ExpressionAttributeNames["#scheduling_state"]; got != "scheduling_state" {
+		t.Fatalf("unexpected scheduling state attribute name %q", got)
+	}
+	runID, ok := input.ExpressionAttributeValues[":run_id"].(*dynamodbtypes.AttributeValueMemberN)
+	if !ok || runID.Value != "1234" {
+		t.Fatalf("expected numeric run ID value, got %#v", input.ExpressionAttributeValues[":run_id"])
+	}
+
+	if input := workflowJobsScanInput("workflow-jobs", workflowJobFilter{}); input.FilterExpression != nil {
+		t.Fatalf("expected no filter expression without filters, got %q", aws.ToString(input.FilterExpression))
+	}
+}
+
+func TestListWorkflowJobsAppliesLocalFiltersAndSortsNewestFirst(t *testing.T) {
+	base := time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC)
+	older := base.Add(-3 * time.Hour)
+	newer := base.Add(time.Hour)
+	client := &mockWorkflowJobsScanClient{
+		pages: [][]map[string]dynamodbtypes.AttributeValue{
+			{
+				marshalWorkflowJobItem(t, workflowJobFactsRecord{
+					JobID:         1,
+					RunID:         10,
+					CreatedAtUnix: base.Unix(),
+					RunnerName:    "runs-on--i-match--job",
+				}),
+				marshalWorkflowJobItem(t, workflowJobFactsRecord{
+					JobID:      2,
+					RunID:      10,
+					CreatedAt:  &older,
+					RunnerName: "runs-on--i-match--job",
+				}),
+			},
+			{
+				marshalWorkflowJobItem(t, workflowJobFactsRecord{
+					JobID:     3,
+					RunID:     10,
+					CreatedAt: &newer,
+					AttemptHistory: []struct {
+						InstanceID string `dynamodbav:"instance_id"`
+					}{{InstanceID: "i-match"}, {InstanceID: "i-other"}},
+				}),
+				marshalWorkflowJobItem(t, workflowJobFactsRecord{
+					JobID:     4,
+					RunID:     10,
+					CreatedAt: &newer,
+					ActiveAttempt: &struct {
+						InstanceID string `dynamodbav:"instance_id"`
+					}{InstanceID: "i-other"},
+				}),
+			},
+		},
+	}
+
+	jobs, err := listWorkflowJobs(context.Background(), client, "workflow-jobs", workflowJobFilter{
+		InstanceID: "i-match",
+		Since:      base.Add(-time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("listWorkflowJobs returned error: %v", err)
+	}
+	if len(client.inputs) != 2 {
+		t.Fatalf("expected both scan pages to be fetched, got %d", len(client.inputs))
+	}
+
+	var ids []int64
+	for _, job := range jobs {
+		ids = append(ids, job.JobID)
+	}
+	if len(ids) != 2 || ids[0] != 3 || ids[1] != 1 {
+		t.Fatalf("expected jobs [3 1], got %v", ids)
+	}
+}
+
+func TestListWorkflowJobsHonorsLimit(t *testing.T) {
+	client := &mockWorkflowJobsScanClient{
+		pages: [][]map[string]dynamodbtypes.AttributeValue{{
+			marshalWorkflowJobItem(t, workflowJobFactsRecord{JobID: 1, CreatedAtUnix: 100}),
+			marshalWorkflowJobItem(t, workflowJobFactsRecord{JobID: 2, CreatedAtUnix: 200}),
+		}},
+	}
+
+	jobs, err := listWorkflowJobs(context.Background(), client, "workflow-jobs", workflowJobFilter{Limit: 1})
+	if err != nil {
+		t.Fatalf("listWorkflowJobs returned error: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].JobID != 2 {
+		t.Fatalf("expected only the newest job, got %+v", jobs)
+	}
+}
+
+func TestWriteWorkflowJobListFormats(t *testing.T) {
+	createdAt := time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC)
+	jobs := []*workflowJobFacts{
+		{
+			JobID:                42,
+			RunID:                1234,
+			Status:               "queued",
+			SchedulingState:      "launching",
+			CurrentInstanceID:    "i-123",
+			AttemptedInstanceIDs: []string{"i-123"},
+			CreatedAt:            createdAt,
+		},
+		{JobID: 43},
+	}
+
+	var table bytes.Buffer
+	if err := writeWorkflowJobList(&table, jobs, "table"); err != nil {
+		t.Fatalf("table output returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "JOB ID") {
+		t.Fatalf("unexpected table output:\n%s", table.String())
+	}
+	if !strings.Contains(lines[1], "i-123") || !strings.Contains(lines[1], "2026-05-08T12:00:00Z") {
+		t.Fatalf("expected job row to contain instance and creation time: %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "-") {
+		t.Fatalf("expected empty columns to render as '-': %q", lines[2])
+	}
+
+	var csvOutput bytes.Buffer
+	if err := writeWorkflowJobList(&csvOutput, jobs, "csv"); err != nil {
+		t.Fatalf("csv output returned error: %v", err)
+	}
+	wantCSV := "job_id,run_id,status,scheduling_state,instance_id,created_at\n42,1234,queued,launching,i-123,2026-05-08T12:00:00Z\n43,,,,,\n"
+	if csvOutput.String() != wantCSV {
+		t.Fatalf("unexpected csv output:\nwant: %q\n got: %q", wantCSV, csvOutput.String())
+	}
+
+	var jsonOutput bytes.Buffer
+	if err := writeWorkflowJobList(&jsonOutput, jobs, "json"); err != nil {
+		t.Fatalf("json output returned error: %v", err)
+	}
+	var entries []map[string]any
+	if err := json.Unmarshal(jsonOutput.Bytes(), &entries); err != nil {
+		t.Fatalf("json output did not parse: %v", err)
+	}
+	if len(entries) != 2 || entries[0]["instance_id"] != "i-123" {
+		t.Fatalf("unexpected json entries: %v", entries)
+	}
+	if _, ok := entries[1]["created_at"]; ok {
+		t.Fatalf("expected zero created_at to be omitted: %v", entries[1])
+	}
+}
+
+func TestListStacksWorkflowJobsMergesAndLabelsStacks(t *testing.T) {
+	base := time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC)
+	clients := map[string]*mockWorkflowJobsScanClient{
+		"jobs-east": {pages: [][]map[string]dynamodbtypes.AttributeValue{{
+			marshalWorkflowJobItem(t, workflowJobFactsRecord{JobID: 1, CreatedAtUnix: base.Unix()}),
+			marshalWorkflowJobItem(t, workflowJobFactsRecord{JobID: 3, CreatedAtUnix: base.Add(2 * time.Hour).Unix()}),
+		}}},
+		"jobs-west": {pages: [][]map[string]dynamodbtypes.AttributeValue{{
+			marshalWorkflowJobItem(t, workflowJobFactsRecord{JobID: 2, CreatedAtUnix: base.Add(time.Hour).Unix()}),
+		}}},
+	}
+	configs := []*RunsOnConfig{
+		{StackName: "runs-on", WorkflowJobsTable: "jobs-east", AWSConfig: aws.Config{Region: "us-east-1"}},
+		{StackName: "runs-on", WorkflowJobsTable: "jobs-west", AWSConfig: aws.Config{Region: "eu-west-1"}},
+	}
+
+	entries, err := listStacksWorkflowJobs(context.Background(), configs, func(config *RunsOnConfig) workflowJobsScanAPI {
+		return clients[config.WorkflowJobsTable]
+	}, workflowJobFilter{Limit: 2})
+	if err != nil {
+		t.Fatalf("listStacksWorkflowJobs returned error: %v", err)
+	}
+	if len(entries) != 2 || entries[0].JobID != 3 || entries[1].JobID != 2 || entries[1].Region != "eu-west-1" {
+		t.Fatalf("expected merged jobs newest first with limit applied, got %+v", entries)
+	}
+
+	var table bytes.Buffer
+	if err := writeWorkflowJobEntries(&table, entries, "table", true); err != nil {
+		t.Fatalf("writeWorkflowJobEntries returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	if !strings.HasPrefix(lines[0], "STACK") || !strings.HasPrefix(lines[2], "runs-on  eu-west-1  2 ") {
+		t.Fatalf("expected stack and region columns:\n%s", table.String())
+	}
+
+	var csvOutput bytes.Buffer
+	if err := writeWorkflowJobEntries(&csvOutput, entries, "csv", true); err != nil {
+		t.Fatalf("writeWorkflowJobEntries returned error: %v", err)
+	}
+	if !strings.HasPrefix(csvOutput.String(), "stack,region,job_id,") {
+		t.Fatalf("expected labeled CSV header:\n%s", csvOutput.String())
+	}
+}
+
+func TestParseTimeFlagAcceptsDurationsAndRFC3339(t *testing.T) {
+	now := time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC)
+
+	got, err := parseTimeFlag("since", "2h", now)
+	if err != nil || !got.Equal(now.Add(-2*time.Hour)) {
+		t.Fatalf("expected duration to resolve relative to now, got %s (%v)", got, err)
+	}
+	got, err = parseTimeFlag("since", "2026-05-07T10:00:00Z", now)
+	if err != nil || !got.Equal(time.Date(2026, 5, 7, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected RFC3339 time, got %s (%v)", got, err)
+	}
+	if got, err := parseTimeFlag("since", "", now); err != nil || !got.IsZero() {
+		t.Fatalf("expected empty value to return zero time, got %s (%v)", got, err)
+	}
+	if _, err := parseTimeFlag("since", "yesterday", now); err == nil || !strings.Contains(err.Error(), "--since") {
+		t.Fatalf("expected invalid value error naming the flag, got %v", err)
+	}
+}
+
+func TestExtractRunID(t *testing.T) {
+	for input, want := range map[string]string{
+		"12312372848": "12312372848",
+		"https://github.com/runs-on/runs-on/actions/runs/12312372848":                   "12312372848",
+		"https://github.com/runs-on/runs-on/actions/runs/12312372848/job/34368864490":   "12312372848",
+		"https://github.com/runs-on/runs-on/actions/runs/12312372848/attempts/2?pr=123": "12312372848",
+	} {
+		if got := extractRunID(input); got != want {
+			t.Fatalf("extractRunID(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
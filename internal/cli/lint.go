@@ -19,8 +19,9 @@ func NewLintCmd() *cobra.Command {
 	var stdin bool
 
 	cmd := &cobra.Command{
-		Use:   "lint [flags] [file]",
-		Short: "Validate runs-on.yml configuration files",
+		Use:         "lint [flags] [file]",
+		Short:       "Validate runs-on.yml configuration files",
+		Annotations: map[string]string{noContextAnnotation: "true"},
 		Long: `Validate and lint runs-on.yml configuration files.
 
 If no file is specified, searches for all runs-on.yml files in the current directory
@@ -41,6 +42,9 @@ The validator supports YAML anchors and will automatically expand them during va
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := context.Background()
+			if !cmd.Flags().Changed("format") && structuredOutput(cmd) {
+				format = outputFormat(cmd)
+			}
 
 			var err error
 			if stdin {
@@ -61,7 +65,7 @@ The validator supports YAML anchors and will automatically expand them during va
 		},
 	}
 
-	cmd.Flags().StringVarP(&format, "format", "f", "text", "Output format: text, json, or sarif")
+	cmd.Flags().StringVarP(&format, "format", "f", "text", "Output format: text, json, yaml, or sarif (--output json|yaml applies when unset)")
 	cmd.Flags().BoolVar(&stdin, "stdin", false, "Read from stdin instead of file")
 
 	// Enable file path completion for the file argument
@@ -154,10 +158,12 @@ func lintAllFiles(ctx context.Context, format string) error {
 		return outputLintAllText(allResults)
 	case "json":
 		return outputLintAllJSON(allResults)
+	case "yaml":
+		return outputLintAllStructured(allResults, outputYAML)
 	case "sarif":
 		return outputLintAllSARIF(allResults)
 	default:
-		return fmt.Errorf("invalid format %q (valid: text, json, sarif)", format)
+		return fmt.Errorf("invalid format %q (valid: text, json, yaml, sarif)", format)
 	}
 }
 
@@ -307,6 +313,15 @@ func writeIndentedJSON(value any, formatName string) error {
 	return nil
 }
 
+// writeLintStructured writes the json or yaml lint output. The YAML form has
+// the same fields as the JSON one.
+func writeLintStructured(value any, format string) error {
+	if format == outputYAML {
+		return writeStructured(os.Stdout, outputYAML, value)
+	}
+	return writeIndentedJSON(value, "JSON")
+}
+
 func splitDiagnostics(diags []validate.Diagnostic) ([]validate.Diagnostic, []validate.Diagnostic) {
 	var errors []validate.Diagnostic
 	var warnings []validate.Diagnostic
@@ -426,6 +441,10 @@ func isValidDiagnostics(diags []validate.Diagnostic) bool {
 }
 
 func outputLintAllJSON(results []fileResult) error {
+	return outputLintAllStructured(results, outputJSON)
+}
+
+func outputLintAllStructured(results []fileResult, format string) error {
 	allValid := lintResultsValid(results)
 	jsonResults := make([]lintJSONFileResult, len(results))
 	for i, result := range results {
@@ -441,7 +460,7 @@ func outputLintAllJSON(results []fileResult) error {
 		Files: jsonResults,
 	}
 
-	if err := writeIndentedJSON(output, "JSON"); err != nil {
+	if err := writeLintStructured(output, format); err != nil {
 		return err
 	}
 
@@ -477,10 +496,12 @@ func outputLintResults(diags []validate.Diagnostic, sourceName string, format st
 		return outputLintText(diags, sourceName)
 	case "json":
 		return outputLintJSON(diags)
+	case "yaml":
+		return outputLintStructured(diags, outputYAML)
 	case "sarif":
 		return outputLintSARIF(diags)
 	default:
-		return fmt.Errorf("invalid format %q (valid: text, json, sarif)", format)
+		return fmt.Errorf("invalid format %q (valid: text, json, yaml, sarif)", format)
 	}
 }
 
@@ -533,12 +554,16 @@ func outputLintText(diags []validate.Diagnostic, sourceName string) error {
 }
 
 func outputLintJSON(diags []validate.Diagnostic) error {
+	return outputLintStructured(diags, outputJSON)
+}
+
+func outputLintStructured(diags []validate.Diagnostic, format string) error {
 	output := lintSingleJSONOutput{
 		Valid:       isValidDiagnostics(diags),
 		Diagnostics: lintJSONDiagnostics(diags),
 	}
 
-	if err := writeIndentedJSON(output, "JSON"); err != nil {
+	if err := writeLintStructured(output, format); err != nil {
 		return err
 	}
 
@@ -7,14 +7,15 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"maps"
+	"os"
 	"slices"
-	"sort"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 )
 
@@ -26,13 +27,44 @@ type StackOutputs struct {
 type LogOptions struct {
 	Watch         bool
 	WatchInterval time.Duration
-	StartTime     int64
-	Format        string
-	NoColor       bool
+	// StartTime and EndTime bound the fetched logs, in Unix milliseconds.
+	// A zero EndTime fetches up to now. For job logs, a zero StartTime starts
+	// from the creation of the job.
+	StartTime int64
+	EndTime   int64
+	Format    string
+	// Fields are the fields printed by the pretty format.
+	Fields  []string
+	NoColor bool
+	// Output is the --output format. With json or yaml, events are written
+	// as structured records instead of formatted lines.
+	Output string
+	// Out receives the events. It defaults to stdout.
+	Out io.Writer
+	// ErrOut receives the warnings. It defaults to stderr.
+	ErrOut io.Writer
+	// Filter selects the events to show. nil shows every event.
+	Filter *logFilter
+	// Tee receives a copy of the events, e.g. the --tee file.
+	Tee *logTee
+	// NoLiveTail polls FilterLogEvents every WatchInterval in watch mode,
+	// instead of following new events with CloudWatch Logs Live Tail.
+	NoLiveTail bool
+	// MaxEvents stops the stream after this many events. Zero doesn't limit
+	// the number of events.
+	MaxEvents int
+	// Concurrency is the number of shards of the time range fetched at once.
+	// Zero fetches the time range as a whole.
+	Concurrency int
+	// FollowUntilDone stops watching the job logs DoneGracePeriod after every
+	// job is completed.
+	FollowUntilDone bool
+	DoneGracePeriod time.Duration
 }
 
 type cloudWatchLogsAPI interface {
 	FilterLogEvents(ctx context.Context, params *cloudwatchlogs.FilterLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error)
+	StartLiveTailStream(ctx context.Context, params *cloudwatchlogs.StartLiveTailInput) (liveTailStream, error)
 }
 
 type ec2ConsoleAPI interface {
@@ -49,7 +81,7 @@ type jobLogStreamer struct {
 func newJobLogStreamer(config *RunsOnConfig) *jobLogStreamer {
 	logger := log.New(io.Discard, "", 0)
 	return &jobLogStreamer{
-		cwl: cloudwatchlogs.NewFromConfig(config.AWSConfig),
+		cwl: newCloudWatchLogsClient(config.AWSConfig),
 		ec2: ec2.NewFromConfig(config.AWSConfig),
 		outputs: &StackOutputs{
 			ServiceLogGroupName:    config.ServiceLogGroupName,
@@ -63,14 +95,17 @@ type applicationLogStreamer struct {
 	cwl     cloudWatchLogsAPI
 	outputs *StackOutputs
 	logger  *log.Logger
+	// label prefixes every event when logs of several stacks are merged.
+	label string
 }
 
 func newApplicationLogStreamer(config *RunsOnConfig) *applicationLogStreamer {
 	logger := log.New(io.Discard, "", 0)
 	return &applicationLogStreamer{
-		cwl: cloudwatchlogs.NewFromConfig(config.AWSConfig),
+		cwl: newCloudWatchLogsClient(config.AWSConfig),
 		outputs: &StackOutputs{
-			ServiceLogGroupName: config.ServiceLogGroupName,
+			ServiceLogGroupName:    config.ServiceLogGroupName,
+			EC2InstanceLogGroupArn: config.EC2InstanceLogGroupArn,
 		},
 		logger: logger,
 	}
@@ -94,29 +129,128 @@ func (s *jobLogStreamer) Stream(ctx context.Context, jobID string, facts *workfl
 	s.logger.Printf("Fetching logs for job ID: %s (include types: %v)", jobID, includeTypes)
 
 	facts.refresh(ctx)
+	opts = jobLogOptions(opts, facts)
 	refreshCtx, cancelRefresh := context.WithCancel(ctx)
 	defer cancelRefresh()
 	facts.startRefresh(refreshCtx)
 
 	session := newStreamedLogSession(opts, s.logger)
-	session.startCloudWatchStream(ctx, "instance", s.cwl, s.updateInstanceLogInput(jobID, facts, opts))
-	if includeLogType(includeTypes, "console") {
-		session.startOnce("console", func(collector *logCollector) error {
-			return s.collectConsoleLogs(ctx, jobID, facts, collector, opts)
+	if opts.FollowUntilDone {
+		session.done = jobsDone(refreshCtx, []*workflowJobFactsProvider{facts})
+	}
+	session.startCloudWatchStream(ctx, "instance", s.cwl, s.outputs, s.updateInstanceLogInput(jobID, facts, opts))
+	if includeConsoleLogs(includeTypes, opts) {
+		session.startOnce("console", func(source *logSource) error {
+			return s.collectConsoleLogs(ctx, jobID, facts, source, opts)
 		})
 	}
-	session.startCloudWatchStream(ctx, "application", s.cwl, s.updateJobApplicationLogInput(jobID, facts, includeTypes, opts))
+	session.startCloudWatchStream(ctx, "application", s.cwl, s.outputs, s.updateJobApplicationLogInput(jobID, facts, includeTypes, opts))
 
 	return session.drainAndWatch(ctx)
 }
 
-func (s *applicationLogStreamer) Stream(ctx context.Context, opts *LogOptions) error {
+// StreamRun merges the logs of several jobs of the same workflow run: the
+// instance (and optionally console) logs of every job, plus the application
+// logs of the whole run.
+func (s *jobLogStreamer) StreamRun(ctx context.Context, runID int64, jobs map[string]*workflowJobFactsProvider, includeTypes []string, opts *LogOptions) error {
 	s.ensureLogger()
+	if runID == 0 {
+		return fmt.Errorf("workflow run ID is required")
+	}
+	s.logger.Printf("Fetching logs for run ID: %d (%d jobs, include types: %v)", runID, len(jobs), includeTypes)
+
+	refreshCtx, cancelRefresh := context.WithCancel(ctx)
+	defer cancelRefresh()
+
+	for _, facts := range jobs {
+		facts.refresh(ctx)
+	}
+	opts = jobLogOptions(opts, slices.Collect(maps.Values(jobs))...)
+
 	session := newStreamedLogSession(opts, s.logger)
-	session.startCloudWatchStream(ctx, "application", s.cwl, s.updateAllApplicationLogInput(opts))
+	if opts.FollowUntilDone {
+		session.done = jobsDone(refreshCtx, slices.Collect(maps.Values(jobs)))
+	}
+	for _, jobID := range slices.Sorted(maps.Keys(jobs)) {
+		facts := jobs[jobID]
+		facts.startRefresh(refreshCtx)
+
+		session.startCloudWatchStream(ctx, "instance", s.cwl, s.outputs, s.updateInstanceLogInput(jobID, facts, opts))
+		if includeConsoleLogs(includeTypes, opts) {
+			session.startOnce("console", func(source *logSource) error {
+				return s.collectConsoleLogs(ctx, jobID, facts, source, opts)
+			})
+		}
+	}
+	session.startCloudWatchStream(ctx, "application", s.cwl, s.outputs, s.updateRunApplicationLogInput(runID, opts))
+
 	return session.drainAndWatch(ctx)
 }
 
+func (s *applicationLogStreamer) Stream(ctx context.Context, opts *LogOptions) error {
+	return streamApplicationLogs(ctx, []*applicationLogStreamer{s}, opts)
+}
+
+// streamApplicationLogs merges the application logs of several stacks into a
+// single time-ordered stream.
+func streamApplicationLogs(ctx context.Context, streamers []*applicationLogStreamer, opts *LogOptions) error {
+	if len(streamers) == 0 {
+		return fmt.Errorf("no stack selected")
+	}
+	for _, streamer := range streamers {
+		streamer.ensureLogger()
+	}
+	session := newStreamedLogSession(opts, streamers[0].logger)
+	for _, streamer := range streamers {
+		session.startLabeledCloudWatchStream(ctx, streamer.label, "application", streamer.cwl, streamer.outputs, streamer.updateAllApplicationLogInput(opts))
+	}
+	return session.drainAndWatch(ctx)
+}
+
+// defaultLogLookback is how far back the logs are fetched when no start time
+// is known.
+const defaultLogLookback = 2 * time.Hour
+
+// jobLogOptions returns opts with the start time of the job logs filled in
+// when --since isn't set: like the --full window, it starts an hour before the
+// earliest of the jobs was created.
+func jobLogOptions(opts *LogOptions, jobs ...*workflowJobFactsProvider) *LogOptions {
+	if opts == nil {
+		opts = &LogOptions{}
+	}
+	if opts.StartTime != 0 {
+		return opts
+	}
+	var createdAt time.Time
+	for _, job := range jobs {
+		facts := job.current()
+		if facts == nil || facts.CreatedAt.IsZero() {
+			continue
+		}
+		if createdAt.IsZero() || facts.CreatedAt.Before(createdAt) {
+			createdAt = facts.CreatedAt
+		}
+	}
+
+	withStart := *opts
+	if createdAt.IsZero() {
+		withStart.StartTime = time.Now().Add(-defaultLogLookback).UnixMilli()
+	} else {
+		withStart.StartTime = createdAt.Add(-fullLogWindowPadding).UnixMilli()
+	}
+	return &withStart
+}
+
+// setLogTimeRange sets the start time of the first fetch, and the end time.
+func setLogTimeRange(input *cloudwatchlogs.FilterLogEventsInput, opts *LogOptions) {
+	if input.StartTime == nil {
+		input.StartTime = aws.Int64(opts.StartTime)
+	}
+	if opts.EndTime != 0 {
+		input.EndTime = aws.Int64(opts.EndTime)
+	}
+}
+
 func (s *jobLogStreamer) ensureLogger() {
 	if s.logger == nil {
 		s.logger = log.New(io.Discard, "", 0)
@@ -135,7 +269,7 @@ func jobApplicationFilterPattern(jobID string, facts *workflowJobFactsProvider,
 		if runID == 0 {
 			return "", fmt.Errorf("workflow run ID for job %s not available yet", jobID)
 		}
-		return fmt.Sprintf(`{ ( $.run_id = "%d" ) }`, runID), nil
+		return runFilterPattern(runID), nil
 	}
 	instanceID := facts.currentInstanceID()
 	if instanceID == "" {
@@ -148,15 +282,58 @@ func includeLogType(includeTypes []string, includeType string) bool {
 	return slices.Contains(includeTypes, includeType)
 }
 
+// includeConsoleLogs reports whether the console output is collected. It is
+// plain text, so it is skipped when the filter only matches JSON events.
+func includeConsoleLogs(includeTypes []string, opts *LogOptions) bool {
+	return includeLogType(includeTypes, "console") && (opts == nil || !opts.Filter.selectsJSON())
+}
+
 type logEvent struct {
 	message   string
 	prefix    string
+	label     string
 	stream    string
 	timestamp int64
 	eventId   string
 	noColor   bool
 }
 
+// logEventRecord is a log event as written with --output json|yaml.
+type logEventRecord struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Stream  string    `json:"stream"`
+	Stack   string    `json:"stack,omitempty"`
+	EventID string    `json:"event_id,omitempty"`
+	Message string    `json:"message"`
+	// Fields holds the parsed message when it is a JSON object, as the
+	// application logs are.
+	Fields map[string]any `json:"fields,omitempty"`
+}
+
+func (e *logEvent) record() logEventRecord {
+	source := e.prefix
+	if source == "" {
+		source = "instance"
+	}
+	record := logEventRecord{
+		Time:    time.UnixMilli(e.timestamp).UTC(),
+		Source:  source,
+		Stream:  e.stream,
+		Stack:   e.label,
+		EventID: e.eventId,
+		Message: e.message,
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(e.message), &fields); err == nil && fields != nil {
+		record.Fields = fields
+		if message, ok := fields["message"].(string); ok {
+			record.Message = message
+		}
+	}
+	return record
+}
+
 type applicationLogEvent struct {
 	Message    string    `json:"message"`
 	AppVersion string    `json:"app_version"`
@@ -165,7 +342,7 @@ type applicationLogEvent struct {
 	Timestamp  time.Time `json:"time"`
 }
 
-func (e *logEvent) print(format string) {
+func (e *logEvent) print(w io.Writer, format string) {
 	message := e.message
 	localTime := time.UnixMilli(e.timestamp).Local().Format("2006-01-02T15:04:05.000Z07:00")
 
@@ -179,7 +356,11 @@ func (e *logEvent) print(format string) {
 	}
 
 	if e.noColor {
-		fmt.Printf("%s [%s] %s\n", localTime, e.stream, message)
+		if e.label != "" {
+			fmt.Fprintf(w, "%s [%s] [%s] %s\n", localTime, e.label, e.stream, message)
+			return
+		}
+		fmt.Fprintf(w, "%s [%s] %s\n", localTime, e.stream, message)
 		return
 	}
 
@@ -195,46 +376,22 @@ func (e *logEvent) print(format string) {
 		color = "\033[35m" // magenta for console
 		stream = e.prefix
 	}
-	fmt.Printf("\033[90m%s\033[0m %s[%s]\033[0m %s\n", localTime, color, stream, message)
-}
-
-type logCollector struct {
-	events              []logEvent
-	mu                  sync.Mutex
-	eventCh             chan logEvent
-	wg                  sync.WaitGroup
-	pastEventsCollected bool
-	seenEvents          map[string]struct{}
-}
-
-func newLogCollector() *logCollector {
-	return &logCollector{
-		events:     make([]logEvent, 0),
-		eventCh:    make(chan logEvent, 100),
-		seenEvents: make(map[string]struct{}),
-	}
-}
-
-func (c *logCollector) add(event logEvent) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if _, seen := c.seenEvents[event.eventId]; seen {
-		return
-	}
-	c.seenEvents[event.eventId] = struct{}{}
-
-	if !c.pastEventsCollected {
-		c.events = append(c.events, event)
-	} else {
-		c.eventCh <- event
+	label := ""
+	if e.label != "" {
+		label = fmt.Sprintf("\033[36m[%s]\033[0m ", e.label) // cyan for stack label
 	}
+	fmt.Fprintf(w, "\033[90m%s\033[0m %s%s[%s]\033[0m %s\n", localTime, label, color, stream, message)
 }
 
 type streamedLogSession struct {
 	collector *logCollector
 	opts      *LogOptions
 	logger    *log.Logger
+	out       io.Writer
+	errOut    io.Writer
+	events    *eventWriter
+	// done stops watching, after the DoneGracePeriod of the options.
+	done <-chan struct{}
 }
 
 func newStreamedLogSession(opts *LogOptions, logger *log.Logger) *streamedLogSession {
@@ -244,80 +401,82 @@ func newStreamedLogSession(opts *LogOptions, logger *log.Logger) *streamedLogSes
 	if logger == nil {
 		logger = log.New(io.Discard, "", 0)
 	}
-	return &streamedLogSession{
+	session := &streamedLogSession{
 		collector: newLogCollector(),
 		opts:      opts,
 		logger:    logger,
+		out:       opts.Out,
+		errOut:    opts.ErrOut,
+	}
+	if session.out == nil {
+		session.out = os.Stdout
+	}
+	if session.errOut == nil {
+		session.errOut = os.Stderr
+	}
+	if opts.Output == outputJSON || opts.Output == outputYAML {
+		session.events = newEventWriter(session.out, opts.Output)
+	}
+	return session
+}
+
+func (s *streamedLogSession) emit(event logEvent, formatter *logFormatter) {
+	if s.opts.Tee != nil {
+		if err := s.opts.Tee.write(event, formatter); err != nil {
+			s.logger.Printf("Error writing event to --tee file: %v", err)
+		}
+	}
+	if s.events == nil {
+		if err := formatter.write(s.out, &event); err != nil {
+			s.logger.Printf("Error printing event: %v", err)
+		}
+		return
+	}
+	if err := s.events.write(event.record()); err != nil {
+		s.logger.Printf("Error writing event: %v", err)
 	}
 }
 
-func (s *streamedLogSession) startCloudWatchStream(ctx context.Context, prefix string, cwl cloudWatchLogsAPI, updateInput func(*cloudwatchlogs.FilterLogEventsInput) error) {
-	s.collector.wg.Add(1)
+func (s *streamedLogSession) startCloudWatchStream(ctx context.Context, prefix string, cwl cloudWatchLogsAPI, outputs *StackOutputs, updateInput func(*cloudwatchlogs.FilterLogEventsInput) error) {
+	s.startLabeledCloudWatchStream(ctx, "", prefix, cwl, outputs, updateInput)
+}
+
+func (s *streamedLogSession) startLabeledCloudWatchStream(ctx context.Context, label, prefix string, cwl cloudWatchLogsAPI, outputs *StackOutputs, updateInput func(*cloudwatchlogs.FilterLogEventsInput) error) {
+	source := s.collector.newSource()
 	go func() {
-		if err := s.streamCloudWatchLogs(ctx, prefix, cwl, updateInput); err != nil {
+		if err := s.streamCloudWatchLogs(ctx, source, label, prefix, cwl, outputs, updateInput); err != nil {
 			s.logger.Printf("Error streaming %s logs: %v", prefix, err)
 		}
 	}()
 }
 
-func (s *streamedLogSession) startOnce(prefix string, collect func(*logCollector) error) {
-	s.collector.wg.Go(func() {
-		if err := collect(s.collector); err != nil {
+func (s *streamedLogSession) startOnce(prefix string, collect func(*logSource) error) {
+	source := s.collector.newSource()
+	go func() {
+		defer source.pastCollected()
+		if err := collect(source); err != nil {
 			s.logger.Printf("Error streaming %s logs: %v", prefix, err)
 		}
-	})
+	}()
 }
 
-func (s *streamedLogSession) streamCloudWatchLogs(ctx context.Context, prefix string, cwl cloudWatchLogsAPI, updateInput func(*cloudwatchlogs.FilterLogEventsInput) error) error {
+// streamCloudWatchLogs fetches the events of updateInput. In watch mode, it
+// then follows new events with Live Tail, or polls for them every
+// WatchInterval when Live Tail isn't available.
+func (s *streamedLogSession) streamCloudWatchLogs(ctx context.Context, source *logSource, label, prefix string, cwl cloudWatchLogsAPI, outputs *StackOutputs, updateInput func(*cloudwatchlogs.FilterLogEventsInput) error) error {
 	input := &cloudwatchlogs.FilterLogEventsInput{}
-	pastEventsCollected := false
-	markPastEventsCollected := func() {
-		if !pastEventsCollected {
-			pastEventsCollected = true
-			s.collector.wg.Done()
-		}
-	}
+	liveTail := s.opts.Watch && !s.opts.NoLiveTail
 
 	for {
-		if err := updateInput(input); err != nil {
+		match, err := s.prepareInput(prefix, input, updateInput)
+		if err != nil {
 			s.logger.Printf("[%s]: Cannot stream logs: %v", prefix, err)
 		} else {
 			s.logger.Printf("[%s]: Streaming logs...", prefix)
-
-			paginator := cloudwatchlogs.NewFilterLogEventsPaginator(cwl, input)
-			var lastTimestamp int64
-
-			for paginator.HasMorePages() {
-				s.logger.Printf("[%s]: Fetching next page", prefix)
-				output, err := paginator.NextPage(ctx)
-				if err != nil {
-					s.logger.Printf("[%s]: Error fetching logs: %v", prefix, err)
-					markPastEventsCollected()
-					return fmt.Errorf("error fetching logs: %w", err)
-				}
-
-				if output.NextToken != nil {
-					s.logger.Printf("[%s]: Received %d events (next token: %s)", prefix, len(output.Events), *output.NextToken)
-				} else {
-					s.logger.Printf("[%s]: Received %d events", prefix, len(output.Events))
-				}
-
-				for i, event := range output.Events {
-					s.collector.add(logEvent{
-						message:   aws.ToString(event.Message),
-						prefix:    prefix,
-						stream:    aws.ToString(event.LogStreamName),
-						timestamp: aws.ToInt64(event.Timestamp),
-						eventId:   aws.ToString(event.EventId),
-						noColor:   s.opts.NoColor,
-					})
-
-					if event.Timestamp != nil && *event.Timestamp > lastTimestamp {
-						lastTimestamp = *event.Timestamp
-					}
-					s.logger.Printf("[%s]: %d: Last timestamp: %d", prefix, i, lastTimestamp)
-				}
-				s.logger.Printf("[%s]: Done fetching page", prefix)
+			lastTimestamp, err := s.fetchCloudWatchLogs(ctx, source, label, prefix, cwl, input, match)
+			if err != nil {
+				source.pastCollected()
+				return fmt.Errorf("error fetching logs: %w", err)
 			}
 
 			if lastTimestamp > 0 {
@@ -326,10 +485,21 @@ func (s *streamedLogSession) streamCloudWatchLogs(ctx context.Context, prefix st
 				input.StartTime = aws.Int64(time.Now().UnixMilli() - 1000)
 			}
 			s.logger.Printf("[%s]: Updated start time: %d", prefix, *input.StartTime)
+
+			if liveTail {
+				source.pastCollected()
+				tail := &cloudWatchLiveTail{session: s, source: source, label: label, prefix: prefix, cwl: cwl, outputs: outputs, input: input, match: match, updateInput: updateInput}
+				err := tail.run(ctx)
+				if err == nil {
+					return nil
+				}
+				s.logger.Printf("[%s]: Live Tail not available, polling instead: %v", prefix, err)
+				input, liveTail = tail.input, false
+			}
 		}
 
 		s.logger.Printf("[%s]: Done streaming logs", prefix)
-		markPastEventsCollected()
+		source.pastCollected()
 		if !s.opts.Watch {
 			break
 		}
@@ -339,34 +509,89 @@ func (s *streamedLogSession) streamCloudWatchLogs(ctx context.Context, prefix st
 	return nil
 }
 
-func (s *streamedLogSession) drainAndWatch(ctx context.Context) error {
-	s.collector.wg.Wait()
+// prepareInput updates input for the next fetch, and adds the log filters to
+// its filter pattern. match is the part of the filters applied locally.
+func (s *streamedLogSession) prepareInput(prefix string, input *cloudwatchlogs.FilterLogEventsInput, updateInput func(*cloudwatchlogs.FilterLogEventsInput) error) (func(string) bool, error) {
+	if err := updateInput(input); err != nil {
+		return nil, err
+	}
+	filterPattern, match := s.opts.Filter.streamFilter(aws.ToString(input.FilterPattern))
+	input.FilterPattern = aws.String(filterPattern)
+	if s.opts.Filter != nil {
+		s.logger.Printf("[%s]: Filter pattern with log filters: %s", prefix, filterPattern)
+	}
+	return match, nil
+}
+
+// fetchCloudWatchLogs adds the events of input to source, and returns the
+// timestamp of the latest one.
+func (s *streamedLogSession) fetchCloudWatchLogs(ctx context.Context, source *logSource, label, prefix string, cwl cloudWatchLogsAPI, input *cloudwatchlogs.FilterLogEventsInput, match func(string) bool) (int64, error) {
+	fetcher := newLogFetcher(cwl, s.opts.Concurrency)
+	fetcher.logger, fetcher.prefix = s.logger, prefix
+	var lastTimestamp int64
 
-	s.collector.mu.Lock()
-	s.logger.Printf("Draining remaining events")
-	sort.Slice(s.collector.events, func(i, j int) bool {
-		return s.collector.events[i].timestamp < s.collector.events[j].timestamp
+	err := fetcher.fetch(ctx, input, func(event cwltypes.FilteredLogEvent) error {
+		if event.Timestamp != nil && *event.Timestamp > lastTimestamp {
+			lastTimestamp = *event.Timestamp
+		}
+		if match != nil && !match(aws.ToString(event.Message)) {
+			return nil
+		}
+		source.add(logEvent{
+			message:   aws.ToString(event.Message),
+			prefix:    prefix,
+			label:     label,
+			stream:    aws.ToString(event.LogStreamName),
+			timestamp: aws.ToInt64(event.Timestamp),
+			eventId:   aws.ToString(event.EventId),
+			noColor:   s.opts.NoColor,
+		})
+		return nil
 	})
-	format := s.opts.Format
-	if format == "" {
-		format = "long"
+	if err != nil {
+		s.logger.Printf("[%s]: Error fetching logs: %v", prefix, err)
+	}
+	return lastTimestamp, err
+}
+
+func (s *streamedLogSession) drainAndWatch(ctx context.Context) error {
+	formatter, err := newLogFormatter(s.opts.Format, s.opts.Fields)
+	if err != nil {
+		return err
 	}
-	for _, event := range s.collector.events {
-		event.print(format)
+	defer s.collector.close()
+
+	// emit prints an event, and reports whether more can be printed.
+	emitted := 0
+	emit := func(event logEvent) bool {
+		s.emit(event, formatter)
+		emitted++
+		return s.opts.MaxEvents == 0 || emitted < s.opts.MaxEvents
 	}
-	s.collector.pastEventsCollected = true
-	s.collector.mu.Unlock()
 
-	if !s.opts.Watch {
+	s.logger.Printf("Merging past events")
+	if err := s.collector.mergePast(ctx, emit); err != nil {
+		return err
+	}
+	if !s.opts.Watch || (s.opts.MaxEvents > 0 && emitted >= s.opts.MaxEvents) {
 		return nil
 	}
 
+	done := s.done
+	var gracePeriodEnded <-chan time.Time
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case event := <-s.collector.eventCh:
-			event.print(format)
+			if !emit(event) {
+				return nil
+			}
+		case <-done:
+			s.logger.Printf("Jobs completed, streaming for %s more", s.opts.DoneGracePeriod)
+			done, gracePeriodEnded = nil, time.After(s.opts.DoneGracePeriod)
+		case <-gracePeriodEnded:
+			return nil
 		case <-time.After(10 * time.Second):
 			if !s.opts.Watch {
 				return nil
@@ -379,9 +604,7 @@ func (s *jobLogStreamer) updateInstanceLogInput(jobID string, facts *workflowJob
 	return func(input *cloudwatchlogs.FilterLogEventsInput) error {
 		input.LogGroupIdentifier = &s.outputs.EC2InstanceLogGroupArn
 		input.FilterPattern = aws.String("")
-		if input.StartTime == nil {
-			input.StartTime = aws.Int64(opts.StartTime)
-		}
+		setLogTimeRange(input, opts)
 		instanceID := facts.currentInstanceID()
 		if instanceID == "" {
 			return fmt.Errorf("instance ID for job %s not available yet", jobID)
@@ -393,7 +616,7 @@ func (s *jobLogStreamer) updateInstanceLogInput(jobID string, facts *workflowJob
 	}
 }
 
-func (s *jobLogStreamer) collectConsoleLogs(ctx context.Context, jobID string, facts *workflowJobFactsProvider, collector *logCollector, opts *LogOptions) error {
+func (s *jobLogStreamer) collectConsoleLogs(ctx context.Context, jobID string, facts *workflowJobFactsProvider, source *logSource, opts *LogOptions) error {
 	instanceID := facts.currentInstanceID()
 	if instanceID == "" {
 		return fmt.Errorf("instance ID for job %s not available yet", jobID)
@@ -421,12 +644,12 @@ func (s *jobLogStreamer) collectConsoleLogs(ctx context.Context, jobID string, f
 		// Split console output into lines and add them as log events
 		lines := strings.Split(string(decodedOutput), "\n")
 		for i, line := range lines {
-			if strings.TrimSpace(line) == "" {
+			if strings.TrimSpace(line) == "" || !opts.Filter.matchText(line) {
 				continue
 			}
 			eventId := fmt.Sprintf("console-%s-%d", instanceID, i)
 
-			collector.add(logEvent{
+			source.add(logEvent{
 				message:   line,
 				prefix:    "console",
 				stream:    "console",
@@ -442,9 +665,7 @@ func (s *jobLogStreamer) collectConsoleLogs(ctx context.Context, jobID string, f
 
 func (s *jobLogStreamer) updateJobApplicationLogInput(jobID string, facts *workflowJobFactsProvider, includeTypes []string, opts *LogOptions) func(*cloudwatchlogs.FilterLogEventsInput) error {
 	return updateApplicationLogInput(s.outputs, func(input *cloudwatchlogs.FilterLogEventsInput) error {
-		if input.StartTime == nil {
-			input.StartTime = aws.Int64(opts.StartTime)
-		}
+		setLogTimeRange(input, opts)
 		filterPattern, err := jobApplicationFilterPattern(jobID, facts, includeTypes)
 		if err != nil {
 			return err
@@ -455,12 +676,19 @@ func (s *jobLogStreamer) updateJobApplicationLogInput(jobID string, facts *workf
 	})
 }
 
+func (s *jobLogStreamer) updateRunApplicationLogInput(runID int64, opts *LogOptions) func(*cloudwatchlogs.FilterLogEventsInput) error {
+	return updateApplicationLogInput(s.outputs, func(input *cloudwatchlogs.FilterLogEventsInput) error {
+		setLogTimeRange(input, opts)
+		input.FilterPattern = aws.String(runFilterPattern(runID))
+		s.logger.Printf("Filter pattern: %s", *input.FilterPattern)
+		return nil
+	})
+}
+
 func (s *applicationLogStreamer) updateAllApplicationLogInput(opts *LogOptions) func(*cloudwatchlogs.FilterLogEventsInput) error {
 	return updateApplicationLogInput(s.outputs, func(input *cloudwatchlogs.FilterLogEventsInput) error {
 		input.FilterPattern = aws.String("")
-		if input.StartTime == nil {
-			input.StartTime = aws.Int64(opts.StartTime)
-		}
+		setLogTimeRange(input, opts)
 		return nil
 	})
 }
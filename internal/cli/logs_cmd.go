@@ -5,13 +5,14 @@ import (
 	"os"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/spf13/cobra"
 )
 
 func parseLogWatch(watchDuration string) (bool, time.Duration, error) {
 	watchInterval := 5 * time.Second
 	watch := watchDuration != ""
-	if watch && watchDuration != "true" {
+	if watch && watchDuration != bareLogWatch {
 		duration, err := time.ParseDuration(watchDuration)
 		if err != nil {
 			return false, 0, fmt.Errorf("invalid --watch value: %w", err)
@@ -21,6 +22,59 @@ func parseLogWatch(watchDuration string) (bool, time.Duration, error) {
 	return watch, watchInterval, nil
 }
 
+func validateMaxEvents(maxEvents int) error {
+	if maxEvents < 0 {
+		return fmt.Errorf("invalid --max-events %d: must not be negative", maxEvents)
+	}
+	return nil
+}
+
+func validateConcurrency(concurrency int) error {
+	if concurrency < 1 {
+		return fmt.Errorf("invalid --concurrency %d: must be at least 1", concurrency)
+	}
+	return nil
+}
+
+// parseLogTimeRange parses the --since and --until flags of the log commands
+// into Unix milliseconds. Unset flags are returned as 0.
+func parseLogTimeRange(since, until string, watch bool) (int64, int64, error) {
+	now := time.Now()
+	start, err := parseTimeFlag("since", since, now)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseTimeFlag("until", until, now)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !start.IsZero() && !end.IsZero() && end.Before(start) {
+		return 0, 0, fmt.Errorf("--until must not be before --since")
+	}
+	if !end.IsZero() && watch {
+		return 0, 0, fmt.Errorf("--until cannot be used with --watch")
+	}
+
+	var startTime, endTime int64
+	if !start.IsZero() {
+		startTime = start.UnixMilli()
+	}
+	if !end.IsZero() {
+		endTime = end.UnixMilli()
+	}
+	return startTime, endTime, nil
+}
+
+func writeFullLogArchivePath(cmd *cobra.Command, zipPath string) error {
+	if structuredOutput(cmd) {
+		return writeStructured(cmd.OutOrStdout(), outputFormat(cmd), struct {
+			Archive string `json:"archive"`
+		}{zipPath})
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Full log archive exported to: %s\n", zipPath)
+	return nil
+}
+
 func NewLogsCmd(stack *Stack) *cobra.Command {
 	var (
 		watchDuration string
@@ -28,13 +82,55 @@ func NewLogsCmd(stack *Stack) *cobra.Command {
 		full          bool
 		noColor       bool
 		format        string
+		fields        []string
 		includeFlags  []string
+		jobName       string
+		allJobs       bool
+		since         string
+		until         string
+		noLiveTail    bool
+		untilDone     bool
+		gracePeriod   time.Duration
+		maxEvents     int
+		concurrency   int
+		windowBefore  time.Duration
+		windowAfter   time.Duration
+		asRun         bool
 	)
 
 	cmd := &cobra.Command{
-		Use:   "logs JOB_ID|JOB_URL|RUN_ID",
-		Short: "Fetch RunsOn and instance logs for a specific job ID. Use --include to specify log types (run, console)",
-		Args:  cobra.ExactArgs(1),
+		Use:         "logs JOB_ID|JOB_URL|RUN_ID|RUN_URL",
+		Short:       "Fetch RunsOn and instance logs for a specific job ID. Use --include to specify log types (run, console)",
+		Annotations: map[string]string{logCommandAnnotation: "true"},
+		Long: `Fetch RunsOn and instance logs for a specific job ID. Use --include to specify log types (run, console).
+
+When given a workflow run URL, or a run ID with --run, the jobs of that run are
+looked up in the workflow jobs table. Pick one with --job-name or the
+interactive prompt, or use --all to merge the logs of every job in the run.
+
+Logs are fetched from one hour before the job was created, like the window of
+--full. Use --since and --until to fetch another time range, as a duration ago
+(e.g. 30m) or an RFC3339 time.
+
+The --full archive covers the job from --window-before its creation to
+--window-after its completion, or until now while it runs. --since and --until
+replace either end of that window. With --run, the archive holds every job of
+the run given, or of the run of the job URL given, each in its own
+jobs/<job_id> directory next to the logs of the run. The spot-events.json of
+an instance doesn't include rebalance recommendations or interruption
+warnings, which EC2 only sends to the instance; look for them in its agent
+logs.
+
+With --watch, new logs are followed with CloudWatch Logs Live Tail, or polled
+for at the watch interval when Live Tail isn't available or with --no-live-tail.
+
+--follow-until-done watches the logs until the job, or every job with --all,
+is completed, then for the --grace-period to catch the last logs. roc then
+exits with 0 when the jobs succeeded, 3 when they were cancelled, and 2
+otherwise.
+
+` + logFilterHelp,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 
@@ -42,11 +138,60 @@ func NewLogsCmd(stack *Stack) *cobra.Command {
 			if err != nil {
 				return err
 			}
-			if full && watch {
-				return fmt.Errorf("--full cannot be used with --watch")
+			if full && (watch || untilDone) {
+				return fmt.Errorf("--full cannot be used with --watch or --follow-until-done")
+			}
+			if cmd.Flags().Changed("grace-period") && !untilDone {
+				return fmt.Errorf("--grace-period requires --follow-until-done")
+			}
+			watch = watch || untilDone
+			if full && allJobs {
+				return fmt.Errorf("--full cannot be used with --all; use --full --run to export every job of a run")
+			}
+			if full && asRun && jobName != "" {
+				return fmt.Errorf("--full --run cannot be used with --job-name")
+			}
+			filter, err := logFilterFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			if _, err := newLogFormatter(format, fields); err != nil {
+				return err
+			}
+			if full && filter != nil {
+				return fmt.Errorf("--full cannot be used with --filter, --grep or --filter-pattern")
+			}
+			windowChanged := cmd.Flags().Changed("window-before") || cmd.Flags().Changed("window-after")
+			if windowChanged && !full {
+				return fmt.Errorf("--window-before and --window-after require --full")
+			}
+			if windowBefore < 0 || windowAfter < 0 {
+				return fmt.Errorf("--window-before and --window-after must not be negative")
+			}
+			if cmd.Flags().Changed("window-before") && since != "" {
+				return fmt.Errorf("--window-before cannot be used with --since")
+			}
+			if cmd.Flags().Changed("window-after") && until != "" {
+				return fmt.Errorf("--window-after cannot be used with --until")
+			}
+			if full && cmd.Flags().Changed("tee") {
+				return fmt.Errorf("--full cannot be used with --tee")
+			}
+			if err := validateMaxEvents(maxEvents); err != nil {
+				return err
+			}
+			if maxEvents > 0 && (full || untilDone) {
+				return fmt.Errorf("--max-events cannot be used with --full or --follow-until-done")
+			}
+			if err := validateConcurrency(concurrency); err != nil {
+				return err
+			}
+			startTime, endTime, err := parseLogTimeRange(since, until, watch)
+			if err != nil {
+				return err
 			}
 
-			config, err := stack.getStackOutputs(cmd)
+			config, err := stack.getJobStackOutputs(cmd, args[0])
 			if err != nil {
 				return err
 			}
@@ -54,41 +199,122 @@ func NewLogsCmd(stack *Stack) *cobra.Command {
 				return err
 			}
 
-			jobID := extractJobID(args[0])
+			jobsClient := dynamodb.NewFromConfig(config.AWSConfig)
 			if full {
 				exporter := newFullLogExporter(config)
-				zipPath, fullErr := exporter.Export(ctx, jobID)
+				exporter.concurrency = concurrency
+				exporter.progress = cmd.ErrOrStderr()
+				exporter.window = fullLogWindow{Before: windowBefore, After: windowAfter}
+				if startTime != 0 {
+					exporter.window.Since = time.UnixMilli(startTime)
+				}
+				if endTime != 0 {
+					exporter.window.Until = time.UnixMilli(endTime)
+				}
+
+				var zipPath string
+				var fullErr error
+				if asRun {
+					runID, runJobs, err := resolveWorkflowRun(ctx, jobsClient, config.WorkflowJobsTable, args[0])
+					if err != nil {
+						return err
+					}
+					zipPath, fullErr = exporter.ExportRun(ctx, runID, runJobs)
+				} else {
+					resolved, err := resolveWorkflowJobs(ctx, jobsClient, config.WorkflowJobsTable, args[0], newWorkflowJobSelector(jobName, false))
+					if err != nil {
+						return err
+					}
+					zipPath, fullErr = exporter.Export(ctx, resolved.JobIDs[0])
+				}
 				if zipPath != "" {
-					fmt.Printf("Full log archive exported to: %s\n", zipPath)
+					if err := writeFullLogArchivePath(cmd, zipPath); err != nil {
+						return err
+					}
 				}
 				return fullErr
 			}
 
+			selector := newWorkflowJobSelector(jobName, allJobs)
+			selector.Run = asRun
+			resolved, err := resolveWorkflowJobs(ctx, jobsClient, config.WorkflowJobsTable, args[0], selector)
+			if err != nil {
+				return err
+			}
+			jobID := resolved.JobIDs[0]
+
 			streamer := newJobLogStreamer(config)
 			if debug {
 				streamer.logger.SetOutput(os.Stderr)
 			}
+			tee, err := logTeeFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			defer tee.Close()
 
 			logOptions := &LogOptions{
-				Watch:         watch,
-				WatchInterval: watchInterval,
-				StartTime:     time.Now().Add(-2 * time.Hour).UnixMilli(),
-				Format:        format,
-				NoColor:       noColor,
+				Watch:           watch,
+				WatchInterval:   watchInterval,
+				StartTime:       startTime,
+				EndTime:         endTime,
+				Format:          format,
+				Fields:          fields,
+				NoColor:         noColor,
+				Output:          outputFormat(cmd),
+				Filter:          filter,
+				Tee:             tee,
+				NoLiveTail:      noLiveTail,
+				MaxEvents:       maxEvents,
+				Concurrency:     concurrency,
+				FollowUntilDone: untilDone,
+				DoneGracePeriod: gracePeriod,
 			}
 
-			facts := newWorkflowJobFactsProvider(config, jobID, streamer.logger)
-			return streamer.Stream(ctx, jobID, facts, includeFlags, logOptions)
+			jobs := make(map[string]*workflowJobFactsProvider, len(resolved.JobIDs))
+			for _, runJobID := range resolved.JobIDs {
+				jobs[runJobID] = newWorkflowJobFactsProvider(config, runJobID, streamer.logger)
+			}
+			if len(jobs) > 1 {
+				err = streamer.StreamRun(ctx, resolved.RunID, jobs, includeFlags, logOptions)
+			} else {
+				err = streamer.Stream(ctx, jobID, jobs[jobID], includeFlags, logOptions)
+			}
+			if err != nil || !untilDone {
+				return err
+			}
+			if err := jobsConclusionError(jobs); err != nil {
+				cmd.SilenceUsage = true
+				cmd.SilenceErrors = true
+				return err
+			}
+			return nil
 		},
 	}
 
-	cmd.Flags().StringVarP(&watchDuration, "watch", "w", "", "Watch for new logs with optional interval (e.g. --watch 2s)")
-	cmd.Flags().Lookup("watch").NoOptDefVal = "5s"
+	cmd.Flags().StringVarP(&watchDuration, "watch", "w", "", "Watch for new logs with optional interval (e.g. --watch=2s; default: the watch-interval of the context, or "+defaultLogWatchInterval+")")
+	cmd.Flags().Lookup("watch").NoOptDefVal = bareLogWatch
+	cmd.Flags().BoolVar(&noLiveTail, "no-live-tail", false, "With --watch, poll for new logs at the watch interval instead of using CloudWatch Logs Live Tail")
+	cmd.Flags().BoolVar(&untilDone, "follow-until-done", false, "Watch for new logs until the job is completed, and exit with a code reflecting its conclusion")
+	cmd.Flags().DurationVar(&gracePeriod, "grace-period", defaultDoneGracePeriod, "With --follow-until-done, how long to keep streaming after the job is completed")
 	cmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable debug output")
 	cmd.Flags().BoolVar(&full, "full", false, "Export full diagnostic archive for the job")
-	cmd.Flags().StringVarP(&format, "format", "f", "long", "Output format: long (default) or short")
+	cmd.Flags().DurationVar(&windowBefore, "window-before", fullLogWindowPadding, "With --full, how long before the job was created to fetch logs from")
+	cmd.Flags().DurationVar(&windowAfter, "window-after", fullLogWindowPadding, "With --full, how long after the job was completed to fetch logs until")
+	cmd.Flags().StringVarP(&format, "format", "f", "long", "Output format: long, short, pretty, or template=<Go template>")
+	cmd.Flags().StringSliceVar(&fields, "fields", nil, "Fields of the JSON application logs to show with --format pretty (e.g. job_id,run_id,repo)")
 	cmd.Flags().BoolVar(&noColor, "no-color", false, "Disable color output for streamed logs")
+	cmd.Flags().IntVar(&maxEvents, "max-events", 0, "Stop after printing this many log events (default: no limit)")
+	cmd.Flags().IntVar(&concurrency, "concurrency", defaultLogConcurrency, "Number of parts of the time range of each log group to fetch from CloudWatch at once, with or without --full")
 	cmd.Flags().StringSliceVar(&includeFlags, "include", []string{}, "Include additional log types: 'run' (all logs from entire run), 'console' (EC2 instance console logs)")
+	cmd.Flags().StringVar(&jobName, "job-name", "", "When given a run, select the job with this name")
+	cmd.Flags().BoolVar(&allJobs, "all", false, "When given a run, merge the logs of every job in the run")
+	cmd.Flags().BoolVar(&asRun, "run", false, "Look up the argument as a workflow run ID. With --full, export every job of the run in one archive")
+	cmd.Flags().StringVar(&since, "since", "", "Show logs since this duration ago or RFC3339 time (default: 1h before the job was created)")
+	cmd.Flags().StringVar(&until, "until", "", "Show logs until this duration ago or RFC3339 time")
+	addLogFilterFlags(cmd)
+	addLogTeeFlags(cmd)
+	cmd.AddCommand(NewLogsReplayCmd())
 
 	return cmd
 }
@@ -97,67 +323,121 @@ func NewStackLogsCmd(stack *Stack) *cobra.Command {
 	var (
 		watchDuration string
 		since         string
+		until         string
 		debug         bool
 		noColor       bool
 		format        string
+		fields        []string
+		noLiveTail    bool
+		maxEvents     int
+		concurrency   int
 	)
 
 	cmd := &cobra.Command{
-		Use:   "logs",
-		Short: "Stream all RunsOn application logs from CloudWatch",
+		Use:         "logs",
+		Short:       "Stream all RunsOn application logs from CloudWatch",
+		Annotations: map[string]string{logCommandAnnotation: "true"},
 		Long: `Stream all RunsOn application logs from the CloudWatch log group.
 
 This command streams all application logs from the RunsOn service, not filtered
 by specific jobs. Use this to monitor overall service activity and troubleshoot
-system-wide issues.`,
+system-wide issues.
+
+When several stacks or regions are selected, their logs are merged into one
+stream and each line is labeled with its stack and region.
+
+With --watch, new logs are followed with CloudWatch Logs Live Tail, or polled
+for at the watch interval when Live Tail isn't available or with --no-live-tail.
+
+` + logFilterHelp,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			config, err := stack.getStackOutputs(cmd)
+			configs, err := stack.getAllStackOutputs(cmd)
 			if err != nil {
 				return err
 			}
-			if err := config.validateStackLogs(); err != nil {
-				return err
+			for _, config := range configs {
+				if err := config.validateStackLogs(); err != nil {
+					return err
+				}
 			}
 
 			ctx := cmd.Context()
-
-			startTime := time.Now().Add(-2 * time.Hour)
-			if since != "" {
-				duration, err := time.ParseDuration(since)
-				if err != nil {
-					return fmt.Errorf("invalid --since value: %w", err)
-				}
-				startTime = time.Now().Add(-duration)
+			filter, err := logFilterFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			if _, err := newLogFormatter(format, fields); err != nil {
+				return err
+			}
+			if err := validateMaxEvents(maxEvents); err != nil {
+				return err
+			}
+			if err := validateConcurrency(concurrency); err != nil {
+				return err
 			}
 
 			watch, watchInterval, err := parseLogWatch(watchDuration)
 			if err != nil {
 				return err
 			}
+			startTime, endTime, err := parseLogTimeRange(since, until, watch)
+			if err != nil {
+				return err
+			}
+			if startTime == 0 {
+				startTime = time.Now().Add(-defaultLogLookback).UnixMilli()
+			}
 
-			streamer := newApplicationLogStreamer(config)
-			if debug {
-				streamer.logger.SetOutput(os.Stderr)
+			streamers := make([]*applicationLogStreamer, 0, len(configs))
+			for _, config := range configs {
+				streamer := newApplicationLogStreamer(config)
+				if debug {
+					streamer.logger.SetOutput(os.Stderr)
+				}
+				if len(configs) > 1 {
+					streamer.label = config.label()
+				}
+				streamers = append(streamers, streamer)
+			}
+			tee, err := logTeeFromFlags(cmd)
+			if err != nil {
+				return err
 			}
+			defer tee.Close()
 
 			logOptions := &LogOptions{
 				Watch:         watch,
 				WatchInterval: watchInterval,
-				StartTime:     startTime.UnixMilli(),
+				StartTime:     startTime,
+				EndTime:       endTime,
 				Format:        format,
+				Fields:        fields,
 				NoColor:       noColor,
+				Output:        outputFormat(cmd),
+				Filter:        filter,
+				Tee:           tee,
+				NoLiveTail:    noLiveTail,
+				MaxEvents:     maxEvents,
+				Concurrency:   concurrency,
 			}
 
-			return streamer.Stream(ctx, logOptions)
+			return streamApplicationLogs(ctx, streamers, logOptions)
 		},
 	}
 
-	cmd.Flags().StringVarP(&watchDuration, "watch", "w", "", "Watch for new logs with optional interval (e.g. --watch 2s)")
-	cmd.Flags().Lookup("watch").NoOptDefVal = "5s"
-	cmd.Flags().StringVarP(&since, "since", "s", "2h", "Show logs since duration (e.g. 30m, 2h)")
+	cmd.Flags().StringVarP(&watchDuration, "watch", "w", "", "Watch for new logs with optional interval (e.g. --watch=2s; default: the watch-interval of the context, or "+defaultLogWatchInterval+")")
+	cmd.Flags().Lookup("watch").NoOptDefVal = bareLogWatch
+	cmd.Flags().BoolVar(&noLiveTail, "no-live-tail", false, "With --watch, poll for new logs at the watch interval instead of using CloudWatch Logs Live Tail")
+	cmd.Flags().StringVarP(&since, "since", "s", "2h", "Show logs since this duration ago or RFC3339 time (e.g. 30m, 2026-05-08T12:00:00Z)")
+	cmd.Flags().StringVar(&until, "until", "", "Show logs until this duration ago or RFC3339 time")
 	cmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable debug output")
-	cmd.Flags().StringVarP(&format, "format", "f", "long", "Output format: long (default) or short")
+	cmd.Flags().StringVarP(&format, "format", "f", "long", "Output format: long, short, pretty, or template=<Go template>")
+	cmd.Flags().StringSliceVar(&fields, "fields", nil, "Fields of the JSON application logs to show with --format pretty (e.g. job_id,run_id,repo)")
 	cmd.Flags().BoolVar(&noColor, "no-color", false, "Disable color output for streamed logs")
+	cmd.Flags().IntVar(&maxEvents, "max-events", 0, "Stop after printing this many log events (default: no limit)")
+	cmd.Flags().IntVar(&concurrency, "concurrency", defaultLogConcurrency, "Number of parts of the time range of each log group to fetch from CloudWatch at once")
+	addLogFilterFlags(cmd)
+	addLogTeeFlags(cmd)
 
 	return cmd
 }
@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// seenEventsWindow is how long the IDs of the collected events are kept,
+// behind the latest event of their source, to drop the events fetched twice.
+// Fetches only overlap around the last event of the previous one.
+const seenEventsWindow = 5 * time.Minute
+
+// logSourceBuffer is the number of past events a source fetches ahead of the
+// merge.
+const logSourceBuffer = 100
+
+// logCollector merges the events of several sources, e.g. the instance and
+// application logs of a job. The past events of the sources are merged by
+// timestamp as they are fetched, so only a few of them are held at a time.
+// The events that come after are passed on as they arrive.
+type logCollector struct {
+	mu      sync.Mutex
+	sources []*logSource
+	seen    *seenEvents
+	eventCh chan logEvent
+	// closed unblocks the sources once nothing reads their events anymore.
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// logSource adds the events of one fetch loop to the collector. It must only
+// be used from one goroutine.
+type logSource struct {
+	collector *logCollector
+	seen      seenEventsSource
+	past      chan logEvent
+	pastDone  bool
+}
+
+func newLogCollector() *logCollector {
+	return &logCollector{
+		seen:    newSeenEvents(seenEventsWindow),
+		eventCh: make(chan logEvent, 100),
+		closed:  make(chan struct{}),
+	}
+}
+
+// newSource adds a source of events. Its events are merged with the past
+// events of the other sources until pastCollected is called.
+func (c *logCollector) newSource() *logSource {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	source := &logSource{collector: c, past: make(chan logEvent, logSourceBuffer)}
+	c.sources = append(c.sources, source)
+	return source
+}
+
+func (c *logCollector) close() {
+	c.closeOnce.Do(func() { close(c.closed) })
+}
+
+// add passes an event on, unless an event with the same ID was already added.
+// It blocks while the merge waits for the other sources.
+func (s *logSource) add(event logEvent) {
+	c := s.collector
+	if !c.seen.add(&s.seen, event.label+"/"+event.eventId, event.timestamp) {
+		return
+	}
+	events := c.eventCh
+	if !s.pastDone {
+		events = s.past
+	}
+	select {
+	case events <- event:
+	case <-c.closed:
+	}
+}
+
+// pastCollected marks the end of the past events of the source. The next
+// events are passed on as they arrive.
+func (s *logSource) pastCollected() {
+	if !s.pastDone {
+		s.pastDone = true
+		close(s.past)
+	}
+}
+
+// mergePast calls emit with the past events of every source, by timestamp,
+// until every source has collected its past events or emit returns false.
+// Events of the same timestamp keep the order of their source.
+func (c *logCollector) mergePast(ctx context.Context, emit func(logEvent) bool) error {
+	c.mu.Lock()
+	sources := c.sources
+	c.mu.Unlock()
+
+	heads := &logEventHeap{}
+	next := func(index int) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-sources[index].past:
+			if ok {
+				heap.Push(heads, logEventHead{event: event, source: index})
+			}
+			return nil
+		}
+	}
+	for index := range sources {
+		if err := next(index); err != nil {
+			return err
+		}
+	}
+	for heads.Len() > 0 {
+		head := heap.Pop(heads).(logEventHead)
+		if !emit(head.event) {
+			return nil
+		}
+		if err := next(head.source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type logEventHead struct {
+	event  logEvent
+	source int
+}
+
+// logEventHeap orders the next past event of each source by timestamp.
+type logEventHeap []logEventHead
+
+func (h logEventHeap) Len() int { return len(h) }
+func (h logEventHeap) Less(i, j int) bool {
+	if h[i].event.timestamp != h[j].event.timestamp {
+		return h[i].event.timestamp < h[j].event.timestamp
+	}
+	return h[i].source < h[j].source
+}
+func (h logEventHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *logEventHeap) Push(x any)   { *h = append(*h, x.(logEventHead)) }
+func (h *logEventHeap) Pop() any {
+	old := *h
+	head := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return head
+}
+
+// seenEvents is a set of event IDs. The IDs are added through the window of
+// their source, and dropped once they are more than window older than the
+// latest event of that source. A source fetching ahead of the others thus
+// doesn't drop the IDs of the events the others may fetch again.
+type seenEvents struct {
+	mu     sync.Mutex
+	window int64
+	keys   map[string]struct{}
+}
+
+// seenEventsSource holds the IDs a source added to seenEvents, in the order
+// they were added.
+type seenEventsSource struct {
+	latest int64
+	order  []seenEvent
+	head   int
+}
+
+type seenEvent struct {
+	key       string
+	timestamp int64
+}
+
+func newSeenEvents(window time.Duration) *seenEvents {
+	return &seenEvents{window: window.Milliseconds(), keys: make(map[string]struct{})}
+}
+
+// add reports whether key wasn't seen yet, and adds it to the window of its
+// source.
+func (s *seenEvents) add(source *seenEventsSource, key string, timestamp int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, seen := s.keys[key]; seen {
+		return false
+	}
+	s.keys[key] = struct{}{}
+	source.order = append(source.order, seenEvent{key: key, timestamp: timestamp})
+	source.latest = max(source.latest, timestamp)
+
+	for source.head < len(source.order) && source.order[source.head].timestamp < source.latest-s.window {
+		delete(s.keys, source.order[source.head].key)
+		source.order[source.head] = seenEvent{}
+		source.head++
+	}
+	if source.head > len(source.order)/2 {
+		source.order = append(source.order[:0], source.order[source.head:]...)
+		source.head = 0
+	}
+	return true
+}
+
+func (s *seenEvents) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.keys)
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// pagedCloudWatchLogsClient returns events with increasing timestamps, in
+// pages of pageSize events.
+type pagedCloudWatchLogsClient struct {
+	events   int
+	pageSize int
+}
+
+// FilterLogEvents returns the events of the time range of params, one per
+// second from 1, in pages of pageSize.
+func (c *pagedCloudWatchLogsClient) FilterLogEvents(ctx context.Context, params *cloudwatchlogs.FilterLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+	first := max(int((aws.ToInt64(params.StartTime)-1+999)/1000), 0)
+	last := c.events
+	if params.EndTime != nil {
+		last = min(last, int((*params.EndTime-1)/1000)+1)
+	}
+	start := first
+	if params.NextToken != nil {
+		start, _ = strconv.Atoi(aws.ToString(params.NextToken))
+	}
+	end := max(min(start+c.pageSize, last), start)
+	output := &cloudwatchlogs.FilterLogEventsOutput{Events: make([]cwltypes.FilteredLogEvent, 0, end-start)}
+	for i := start; i < end; i++ {
+		output.Events = append(output.Events, cwltypes.FilteredLogEvent{
+			EventId:       aws.String(strconv.Itoa(i)),
+			Message:       aws.String(fmt.Sprintf(`{"level":"info","message":"event %d"}`, i)),
+			LogStreamName: aws.String("flexd/app"),
+			Timestamp:     aws.Int64(1 + int64(i)*1000),
+		})
+	}
+	if end < last {
+		output.NextToken = aws.String(strconv.Itoa(end))
+	}
+	return output, nil
+}
+
+func (c *pagedCloudWatchLogsClient) StartLiveTailStream(ctx context.Context, params *cloudwatchlogs.StartLiveTailInput) (liveTailStream, error) {
+	return nil, errors.New("live tail not available")
+}
+
+func TestLogCollectorMergesPastEventsByTimestamp(t *testing.T) {
+	collector := newLogCollector()
+	for index, timestamps := range [][]int64{{1, 4, 5, 9}, {2, 3, 8}, {}, {5, 6, 7}} {
+		source := collector.newSource()
+		go func() {
+			for _, timestamp := range timestamps {
+				source.add(logEvent{eventId: fmt.Sprintf("%d-%d", index, timestamp), timestamp: timestamp})
+			}
+			source.pastCollected()
+		}()
+	}
+
+	var timestamps []int64
+	if err := collector.mergePast(context.Background(), func(event logEvent) bool {
+		timestamps = append(timestamps, event.timestamp)
+		return true
+	}); err != nil {
+		t.Fatalf("mergePast returned error: %v", err)
+	}
+	if got := fmt.Sprint(timestamps); got != "[1 2 3 4 5 5 6 7 8 9]" {
+		t.Fatalf("expected the events in timestamp order, got %s", got)
+	}
+}
+
+func TestSeenEventsExpireOutsideTheWindow(t *testing.T) {
+	seen, source := newSeenEvents(time.Second), &seenEventsSource{}
+	for _, event := range []seenEvent{{"a", 0}, {"b", 500}, {"b", 500}, {"c", 1200}} {
+		seen.add(source, event.key, event.timestamp)
+	}
+	if seen.add(source, "b", 500) || seen.len() != 2 {
+		t.Fatalf("expected b to be kept within the window, got %d IDs", seen.len())
+	}
+	if !seen.add(source, "a", 0) {
+		t.Fatal("expected a to have expired")
+	}
+
+	seen.add(source, "d", 5000)
+	if seen.len() != 1 || len(source.order)-source.head != 1 {
+		t.Fatalf("expected only the latest ID to be kept, got %d IDs", seen.len())
+	}
+}
+
+func TestSeenEventsKeepTheWindowOfEachSource(t *testing.T) {
+	seen, slow, fast := newSeenEvents(time.Second), &seenEventsSource{}, &seenEventsSource{}
+	seen.add(slow, "slow", 0)
+	seen.add(fast, "fast", 0)
+	seen.add(fast, "ahead", 60000)
+	if seen.add(slow, "slow", 0) {
+		t.Fatal("expected a source fetching ahead not to expire the IDs of another source")
+	}
+	if !seen.add(fast, "fast", 0) {
+		t.Fatal("expected the IDs of a source to expire behind its own latest event")
+	}
+}
+
+func TestMaxEventsStopsTheStream(t *testing.T) {
+	streamer := &applicationLogStreamer{
+		cwl:     &pagedCloudWatchLogsClient{events: 1000, pageSize: 100},
+		outputs: &StackOutputs{ServiceLogGroupName: "/aws/ecs/runs-on/flexd"},
+	}
+	var output bytes.Buffer
+	if err := streamer.Stream(context.Background(), &LogOptions{StartTime: 1, Format: logFormatShort, NoColor: true, Out: &output, MaxEvents: 10}); err != nil {
+		t.Fatalf("Stream returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 10 || !strings.Contains(lines[9], "event 9") {
+		t.Fatalf("expected the first 10 events, got %q", lines)
+	}
+}
+
+// BenchmarkStreamApplicationLogs merges the past logs of three stacks. The
+// memory used by the collector doesn't grow with the number of events.
+func BenchmarkStreamApplicationLogs(b *testing.B) {
+	const events = 20000
+	streamers := make([]*applicationLogStreamer, 0, 3)
+	for _, label := range []string{"runs-on/us-east-1", "runs-on/eu-west-1", "runs-on/ap-south-1"} {
+		streamers = append(streamers, &applicationLogStreamer{
+			cwl:     &pagedCloudWatchLogsClient{events: events, pageSize: 1000},
+			outputs: &StackOutputs{ServiceLogGroupName: "/aws/ecs/runs-on/flexd"},
+			label:   label,
+		})
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		opts := &LogOptions{StartTime: 1, Format: logFormatShort, NoColor: true, Out: io.Discard, EndTime: events * 1000, Concurrency: defaultLogConcurrency}
+		if err := streamApplicationLogs(context.Background(), streamers, opts); err != nil {
+			b.Fatalf("streamApplicationLogs returned error: %v", err)
+		}
+	}
+	b.ReportMetric(float64(len(streamers)*events), "events/op")
+}
+
+func TestMaxEventsFlagValidation(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"42", "--max-events", "-1"}, "invalid --max-events -1"},
+		{[]string{"42", "--max-events", "10", "--follow-until-done"}, "--max-events cannot be used with --full or --follow-until-done"},
+	} {
+		cmd := NewLogsCmd(&Stack{})
+		cmd.SetArgs(test.args)
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Fatalf("%v: error %v, want %q", test.args, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultDoneGracePeriod is how long --follow-until-done keeps streaming after
+// the jobs complete, for their last logs to reach CloudWatch.
+const defaultDoneGracePeriod = 30 * time.Second
+
+// Exit codes of roc logs --follow-until-done, after the jobs complete.
+const (
+	exitCodeJobFailed    = 2
+	exitCodeJobCancelled = 3
+)
+
+// jobsDoneCheckInterval is how often the facts of the jobs are checked. They
+// are refreshed from the workflow jobs table every 5 seconds.
+var jobsDoneCheckInterval = time.Second
+
+// ExitError makes roc exit with Code instead of 1, e.g. to reflect the
+// conclusion of a job.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code of roc for an error returned by a command.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return 1
+}
+
+// jobsDone returns a channel closed once every job is completed.
+func jobsDone(ctx context.Context, jobs []*workflowJobFactsProvider) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(jobsDoneCheckInterval)
+		defer ticker.Stop()
+		for !jobsCompleted(jobs) {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+		close(done)
+	}()
+	return done
+}
+
+func jobsCompleted(jobs []*workflowJobFactsProvider) bool {
+	for _, job := range jobs {
+		facts := job.current()
+		if facts == nil || facts.Status != "completed" {
+			return false
+		}
+	}
+	return true
+}
+
+// jobsConclusionError returns nil when every job succeeded, was skipped or was
+// neutral. Otherwise, the error exits with exitCodeJobFailed, or with
+// exitCodeJobCancelled when the jobs that didn't succeed were all cancelled.
+func jobsConclusionError(jobs map[string]*workflowJobFactsProvider) error {
+	code := 0
+	var failed []string
+	for _, jobID := range slices.Sorted(maps.Keys(jobs)) {
+		conclusion := "unknown"
+		if facts := jobs[jobID].current(); facts != nil && facts.Conclusion != "" {
+			conclusion = facts.Conclusion
+		}
+		switch conclusion {
+		case "success", "skipped", "neutral":
+			continue
+		case "cancelled":
+			if code == 0 {
+				code = exitCodeJobCancelled
+			}
+		default:
+			code = exitCodeJobFailed
+		}
+		failed = append(failed, fmt.Sprintf("%s (%s)", jobID, conclusion))
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	noun := "job"
+	if len(failed) > 1 {
+		noun = "jobs"
+	}
+	return &ExitError{Code: code, Err: fmt.Errorf("%s %s did not succeed", noun, strings.Join(failed, ", "))}
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+func TestExitCode(t *testing.T) {
+	if code := ExitCode(nil); code != 0 {
+		t.Fatalf("ExitCode(nil) = %d, want 0", code)
+	}
+	if code := ExitCode(errors.New("boom")); code != 1 {
+		t.Fatalf("ExitCode(error) = %d, want 1", code)
+	}
+	wrapped := fmt.Errorf("logs: %w", &ExitError{Code: exitCodeJobCancelled, Err: errors.New("cancelled")})
+	if code := ExitCode(wrapped); code != exitCodeJobCancelled {
+		t.Fatalf("ExitCode(wrapped ExitError) = %d, want %d", code, exitCodeJobCancelled)
+	}
+}
+
+func TestJobsConclusionError(t *testing.T) {
+	jobsWith := func(conclusions ...string) map[string]*workflowJobFactsProvider {
+		jobs := map[string]*workflowJobFactsProvider{}
+		for i, conclusion := range conclusions {
+			jobs[fmt.Sprint(i+1)] = &workflowJobFactsProvider{facts: &workflowJobFacts{Status: "completed", Conclusion: conclusion}}
+		}
+		return jobs
+	}
+	for _, test := range []struct {
+		conclusions []string
+		code        int
+		message     string
+	}{
+		{[]string{"success", "skipped", "neutral"}, 0, ""},
+		{[]string{"success", "failure"}, exitCodeJobFailed, "job 2 (failure) did not succeed"},
+		{[]string{"cancelled", "cancelled"}, exitCodeJobCancelled, "jobs 1 (cancelled), 2 (cancelled) did not succeed"},
+		{[]string{"cancelled", "timed_out"}, exitCodeJobFailed, "jobs 1 (cancelled), 2 (timed_out) did not succeed"},
+		{[]string{""}, exitCodeJobFailed, "job 1 (unknown) did not succeed"},
+	} {
+		err := jobsConclusionError(jobsWith(test.conclusions...))
+		if code := ExitCode(err); code != test.code {
+			t.Fatalf("conclusions %v: exit code %d, want %d", test.conclusions, code, test.code)
+		}
+		if err != nil && err.Error() != test.message {
+			t.Fatalf("conclusions %v: error %q, want %q", test.conclusions, err, test.message)
+		}
+	}
+}
+
+func TestFollowUntilDoneStopsAfterTheGracePeriod(t *testing.T) {
+	jobsClient := &mockWorkflowJobsClient{
+		getItem: func(context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{
+				Item: marshalWorkflowJobItem(t, workflowJobFactsRecord{JobID: 42, RunID: 1234, Status: "completed", Conclusion: "failure"}),
+			}, nil
+		},
+	}
+	streamer := &jobLogStreamer{
+		cwl:     &mockCloudWatchLogsClient{},
+		outputs: testLiveTailOutputs,
+	}
+	facts := &workflowJobFactsProvider{jobs: jobsClient, tableName: "workflow-jobs", jobID: "42"}
+	opts := &LogOptions{
+		Watch:           true,
+		WatchInterval:   time.Millisecond,
+		StartTime:       1,
+		Out:             io.Discard,
+		FollowUntilDone: true,
+		DoneGracePeriod: 10 * time.Millisecond,
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- streamer.Stream(context.Background(), "42", facts, nil, opts)
+	}()
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("Stream returned error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the stream to stop once the job completed")
+	}
+
+	err := jobsConclusionError(map[string]*workflowJobFactsProvider{"42": facts})
+	if ExitCode(err) != exitCodeJobFailed || !strings.Contains(err.Error(), "42 (failure)") {
+		t.Fatalf("expected the failed job to set the exit code, got %v", err)
+	}
+}
+
+func TestFollowUntilDoneFlagValidation(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"42", "--full", "--follow-until-done"}, "--full cannot be used with --watch or --follow-until-done"},
+		{[]string{"42", "--grace-period", "1m"}, "--grace-period requires --follow-until-done"},
+		{[]string{"42", "--follow-until-done", "--until", "1h"}, "--until cannot be used with --watch"},
+	} {
+		cmd := NewLogsCmd(&Stack{})
+		cmd.SetArgs(test.args)
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Fatalf("%v: error %v, want %q", test.args, err, test.want)
+		}
+	}
+}
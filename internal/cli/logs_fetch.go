@@ -0,0 +1,228 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// defaultLogConcurrency is the number of shards of a time range fetched at
+// once by default.
+const defaultLogConcurrency = 4
+
+// Time ranges are split into concurrency*logShardsPerWorker shards, of at
+// least minLogShardDuration, so a slow shard doesn't hold the others back.
+const (
+	logShardsPerWorker  = 4
+	minLogShardDuration = 5 * time.Minute
+)
+
+// logShardPagesAhead is the number of pages a shard fetches ahead of the one
+// being handled, so only a few pages of each shard are held at a time.
+const logShardPagesAhead = 2
+
+// maxThrottledRetries is the number of times a throttled page is fetched
+// again, on top of the retries of the SDK.
+const maxThrottledRetries = 8
+
+// logFetcher fetches the events of FilterLogEvents requests. The time range
+// of a request is split into shards fetched concurrently, and their events
+// are passed on in timestamp order as their pages are fetched.
+type logFetcher struct {
+	cwl         cloudwatchlogs.FilterLogEventsAPIClient
+	concurrency int
+	logger      *log.Logger
+	prefix      string
+	// backoff returns how long to wait before fetching a throttled page
+	// again.
+	backoff func(attempt int) time.Duration
+}
+
+func newLogFetcher(cwl cloudwatchlogs.FilterLogEventsAPIClient, concurrency int) *logFetcher {
+	return &logFetcher{
+		cwl:         cwl,
+		concurrency: max(concurrency, 1),
+		logger:      log.New(io.Discard, "", 0),
+		backoff:     throttledBackoff,
+	}
+}
+
+// throttledBackoff doubles the delay of each attempt from 500ms, up to 20s,
+// with jitter.
+func throttledBackoff(attempt int) time.Duration {
+	delay := min(500*time.Millisecond<<attempt, 20*time.Second)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// logShard is a time range of a request, in Unix milliseconds. A zero end
+// fetches up to now.
+type logShard struct {
+	start, end int64
+}
+
+// logShards splits [start, end] into consecutive shards. An open range is
+// closed at now, except for its last shard.
+func logShards(start, end int64, concurrency int, now time.Time) []logShard {
+	last := end
+	if last == 0 {
+		last = now.UnixMilli()
+	}
+	if start <= 0 || concurrency <= 1 || last <= start {
+		return []logShard{{start: start, end: end}}
+	}
+
+	count := int64(concurrency * logShardsPerWorker)
+	size := max((last-start+1+count-1)/count, minLogShardDuration.Milliseconds())
+	var shards []logShard
+	for shardStart := start; shardStart <= last; shardStart += size {
+		shards = append(shards, logShard{start: shardStart, end: min(shardStart+size-1, last)})
+	}
+	shards[len(shards)-1].end = end
+	return shards
+}
+
+// logShardPages passes the pages of a shard from the goroutine fetching them
+// to fetch.
+type logShardPages struct {
+	pages chan []cwltypes.FilteredLogEvent
+	// err is set before pages is closed.
+	err error
+}
+
+// fetch calls handle with the events of input. The shards are fetched
+// concurrency at a time, ahead of the one being handled, and handled in
+// order. Each shard only fetches logShardPagesAhead pages ahead of the one
+// being handled. The shards don't overlap, so each event is handled once.
+// input isn't modified.
+func (f *logFetcher) fetch(ctx context.Context, input *cloudwatchlogs.FilterLogEventsInput, handle func(cwltypes.FilteredLogEvent) error) error {
+	shards := logShards(aws.ToInt64(input.StartTime), aws.ToInt64(input.EndTime), f.concurrency, time.Now())
+	if len(shards) == 1 {
+		return f.fetchPages(ctx, input, handle)
+	}
+	f.logger.Printf("[%s]: Fetching %d shards, %d at a time", f.prefix, len(shards), f.concurrency)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fetched := make([]*logShardPages, len(shards))
+	start := func(i int) {
+		shardInput := *input
+		shardInput.StartTime = aws.Int64(shards[i].start)
+		shardInput.EndTime = nil
+		if shards[i].end != 0 {
+			shardInput.EndTime = aws.Int64(shards[i].end)
+		}
+		shard := &logShardPages{pages: make(chan []cwltypes.FilteredLogEvent, logShardPagesAhead)}
+		fetched[i] = shard
+		go func() {
+			defer close(shard.pages)
+			shard.err = f.fetchPageEvents(ctx, &shardInput, func(events []cwltypes.FilteredLogEvent) error {
+				select {
+				case shard.pages <- events:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+		}()
+	}
+	for i := range min(f.concurrency, len(shards)) {
+		start(i)
+	}
+
+	for i := range shards {
+		if err := f.handleShard(ctx, fetched[i], handle); err != nil {
+			return err
+		}
+		if next := i + f.concurrency; next < len(shards) {
+			start(next)
+		}
+	}
+	return nil
+}
+
+// handleShard calls handle with the events of a shard as its pages are
+// fetched.
+func (f *logFetcher) handleShard(ctx context.Context, shard *logShardPages, handle func(cwltypes.FilteredLogEvent) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case events, ok := <-shard.pages:
+			if !ok {
+				return shard.err
+			}
+			for _, event := range events {
+				if err := handle(event); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// fetchPages calls handle with the events of input, page by page.
+func (f *logFetcher) fetchPages(ctx context.Context, input *cloudwatchlogs.FilterLogEventsInput, handle func(cwltypes.FilteredLogEvent) error) error {
+	return f.fetchPageEvents(ctx, input, func(events []cwltypes.FilteredLogEvent) error {
+		for _, event := range events {
+			if err := handle(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// fetchPageEvents calls handle with the events of each page of input.
+func (f *logFetcher) fetchPageEvents(ctx context.Context, input *cloudwatchlogs.FilterLogEventsInput, handle func([]cwltypes.FilteredLogEvent) error) error {
+	pageInput := *input
+	pageInput.NextToken = nil
+	for {
+		f.logger.Printf("[%s]: Fetching next page", f.prefix)
+		output, err := f.fetchPage(ctx, &pageInput)
+		if err != nil {
+			return err
+		}
+		if output.NextToken != nil {
+			f.logger.Printf("[%s]: Received %d events (next token: %s)", f.prefix, len(output.Events), *output.NextToken)
+		} else {
+			f.logger.Printf("[%s]: Received %d events", f.prefix, len(output.Events))
+		}
+		if err := handle(output.Events); err != nil {
+			return err
+		}
+		if output.NextToken == nil || aws.ToString(output.NextToken) == aws.ToString(pageInput.NextToken) {
+			return nil
+		}
+		pageInput.NextToken = output.NextToken
+	}
+}
+
+// fetchPage fetches a page, and fetches it again with backoff while it's
+// throttled.
+func (f *logFetcher) fetchPage(ctx context.Context, input *cloudwatchlogs.FilterLogEventsInput) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+	for attempt := 0; ; attempt++ {
+		output, err := f.cwl.FilterLogEvents(ctx, input)
+		if err == nil || attempt == maxThrottledRetries || !isThrottlingError(err) {
+			return output, err
+		}
+		delay := f.backoff(attempt)
+		f.logger.Printf("[%s]: Throttled, retrying in %s: %v", f.prefix, delay, err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func isThrottlingError(err error) bool {
+	return retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary
+}
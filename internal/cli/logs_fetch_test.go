@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// shardedCloudWatchLogsClient returns one event per minute in the requested
+// time range, in pages of 10 events. The first request of each range is
+// throttled.
+type shardedCloudWatchLogsClient struct {
+	mu        sync.Mutex
+	throttled map[int64]bool
+	inFlight  int
+	maxFlight int
+	// pages counts the pages returned.
+	pages int
+	err   error
+}
+
+func (c *shardedCloudWatchLogsClient) FilterLogEvents(ctx context.Context, params *cloudwatchlogs.FilterLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+	start, end := aws.ToInt64(params.StartTime), aws.ToInt64(params.EndTime)
+	c.mu.Lock()
+	if c.throttled == nil {
+		c.throttled = map[int64]bool{}
+	}
+	throttle := !c.throttled[start]
+	c.throttled[start] = true
+	c.inFlight++
+	c.maxFlight = max(c.maxFlight, c.inFlight)
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.inFlight--
+		c.mu.Unlock()
+	}()
+	time.Sleep(time.Millisecond)
+
+	if c.err != nil {
+		return nil, c.err
+	}
+	if throttle {
+		return nil, &cwltypes.ThrottlingException{Message: aws.String("Rate exceeded")}
+	}
+
+	minute := time.Minute.Milliseconds()
+	first := (start + minute - 1) / minute * minute
+	skip, _ := strconv.Atoi(aws.ToString(params.NextToken))
+	output := &cloudwatchlogs.FilterLogEventsOutput{}
+	for timestamp := first + int64(skip)*minute; timestamp <= end && len(output.Events) < 10; timestamp += minute {
+		output.Events = append(output.Events, cwltypes.FilteredLogEvent{
+			EventId:   aws.String(strconv.FormatInt(timestamp, 10)),
+			Timestamp: aws.Int64(timestamp),
+		})
+	}
+	if len(output.Events) == 10 && first+int64(skip+10)*minute <= end {
+		output.NextToken = aws.String(strconv.Itoa(skip + 10))
+	}
+	c.mu.Lock()
+	c.pages++
+	c.mu.Unlock()
+	return output, nil
+}
+
+func TestLogShards(t *testing.T) {
+	now := time.UnixMilli(10_000_000)
+	for _, test := range []struct {
+		start, end  int64
+		concurrency int
+		want        string
+	}{
+		{1000, 0, 1, "[{1000 0}]"},
+		{0, 0, 4, "[{0 0}]"},
+		{1000, 60_000, 4, "[{1000 60000}]"},
+		{1, 1_200_000, 2, "[{1 300000} {300001 600000} {600001 900000} {900001 1200000}]"},
+		{9_000_001, 0, 2, "[{9000001 9300000} {9300001 9600000} {9600001 9900000} {9900001 0}]"},
+	} {
+		if got := fmt.Sprint(logShards(test.start, test.end, test.concurrency, now)); got != test.want {
+			t.Fatalf("logShards(%d, %d, %d) = %s, want %s", test.start, test.end, test.concurrency, got, test.want)
+		}
+	}
+}
+
+func TestLogFetcherMergesShardsInOrder(t *testing.T) {
+	cwl := &shardedCloudWatchLogsClient{}
+	fetcher := newLogFetcher(cwl, 3)
+	fetcher.backoff = func(int) time.Duration { return time.Millisecond }
+
+	end := 24 * time.Hour.Milliseconds()
+	input := &cloudwatchlogs.FilterLogEventsInput{StartTime: aws.Int64(1), EndTime: aws.Int64(end)}
+	var timestamps []int64
+	if err := fetcher.fetch(context.Background(), input, func(event cwltypes.FilteredLogEvent) error {
+		timestamps = append(timestamps, aws.ToInt64(event.Timestamp))
+		return nil
+	}); err != nil {
+		t.Fatalf("fetch returned error: %v", err)
+	}
+
+	if len(cwl.throttled) != 3*logShardsPerWorker {
+		t.Fatalf("expected %d shards, got %d", 3*logShardsPerWorker, len(cwl.throttled))
+	}
+	if cwl.maxFlight > 3 {
+		t.Fatalf("expected at most 3 concurrent fetches, got %d", cwl.maxFlight)
+	}
+	if len(timestamps) != 24*60 {
+		t.Fatalf("expected every event once, got %d events", len(timestamps))
+	}
+	for i, timestamp := range timestamps {
+		if want := int64(i+1) * time.Minute.Milliseconds(); timestamp != want {
+			t.Fatalf("event %d: timestamp %d, want %d", i, timestamp, want)
+		}
+	}
+	if aws.ToInt64(input.StartTime) != 1 || input.NextToken != nil {
+		t.Fatalf("expected the input to be left as is, got %+v", input)
+	}
+}
+
+func TestLogFetcherOnlyFetchesAFewPagesAhead(t *testing.T) {
+	cwl := &shardedCloudWatchLogsClient{}
+	fetcher := newLogFetcher(cwl, 2)
+	fetcher.backoff = func(int) time.Duration { return time.Millisecond }
+
+	input := &cloudwatchlogs.FilterLogEventsInput{StartTime: aws.Int64(1), EndTime: aws.Int64(24 * time.Hour.Milliseconds())}
+	handled := 0
+	if err := fetcher.fetch(context.Background(), input, func(cwltypes.FilteredLogEvent) error {
+		if handled++; handled == 1 {
+			// Let the shards fetch as far ahead as they can.
+			time.Sleep(100 * time.Millisecond)
+			cwl.mu.Lock()
+			pages := cwl.pages
+			cwl.mu.Unlock()
+			// Each shard holds the pages ahead, the one it's passing on,
+			// and the one being handled.
+			if limit := 2 * (logShardPagesAhead + 2); pages > limit {
+				t.Errorf("expected at most %d pages fetched ahead of the first event, got %d", limit, pages)
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("fetch returned error: %v", err)
+	}
+	if handled != 24*60 {
+		t.Fatalf("expected every event, got %d", handled)
+	}
+}
+
+func TestLogFetcherReturnsOtherErrors(t *testing.T) {
+	cwl := &shardedCloudWatchLogsClient{err: &cwltypes.ResourceNotFoundException{Message: aws.String("missing")}}
+	fetcher := newLogFetcher(cwl, 2)
+	fetcher.backoff = func(int) time.Duration {
+		t.Error("did not expect a retry")
+		return 0
+	}
+
+	input := &cloudwatchlogs.FilterLogEventsInput{StartTime: aws.Int64(1), EndTime: aws.Int64(time.Hour.Milliseconds())}
+	err := fetcher.fetch(context.Background(), input, func(cwltypes.FilteredLogEvent) error { return nil })
+	var notFound *cwltypes.ResourceNotFoundException
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected the error of the request, got %v", err)
+	}
+}
+
+func TestConcurrencyFlagValidation(t *testing.T) {
+	cmd := NewLogsCmd(&Stack{})
+	cmd.SetArgs([]string{"42", "--concurrency", "0"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "invalid --concurrency 0") {
+		t.Fatalf("expected --concurrency 0 to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// logFilter narrows the streamed logs down with the --filter, --grep and
+// --filter-pattern flags. Field filters and JSON filter patterns are part of
+// the CloudWatch filter pattern of every stream. A text filter pattern is sent
+// to CloudWatch for the streams that have no pattern of their own, and matched
+// locally for the others, since CloudWatch can't combine JSON and text
+// patterns. --grep is always matched locally, before the events are printed.
+type logFilter struct {
+	Fields  []logFieldFilter
+	Grep    *regexp.Regexp
+	Pattern string
+}
+
+// logFieldFilter matches a field of JSON log events, as in level=error or
+// repo!=runs-on/cli. The value may contain * wildcards.
+type logFieldFilter struct {
+	Key    string
+	Value  string
+	Negate bool
+}
+
+var logFieldKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+func parseLogFieldFilter(value string) (logFieldFilter, error) {
+	key, fieldValue, ok := strings.Cut(value, "=")
+	if !ok {
+		return logFieldFilter{}, fmt.Errorf("invalid --filter %q: expected KEY=VALUE or KEY!=VALUE", value)
+	}
+	filter := logFieldFilter{Key: strings.TrimSpace(key), Value: strings.TrimSpace(fieldValue)}
+	if strings.HasSuffix(filter.Key, "!") {
+		filter.Key = strings.TrimSpace(strings.TrimSuffix(filter.Key, "!"))
+		filter.Negate = true
+	}
+	if !logFieldKeyPattern.MatchString(filter.Key) {
+		return logFieldFilter{}, fmt.Errorf("invalid --filter %q: %q is not a field name", value, filter.Key)
+	}
+	return filter, nil
+}
+
+// expression returns the CloudWatch JSON pattern expression of the filter.
+func (f logFieldFilter) expression() string {
+	selector := "$." + f.Key
+	switch f.Value {
+	case "true", "false":
+		value := strings.ToUpper(f.Value)
+		if f.Negate {
+			value = map[string]string{"TRUE": "FALSE", "FALSE": "TRUE"}[value]
+		}
+		return fmt.Sprintf("( %s IS %s )", selector, value)
+	}
+	operator := "="
+	if f.Negate {
+		operator = "!="
+	}
+	if _, err := strconv.ParseFloat(f.Value, 64); err == nil {
+		return fmt.Sprintf("( %s %s %s )", selector, operator, f.Value)
+	}
+	return fmt.Sprintf("( %s %s %s )", selector, operator, strconv.Quote(f.Value))
+}
+
+func newLogFilter(fields []string, grep, pattern string) (*logFilter, error) {
+	filter := &logFilter{Pattern: strings.TrimSpace(pattern)}
+	for _, value := range fields {
+		field, err := parseLogFieldFilter(value)
+		if err != nil {
+			return nil, err
+		}
+		filter.Fields = append(filter.Fields, field)
+	}
+	if grep != "" {
+		expression, err := regexp.Compile(grep)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --grep: %w", err)
+		}
+		filter.Grep = expression
+	}
+	if filter.Pattern != "" && isJSONFilterPattern(filter.Pattern) && jsonFilterExpression(filter.Pattern) == "" {
+		return nil, fmt.Errorf("invalid --filter-pattern %q: empty JSON pattern", pattern)
+	}
+	if len(filter.Fields) == 0 && filter.Grep == nil && filter.Pattern == "" {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+const logFilterHelp = `Filters:
+  --filter KEY=VALUE    Keep the JSON events whose field matches, e.g. level=error,
+                        repo=runs-on/*. Use KEY!=VALUE to exclude. Repeat to combine.
+  --filter-pattern RAW  A CloudWatch Logs filter pattern, e.g. '{ $.duration > 60 }'
+                        or '"launch failed" -debug'.
+  --grep REGEX          A regular expression matched against the raw log line.
+
+Field filters and JSON filter patterns are evaluated by CloudWatch, so they hide
+the plain text instance and console logs. --grep, and text filter patterns that
+can't be combined with the job filters, are matched locally.`
+
+func addLogFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("filter", nil, "Only show JSON log events whose field matches (KEY=VALUE or KEY!=VALUE, * wildcards, repeatable)")
+	cmd.Flags().String("grep", "", "Only show log events matching this regular expression (matched locally)")
+	cmd.Flags().String("filter-pattern", "", "Raw CloudWatch Logs filter pattern to apply to the log events")
+}
+
+func logFilterFromFlags(cmd *cobra.Command) (*logFilter, error) {
+	fields, _ := cmd.Flags().GetStringArray("filter")
+	grep, _ := cmd.Flags().GetString("grep")
+	pattern, _ := cmd.Flags().GetString("filter-pattern")
+	return newLogFilter(fields, grep, pattern)
+}
+
+func isJSONFilterPattern(pattern string) bool {
+	return strings.HasPrefix(strings.TrimSpace(pattern), "{")
+}
+
+// jsonFilterExpression returns the expression of a JSON filter pattern,
+// without the surrounding braces.
+func jsonFilterExpression(pattern string) string {
+	pattern = strings.TrimSpace(pattern)
+	pattern = strings.TrimPrefix(pattern, "{")
+	pattern = strings.TrimSuffix(pattern, "}")
+	return strings.TrimSpace(pattern)
+}
+
+// selectsJSON reports whether only JSON log events can match the filter.
+func (f *logFilter) selectsJSON() bool {
+	return f != nil && (len(f.Fields) > 0 || isJSONFilterPattern(f.Pattern))
+}
+
+// streamFilter combines the filter with base, the filter pattern of a stream.
+// It returns the pattern to send to CloudWatch, and the function that matches
+// the rest of the filter on the received messages, if any.
+func (f *logFilter) streamFilter(base string) (string, func(string) bool) {
+	if f == nil {
+		return base, nil
+	}
+
+	var expressions []string
+	for _, field := range f.Fields {
+		expressions = append(expressions, field.expression())
+	}
+	textPattern := ""
+	if isJSONFilterPattern(f.Pattern) {
+		expressions = append(expressions, "( "+jsonFilterExpression(f.Pattern)+" )")
+	} else {
+		textPattern = f.Pattern
+	}
+
+	pattern := base
+	switch {
+	case len(expressions) > 0:
+		if base != "" {
+			expressions = append([]string{"( " + jsonFilterExpression(base) + " )"}, expressions...)
+		}
+		pattern = "{ " + strings.Join(expressions, " && ") + " }"
+	case textPattern != "" && base == "":
+		pattern, textPattern = textPattern, ""
+	}
+
+	if f.Grep == nil && textPattern == "" {
+		return pattern, nil
+	}
+	return pattern, func(message string) bool {
+		if textPattern != "" && !matchTextFilterPattern(textPattern, message) {
+			return false
+		}
+		return f.Grep == nil || f.Grep.MatchString(message)
+	}
+}
+
+// matchText matches the messages that don't come from CloudWatch, such as
+// the console output. Only --grep and text filter patterns apply to them.
+func (f *logFilter) matchText(message string) bool {
+	if f == nil {
+		return true
+	}
+	if f.Pattern != "" && !isJSONFilterPattern(f.Pattern) && !matchTextFilterPattern(f.Pattern, message) {
+		return false
+	}
+	return f.Grep == nil || f.Grep.MatchString(message)
+}
+
+// matchTextFilterPattern matches a message with a CloudWatch text filter
+// pattern: every term must be present, terms prefixed with - must not be, and
+// at least one of the terms prefixed with ? must be. Terms can be quoted.
+func matchTextFilterPattern(pattern, message string) bool {
+	matchedAny, hasAny := false, false
+	for _, term := range splitFilterPatternTerms(pattern) {
+		switch {
+		case strings.HasPrefix(term, "?"):
+			hasAny = true
+			if strings.Contains(message, unquoteFilterPatternTerm(term[1:])) {
+				matchedAny = true
+			}
+		case strings.HasPrefix(term, "-"):
+			if strings.Contains(message, unquoteFilterPatternTerm(term[1:])) {
+				return false
+			}
+		default:
+			if !strings.Contains(message, unquoteFilterPatternTerm(term)) {
+				return false
+			}
+		}
+	}
+	return !hasAny || matchedAny
+}
+
+func splitFilterPatternTerms(pattern string) []string {
+	var (
+		terms   []string
+		current strings.Builder
+		quoted  bool
+	)
+	for _, r := range pattern {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case r == ' ' && !quoted:
+			if current.Len() > 0 {
+				terms = append(terms, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		terms = append(terms, current.String())
+	}
+	return terms
+}
+
+func unquoteFilterPatternTerm(term string) string {
+	if len(term) >= 2 && strings.HasPrefix(term, `"`) && strings.HasSuffix(term, `"`) {
+		return term[1 : len(term)-1]
+	}
+	return term
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestParseLogFieldFilter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"level=error", `( $.level = "error" )`},
+		{"repo!=runs-on/*", `( $.repo != "runs-on/*" )`},
+		{"status=500", `( $.status = 500 )`},
+		{"github.retry=true", `( $.github.retry IS TRUE )`},
+		{"cached!=true", `( $.cached IS FALSE )`},
+	}
+	for _, test := range tests {
+		filter, err := parseLogFieldFilter(test.value)
+		if err != nil {
+			t.Fatalf("parseLogFieldFilter(%q) returned error: %v", test.value, err)
+		}
+		if got := filter.expression(); got != test.want {
+			t.Fatalf("parseLogFieldFilter(%q).expression() = %q, want %q", test.value, got, test.want)
+		}
+	}
+
+	for _, value := range []string{"level", "=error", "$.level=error"} {
+		if _, err := parseLogFieldFilter(value); err == nil {
+			t.Fatalf("expected parseLogFieldFilter(%q) to fail", value)
+		}
+	}
+}
+
+func TestNewLogFilterWithoutFlagsIsNil(t *testing.T) {
+	filter, err := newLogFilter(nil, "", " ")
+	if err != nil || filter != nil {
+		t.Fatalf("expected no filter, got %+v (%v)", filter, err)
+	}
+	if _, err := newLogFilter(nil, "(", ""); err == nil || !strings.Contains(err.Error(), "invalid --grep") {
+		t.Fatalf("expected an invalid --grep error, got %v", err)
+	}
+}
+
+func TestLogFilterStreamFilter(t *testing.T) {
+	jobPattern := `{ ( $.job_id = "42" ) }`
+	tests := []struct {
+		name        string
+		fields      []string
+		pattern     string
+		base        string
+		want        string
+		matchLocal  bool
+		localAccept string
+		localReject string
+	}{
+		{
+			name:   "fields without base",
+			fields: []string{"level=error", "repo=runs-on/cli"},
+			want:   `{ ( $.level = "error" ) && ( $.repo = "runs-on/cli" ) }`,
+		},
+		{
+			name:   "fields with job pattern",
+			fields: []string{"level=error"},
+			base:   jobPattern,
+			want:   `{ ( ( $.job_id = "42" ) ) && ( $.level = "error" ) }`,
+		},
+		{
+			name:    "JSON pattern with job pattern",
+			pattern: `{ $.duration > 60 }`,
+			base:    jobPattern,
+			want:    `{ ( ( $.job_id = "42" ) ) && ( $.duration > 60 ) }`,
+		},
+		{
+			name:    "text pattern without base",
+			pattern: `"launch failed" -debug`,
+			want:    `"launch failed" -debug`,
+		},
+		{
+			name:        "text pattern with job pattern",
+			pattern:     `"launch failed" -debug`,
+			base:        jobPattern,
+			want:        jobPattern,
+			matchLocal:  true,
+			localAccept: `{"message":"launch failed"}`,
+			localReject: `{"message":"launch failed","level":"debug"}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := newLogFilter(test.fields, "", test.pattern)
+			if err != nil {
+				t.Fatalf("newLogFilter returned error: %v", err)
+			}
+			pattern, match := filter.streamFilter(test.base)
+			if pattern != test.want {
+				t.Fatalf("streamFilter() pattern = %q, want %q", pattern, test.want)
+			}
+			if (match != nil) != test.matchLocal {
+				t.Fatalf("expected local matching %t", test.matchLocal)
+			}
+			if match != nil && (!match(test.localAccept) || match(test.localReject)) {
+				t.Fatalf("unexpected local matching of %q and %q", test.localAccept, test.localReject)
+			}
+		})
+	}
+}
+
+func TestMatchTextFilterPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		message string
+		want    bool
+	}{
+		{"ERROR", "level=ERROR launch", true},
+		{"ERROR launch", "level=ERROR", false},
+		{`"launch failed"`, "instance launch failed", true},
+		{"ERROR -timeout", "ERROR timeout", false},
+		{"?ERROR ?WARN", "WARN disk", true},
+		{"?ERROR ?WARN", "INFO disk", false},
+	}
+	for _, test := range tests {
+		if got := matchTextFilterPattern(test.pattern, test.message); got != test.want {
+			t.Fatalf("matchTextFilterPattern(%q, %q) = %t, want %t", test.pattern, test.message, got, test.want)
+		}
+	}
+}
+
+func TestApplicationLogsAreFilteredBeforePrinting(t *testing.T) {
+	filter, err := newLogFilter([]string{"level=error"}, "^nomatch", "")
+	if err != nil {
+		t.Fatalf("newLogFilter returned error: %v", err)
+	}
+	cwl := &mockCloudWatchLogsClient{}
+	streamer := &applicationLogStreamer{
+		cwl:     cwl,
+		outputs: &StackOutputs{ServiceLogGroupName: "/aws/ecs/runs-on/flexd"},
+	}
+	var output bytes.Buffer
+	if err := streamer.Stream(context.Background(), &LogOptions{StartTime: 1, NoColor: true, Out: &output, Filter: filter}); err != nil {
+		t.Fatalf("Stream returned error: %v", err)
+	}
+
+	if got := aws.ToString(cwl.inputs[0].FilterPattern); got != `{ ( $.level = "error" ) }` {
+		t.Fatalf("expected the field filter to be sent to CloudWatch, got %q", got)
+	}
+	if output.Len() != 0 {
+		t.Fatalf("expected --grep to drop the event, got %q", output.String())
+	}
+}
@@ -0,0 +1,227 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	logFormatLong     = "long"
+	logFormatShort    = "short"
+	logFormatPretty   = "pretty"
+	logTemplatePrefix = "template="
+)
+
+// logFormatter prints the streamed log events in the --format of the log
+// commands: long, short, pretty, or template=<Go template>.
+type logFormatter struct {
+	format   string
+	fields   []string
+	template *template.Template
+}
+
+func newLogFormatter(format string, fields []string) (*logFormatter, error) {
+	if format == "" {
+		format = logFormatLong
+	}
+	formatter := &logFormatter{format: format}
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			formatter.fields = append(formatter.fields, field)
+		}
+	}
+
+	switch {
+	case format == logFormatLong, format == logFormatShort:
+	case format == logFormatPretty:
+		return formatter, nil
+	case strings.HasPrefix(format, logTemplatePrefix):
+		text := strings.TrimPrefix(format, logTemplatePrefix)
+		tmpl, err := template.New("format").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --format template: %w", err)
+		}
+		formatter.format = logTemplatePrefix
+		formatter.template = tmpl
+	default:
+		return nil, fmt.Errorf("invalid --format %q (valid: long, short, pretty, template=<Go template>)", format)
+	}
+	if len(formatter.fields) > 0 {
+		return nil, fmt.Errorf("--fields requires --format pretty")
+	}
+	return formatter, nil
+}
+
+func (f *logFormatter) write(w io.Writer, event *logEvent) error {
+	switch f.format {
+	case logFormatPretty:
+		event.printPretty(w, f.fields)
+	case logTemplatePrefix:
+		return event.printTemplate(w, f.template)
+	default:
+		event.print(w, f.format)
+	}
+	return nil
+}
+
+// applicationLogFields returns the fields of a JSON log event, or nil when the
+// message isn't a JSON object.
+func (e *logEvent) applicationLogFields() map[string]any {
+	var fields map[string]any
+	decoder := json.NewDecoder(strings.NewReader(e.message))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+// printPretty prints a log event with its level colored, followed by the
+// selected fields, or by every field when none are selected. Messages that
+// aren't JSON are printed as they are.
+func (e *logEvent) printPretty(w io.Writer, selected []string) {
+	fields := e.applicationLogFields()
+	localTime := time.UnixMilli(e.timestamp).Local()
+	message := e.message
+	level := ""
+	if fields != nil {
+		if value, ok := fields["time"].(string); ok {
+			if parsed, err := time.Parse(time.RFC3339Nano, value); err == nil {
+				localTime = parsed.Local()
+			}
+		}
+		level, _ = firstStringField(fields, "level", "lvl", "severity")
+		message, _ = firstStringField(fields, "message", "msg")
+	}
+
+	var line strings.Builder
+	line.WriteString(e.colorize("\033[90m", localTime.Format("2006-01-02T15:04:05.000Z07:00")))
+	if e.label != "" {
+		line.WriteString(" " + e.colorize("\033[36m", "["+e.label+"]"))
+	}
+	line.WriteString(" " + e.colorize("\033[90m", "["+e.streamName()+"]"))
+	if level != "" {
+		line.WriteString(" " + e.colorize(logLevelColor(level), fmt.Sprintf("%-5s", strings.ToUpper(level))))
+	}
+	if message != "" {
+		line.WriteString(" " + message)
+	}
+
+	if fields != nil {
+		names := selected
+		if len(names) == 0 {
+			names = slices.Sorted(maps.Keys(fields))
+			names = slices.DeleteFunc(names, func(name string) bool {
+				switch name {
+				case "time", "level", "lvl", "severity", "message", "msg":
+					return true
+				}
+				return false
+			})
+		}
+		for _, name := range names {
+			value, ok := fields[name]
+			if !ok {
+				continue
+			}
+			line.WriteString(" " + e.colorize("\033[36m", name+"=") + formatLogFieldValue(value))
+		}
+	}
+	fmt.Fprintln(w, line.String())
+}
+
+// printTemplate executes tmpl with the fields of a JSON log event. The source,
+// stream, stack, time and message of the event are available too, unless the
+// event has fields with these names.
+func (e *logEvent) printTemplate(w io.Writer, tmpl *template.Template) error {
+	data := e.applicationLogFields()
+	if data == nil {
+		data = map[string]any{}
+	}
+	record := e.record()
+	defaults := map[string]any{
+		"time":    record.Time.Format(time.RFC3339Nano),
+		"message": record.Message,
+		"source":  record.Source,
+		"stream":  record.Stream,
+		"stack":   record.Stack,
+	}
+	for name, value := range defaults {
+		if _, ok := data[name]; !ok {
+			data[name] = value
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, data); err != nil {
+		return fmt.Errorf("execute --format template: %w", err)
+	}
+	if !bytes.HasSuffix(buffer.Bytes(), []byte("\n")) {
+		buffer.WriteByte('\n')
+	}
+	_, err := w.Write(buffer.Bytes())
+	return err
+}
+
+func (e *logEvent) streamName() string {
+	switch e.prefix {
+	case "application", "console":
+		return e.prefix
+	}
+	return e.stream
+}
+
+func (e *logEvent) colorize(color, text string) string {
+	if e.noColor || color == "" {
+		return text
+	}
+	return color + text + "\033[0m"
+}
+
+func logLevelColor(level string) string {
+	switch strings.ToLower(level) {
+	case "error", "fatal", "panic", "critical":
+		return "\033[31m" // red
+	case "warn", "warning":
+		return "\033[33m" // yellow
+	case "info":
+		return "\033[32m" // green
+	case "debug", "trace":
+		return "\033[90m" // gray
+	}
+	return ""
+}
+
+func firstStringField(fields map[string]any, names ...string) (string, bool) {
+	for _, name := range names {
+		if value, ok := fields[name].(string); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func formatLogFieldValue(value any) string {
+	switch value := value.(type) {
+	case string:
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			return strconv.Quote(value)
+		}
+		return value
+	case json.Number:
+		return value.String()
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testApplicationLogMessage = `{"level":"error","time":"2026-05-08T12:00:00Z","message":"launch failed","job_id":42,"repo":"runs-on/cli","labels":["linux","x64"]}`
+
+func TestNewLogFormatterValidation(t *testing.T) {
+	for _, format := range []string{"", "long", "short", "pretty", "template={{.message}}"} {
+		if _, err := newLogFormatter(format, nil); err != nil {
+			t.Fatalf("newLogFormatter(%q) returned error: %v", format, err)
+		}
+	}
+	for _, test := range []struct {
+		format string
+		fields []string
+		want   string
+	}{
+		{"json", nil, `invalid --format "json"`},
+		{"template={{.message", nil, "invalid --format template"},
+		{"short", []string{"job_id"}, "--fields requires --format pretty"},
+	} {
+		if _, err := newLogFormatter(test.format, test.fields); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Fatalf("newLogFormatter(%q, %v) error = %v, want %q", test.format, test.fields, err, test.want)
+		}
+	}
+}
+
+func TestLogEventPrintPretty(t *testing.T) {
+	event := &logEvent{message: testApplicationLogMessage, prefix: "application", stream: "flexd/app", timestamp: 1, noColor: true}
+
+	var output bytes.Buffer
+	event.printPretty(&output, nil)
+	line := output.String()
+	wantTime := time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC).Local().Format("2006-01-02T15:04:05.000Z07:00")
+	if !strings.HasPrefix(line, wantTime+" [application] ERROR launch failed ") {
+		t.Fatalf("unexpected pretty line %q", line)
+	}
+	if !strings.HasSuffix(line, ` job_id=42 labels=["linux","x64"] repo=runs-on/cli`+"\n") {
+		t.Fatalf("expected every other field in name order, got %q", line)
+	}
+
+	output.Reset()
+	event.printPretty(&output, []string{"repo", "missing", "job_id"})
+	if !strings.HasSuffix(output.String(), "launch failed repo=runs-on/cli job_id=42\n") {
+		t.Fatalf("expected only the selected fields, got %q", output.String())
+	}
+
+	output.Reset()
+	event.noColor = false
+	event.printPretty(&output, nil)
+	if !strings.Contains(output.String(), "\033[31mERROR\033[0m") {
+		t.Fatalf("expected the error level in red, got %q", output.String())
+	}
+
+	output.Reset()
+	plain := &logEvent{message: "Cloud-init finished", stream: "i-123/cloud-init", timestamp: 1, noColor: true}
+	plain.printPretty(&output, []string{"job_id"})
+	if !strings.HasSuffix(output.String(), " [i-123/cloud-init] Cloud-init finished\n") {
+		t.Fatalf("expected a plain message to be printed as is, got %q", output.String())
+	}
+}
+
+func TestLogEventPrintTemplate(t *testing.T) {
+	formatter, err := newLogFormatter(`template={{.time}} {{.level}} [{{.source}}] {{.message}} {{index .labels 0}}`, nil)
+	if err != nil {
+		t.Fatalf("newLogFormatter returned error: %v", err)
+	}
+
+	var output bytes.Buffer
+	event := &logEvent{message: testApplicationLogMessage, prefix: "application", stream: "flexd/app", timestamp: 1}
+	if err := formatter.write(&output, event); err != nil {
+		t.Fatalf("write returned error: %v", err)
+	}
+	if got, want := output.String(), "2026-05-08T12:00:00Z error [application] launch failed linux\n"; got != want {
+		t.Fatalf("template output = %q, want %q", got, want)
+	}
+
+	output.Reset()
+	formatter, _ = newLogFormatter(`template={{.stream}}: {{.message}}`, nil)
+	if err := formatter.write(&output, &logEvent{message: "plain", stream: "i-123/cloud-init"}); err != nil {
+		t.Fatalf("write returned error: %v", err)
+	}
+	if output.String() != "i-123/cloud-init: plain\n" {
+		t.Fatalf("unexpected template output for a plain event %q", output.String())
+	}
+}
@@ -7,22 +7,70 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
 	cloudtrailtypes "github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 )
 
+// fullLogWindowPadding is the default padding of the --full window, before
+// the job is created and after it is completed.
 const fullLogWindowPadding = time.Hour
 
+// defaultFullArtifactWorkers is the number of artifacts of a --full archive
+// collected at once. The CloudWatch artifacts are fetched in shards of their
+// own, so this is kept low to stay clear of the CloudWatch Logs quotas.
+const defaultFullArtifactWorkers = 4
+
+// fullLogWindow sets the time range of a --full archive. Since and Until
+// replace the padded creation and completion times of the job.
+type fullLogWindow struct {
+	Before time.Duration
+	After  time.Duration
+	Since  time.Time
+	Until  time.Time
+}
+
+// bounds returns the time range of the archive of a job. The range ends After
+// the completion of the job, or now while it runs. When the completion time
+// is unknown, it ends After the creation of the job.
+func (w fullLogWindow) bounds(facts *workflowJobFacts, now time.Time) (time.Time, time.Time, error) {
+	createdAt, err := facts.createdAtOrError()
+	if err != nil && (w.Since.IsZero() || w.Until.IsZero()) {
+		return time.Time{}, time.Time{}, err
+	}
+
+	start := w.Since
+	if start.IsZero() {
+		start = createdAt.Add(-w.Before)
+	}
+	end := w.Until
+	switch {
+	case !end.IsZero():
+	case !facts.CompletedAt.IsZero():
+		end = facts.CompletedAt.Add(w.After)
+	case facts.Status != "" && facts.Status != "completed":
+		end = now
+	default:
+		end = createdAt.Add(w.After)
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("the window of job %d ends before it starts (%s to %s)", facts.JobID, start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339))
+	}
+	return start, end, nil
+}
+
 type fullLogManifest struct {
 	StackName          string              `json:"stack_name,omitempty"`
 	Region             string              `json:"region,omitempty"`
@@ -31,7 +79,39 @@ type fullLogManifest struct {
 	WindowStart        time.Time           `json:"window_start"`
 	WindowEnd          time.Time           `json:"window_end"`
 	AttemptedInstances []string            `json:"attempted_instances"`
+	Artifacts          []fullArtifact      `json:"artifacts"`
 	Errors             []fullArtifactError `json:"errors,omitempty"`
+	Notes              []string            `json:"notes,omitempty"`
+}
+
+// fullRunLogManifest is the manifest of an archive of every job of a run.
+// Its window covers the windows of all the jobs.
+type fullRunLogManifest struct {
+	StackName   string                  `json:"stack_name,omitempty"`
+	Region      string                  `json:"region,omitempty"`
+	RunID       int64                   `json:"run_id"`
+	WindowStart time.Time               `json:"window_start"`
+	WindowEnd   time.Time               `json:"window_end"`
+	Jobs        []fullRunLogManifestJob `json:"jobs"`
+	Artifacts   []fullArtifact          `json:"artifacts"`
+	Errors      []fullArtifactError     `json:"errors,omitempty"`
+	Notes       []string                `json:"notes,omitempty"`
+}
+
+type fullRunLogManifestJob struct {
+	JobID              int64     `json:"job_id"`
+	JobName            string    `json:"job_name,omitempty"`
+	Path               string    `json:"path"`
+	WindowStart        time.Time `json:"window_start"`
+	WindowEnd          time.Time `json:"window_end"`
+	AttemptedInstances []string  `json:"attempted_instances"`
+}
+
+// fullArtifact records how long an artifact took to collect.
+type fullArtifact struct {
+	Path            string  `json:"path"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Failed          bool    `json:"failed,omitempty"`
 }
 
 type fullArtifactError struct {
@@ -43,11 +123,20 @@ type fullLogExporter struct {
 	cwl        cloudWatchLogsAPI
 	jobs       workflowJobsAPI
 	ec2        ec2ConsoleAPI
+	instances  ec2InstanceAPI
 	cloudtrail cloudTrailLookupAPI
 	outputs    *StackOutputs
 	jobsTable  string
 	stackName  string
 	region     string
+	// concurrency is the number of shards of a time range fetched at once.
+	concurrency int
+	window      fullLogWindow
+	// workers is the number of artifacts collected at once.
+	workers int
+	// progress receives a line per collected artifact. It is nil to collect
+	// silently.
+	progress io.Writer
 }
 
 type cloudTrailLookupAPI interface {
@@ -55,14 +144,19 @@ type cloudTrailLookupAPI interface {
 }
 
 func newFullLogExporter(config *RunsOnConfig) *fullLogExporter {
+	ec2Client := ec2.NewFromConfig(config.AWSConfig)
 	return &fullLogExporter{
-		cwl:        cloudwatchlogs.NewFromConfig(config.AWSConfig),
-		jobs:       dynamodb.NewFromConfig(config.AWSConfig),
-		ec2:        ec2.NewFromConfig(config.AWSConfig),
-		cloudtrail: cloudtrail.NewFromConfig(config.AWSConfig),
-		jobsTable:  config.WorkflowJobsTable,
-		stackName:  config.StackName,
-		region:     config.AWSConfig.Region,
+		cwl:         newCloudWatchLogsClient(config.AWSConfig),
+		jobs:        dynamodb.NewFromConfig(config.AWSConfig),
+		ec2:         ec2Client,
+		instances:   ec2Client,
+		cloudtrail:  cloudtrail.NewFromConfig(config.AWSConfig),
+		jobsTable:   config.WorkflowJobsTable,
+		stackName:   config.StackName,
+		region:      config.AWSConfig.Region,
+		concurrency: defaultLogConcurrency,
+		window:      fullLogWindow{Before: fullLogWindowPadding, After: fullLogWindowPadding},
+		workers:     defaultFullArtifactWorkers,
 		outputs: &StackOutputs{
 			ServiceLogGroupName:    config.ServiceLogGroupName,
 			EC2InstanceLogGroupArn: config.EC2InstanceLogGroupArn,
@@ -79,110 +173,276 @@ func (f *fullLogExporter) Export(ctx context.Context, jobID string) (string, err
 		return "", fmt.Errorf("job %s not found in workflow jobs table", jobID)
 	}
 
-	createdAt, err := facts.createdAtOrError()
+	windowStart, windowEnd, err := f.window.bounds(facts, time.Now())
 	if err != nil {
 		return "", err
 	}
-	windowStart := createdAt.Add(-fullLogWindowPadding)
-	windowEnd := createdAt.Add(fullLogWindowPadding)
-	instanceIDs := facts.AttemptedInstanceIDs
 	parsedJobID := strconv.FormatInt(facts.JobID, 10)
 
 	zipPath := fmt.Sprintf("roc-logs-%s-%s.zip", parsedJobID, time.Now().Format("2006-01-02-15-04-05"))
 
-	archive, err := newArchiveWriter(zipPath)
+	archive, err := newFullArchive(zipPath)
 	if err != nil {
-		return "", fmt.Errorf("create full log archive: %w", err)
+		return "", err
 	}
 	defer archive.Close()
 
-	artifactErrors := make([]fullArtifactError, 0)
-	addArtifactError := func(artifactPath string, err error) {
-		if err == nil {
-			return
-		}
-		artifactErrors = append(artifactErrors, fullArtifactError{Path: artifactPath, Error: err.Error()})
-		_ = archive.writeJSON(errorPathFor(artifactPath), map[string]string{"error": err.Error()})
+	evictions := &spotEvictions{cloudtrail: f.cloudtrail, start: windowStart, end: windowEnd}
+	tasks := f.jobArtifactTasks(archive, "", facts, windowStart, windowEnd, evictions)
+	runLogsPath := fmt.Sprintf("server/run-%d.jsonl", facts.RunID)
+	if facts.RunID == 0 {
+		archive.addError(runLogsPath, fmt.Errorf("workflow run ID for job %s is not available", parsedJobID))
+	} else {
+		tasks = append(tasks, f.runLogsTask(archive, runLogsPath, facts.RunID, windowStart, windowEnd))
 	}
+	f.collect(ctx, archive, tasks)
 
-	jobRecordPath := fmt.Sprintf("dynamodb/job-%s.ddb.json", parsedJobID)
-	if data, err := facts.rawDynamoDBItemJSON(); err != nil {
-		addArtifactError(jobRecordPath, err)
-	} else if err := archive.writeBytes(jobRecordPath, prettyJSON(data)); err != nil {
-		addArtifactError(jobRecordPath, err)
+	manifest := fullLogManifest{
+		StackName:          f.stackName,
+		Region:             f.region,
+		JobID:              facts.JobID,
+		RunID:              facts.RunID,
+		WindowStart:        windowStart.UTC(),
+		WindowEnd:          windowEnd.UTC(),
+		AttemptedInstances: facts.AttemptedInstanceIDs,
+		Artifacts:          archive.artifacts,
+		Errors:             archive.errors,
 	}
+	if len(facts.AttemptedInstanceIDs) > 0 {
+		manifest.Notes = []string{fullSpotEventsNote}
+	}
+	return archive.finish(zipPath, manifest)
+}
 
-	jobLogsPath := fmt.Sprintf("server/job-%s.jsonl", parsedJobID)
-	if err := f.writeCloudWatchMessages(ctx, archive, jobLogsPath, cloudWatchLogRequest{
-		LogGroupIdentifier: f.outputs.ServiceLogGroupName,
-		FilterPattern:      fullJobFilterPattern(parsedJobID, instanceIDs),
-		StartTime:          windowStart,
-		EndTime:            windowEnd,
-	}); err != nil {
-		addArtifactError(jobLogsPath, err)
+// ExportRun writes one archive for the jobs of a run. The artifacts of each
+// job go in a jobs/<job_id> directory laid out like the archive of a single
+// job, and the run logs are fetched once over the windows of every job.
+func (f *fullLogExporter) ExportRun(ctx context.Context, runID int64, jobs []*workflowJobFacts) (string, error) {
+	if len(jobs) == 0 {
+		return "", fmt.Errorf("no jobs found for run %d in workflow jobs table", runID)
 	}
 
-	runLogsPath := fmt.Sprintf("server/run-%d.jsonl", facts.RunID)
-	if facts.RunID == 0 {
-		addArtifactError(runLogsPath, fmt.Errorf("workflow run ID for job %s is not available", parsedJobID))
-	} else if err := f.writeCloudWatchMessages(ctx, archive, runLogsPath, cloudWatchLogRequest{
-		LogGroupIdentifier: f.outputs.ServiceLogGroupName,
-		FilterPattern:      runFilterPattern(facts.RunID),
-		StartTime:          windowStart,
-		EndTime:            windowEnd,
-	}); err != nil {
-		addArtifactError(runLogsPath, err)
+	now := time.Now()
+	zipPath := fmt.Sprintf("roc-logs-run-%d-%s.zip", runID, now.Format("2006-01-02-15-04-05"))
+
+	archive, err := newFullArchive(zipPath)
+	if err != nil {
+		return "", err
 	}
+	defer archive.Close()
 
-	for _, instanceID := range instanceIDs {
-		instanceDir := path.Join("instances", instanceID)
-		cloudTrailPath := path.Join(instanceDir, "cloudtrail.json")
-		if err := f.writeCloudTrailEvents(ctx, archive, cloudTrailPath, instanceID, windowStart, windowEnd); err != nil {
-			addArtifactError(cloudTrailPath, err)
+	manifest := fullRunLogManifest{
+		StackName: f.stackName,
+		Region:    f.region,
+		RunID:     runID,
+		Jobs:      make([]fullRunLogManifestJob, 0, len(jobs)),
+	}
+	var tasks []fullArtifactTask
+	evictions := &spotEvictions{cloudtrail: f.cloudtrail}
+	for _, facts := range jobs {
+		jobDir := path.Join("jobs", strconv.FormatInt(facts.JobID, 10))
+		windowStart, windowEnd, err := f.window.bounds(facts, now)
+		if err != nil {
+			archive.addError(jobDir, err)
+			continue
+		}
+		if manifest.WindowStart.IsZero() || windowStart.Before(manifest.WindowStart) {
+			manifest.WindowStart = windowStart.UTC()
+		}
+		if windowEnd.After(manifest.WindowEnd) {
+			manifest.WindowEnd = windowEnd.UTC()
 		}
 
-		consolePath := path.Join(instanceDir, "console.log")
-		if err := f.writeConsoleLog(ctx, archive, consolePath, instanceID); err != nil {
-			addArtifactError(consolePath, err)
+		tasks = append(tasks, f.jobArtifactTasks(archive, jobDir, facts, windowStart, windowEnd, evictions)...)
+		manifest.Jobs = append(manifest.Jobs, fullRunLogManifestJob{
+			JobID:              facts.JobID,
+			JobName:            facts.JobName,
+			Path:               jobDir,
+			WindowStart:        windowStart.UTC(),
+			WindowEnd:          windowEnd.UTC(),
+			AttemptedInstances: facts.AttemptedInstanceIDs,
+		})
+		if len(facts.AttemptedInstanceIDs) > 0 {
+			manifest.Notes = []string{fullSpotEventsNote}
 		}
+	}
+	evictions.start, evictions.end = manifest.WindowStart, manifest.WindowEnd
+
+	runLogsPath := fmt.Sprintf("server/run-%d.jsonl", runID)
+	if len(manifest.Jobs) == 0 {
+		archive.addError(runLogsPath, fmt.Errorf("no job of run %d has a time window", runID))
+	} else {
+		tasks = append(tasks, f.runLogsTask(archive, runLogsPath, runID, manifest.WindowStart, manifest.WindowEnd))
+	}
+	f.collect(ctx, archive, tasks)
+
+	manifest.Artifacts = archive.artifacts
+	manifest.Errors = archive.errors
+	return archive.finish(zipPath, manifest)
+}
+
+// fullArtifactTask collects one artifact of a --full archive and writes it to
+// the archive.
+type fullArtifactTask struct {
+	path    string
+	collect func(ctx context.Context) error
+}
+
+// jobArtifactTasks returns the tasks collecting the workflow-job item, the
+// server logs and the instance artifacts of a job under dir. The spot
+// interruptions of the instances are looked up in evictions, shared by the
+// jobs of an export.
+func (f *fullLogExporter) jobArtifactTasks(archive *fullArchive, dir string, facts *workflowJobFacts, windowStart, windowEnd time.Time, evictions *spotEvictions) []fullArtifactTask {
+	parsedJobID := strconv.FormatInt(facts.JobID, 10)
+	instanceIDs := facts.AttemptedInstanceIDs
 
+	jobRecordPath := path.Join(dir, fmt.Sprintf("dynamodb/job-%s.ddb.json", parsedJobID))
+	jobLogsPath := path.Join(dir, fmt.Sprintf("server/job-%s.jsonl", parsedJobID))
+	tasks := []fullArtifactTask{
+		{path: jobRecordPath, collect: func(context.Context) error {
+			data, err := facts.rawDynamoDBItemJSON()
+			if err != nil {
+				return err
+			}
+			return archive.writeBytes(jobRecordPath, prettyJSON(data))
+		}},
+		{path: jobLogsPath, collect: func(ctx context.Context) error {
+			return f.writeCloudWatchMessages(ctx, archive.archiveWriter, jobLogsPath, cloudWatchLogRequest{
+				LogGroupIdentifier: f.outputs.ServiceLogGroupName,
+				FilterPattern:      fullJobFilterPattern(parsedJobID, instanceIDs),
+				StartTime:          windowStart,
+				EndTime:            windowEnd,
+			})
+		}},
+	}
+
+	for _, instanceID := range instanceIDs {
+		instanceDir := path.Join(dir, "instances", instanceID)
+		consolePath := path.Join(instanceDir, "console.log")
 		agentPath := path.Join(instanceDir, "agent.jsonl")
-		if err := f.writeCloudWatchMessages(ctx, archive, agentPath, cloudWatchLogRequest{
-			LogGroupIdentifier:  f.outputs.EC2InstanceLogGroupArn,
-			LogStreamNamePrefix: fmt.Sprintf("%s/", instanceID),
-			StartTime:           windowStart,
-			EndTime:             windowEnd,
-		}); err != nil {
-			addArtifactError(agentPath, err)
-		}
+		tasks = append(tasks,
+			fullArtifactTask{path: consolePath, collect: func(ctx context.Context) error {
+				return f.writeConsoleLog(ctx, archive.archiveWriter, consolePath, instanceID)
+			}},
+			fullArtifactTask{path: agentPath, collect: func(ctx context.Context) error {
+				return f.writeCloudWatchMessages(ctx, archive.archiveWriter, agentPath, cloudWatchLogRequest{
+					LogGroupIdentifier:  f.outputs.EC2InstanceLogGroupArn,
+					LogStreamNamePrefix: fmt.Sprintf("%s/", instanceID),
+					StartTime:           windowStart,
+					EndTime:             windowEnd,
+				})
+			}},
+		)
+		tasks = append(tasks, f.instanceArtifactTasks(archive, instanceDir, instanceID, windowStart, windowEnd, evictions)...)
 	}
+	return tasks
+}
 
-	manifest := fullLogManifest{
-		StackName:          f.stackName,
-		Region:             f.region,
-		JobID:              facts.JobID,
-		RunID:              facts.RunID,
-		WindowStart:        windowStart.UTC(),
-		WindowEnd:          windowEnd.UTC(),
-		AttemptedInstances: instanceIDs,
-		Errors:             artifactErrors,
+func (f *fullLogExporter) runLogsTask(archive *fullArchive, runLogsPath string, runID int64, windowStart, windowEnd time.Time) fullArtifactTask {
+	return fullArtifactTask{path: runLogsPath, collect: func(ctx context.Context) error {
+		return f.writeCloudWatchMessages(ctx, archive.archiveWriter, runLogsPath, cloudWatchLogRequest{
+			LogGroupIdentifier: f.outputs.ServiceLogGroupName,
+			FilterPattern:      runFilterPattern(runID),
+			StartTime:          windowStart,
+			EndTime:            windowEnd,
+		})
+	}}
+}
+
+// collect runs the tasks, f.workers at a time, and reports each artifact on
+// f.progress as it completes. The durations and errors are recorded in the
+// order of the tasks, so the manifest doesn't depend on which finished first.
+func (f *fullLogExporter) collect(ctx context.Context, archive *fullArchive, tasks []fullArtifactTask) {
+	durations := make([]time.Duration, len(tasks))
+	errs := make([]error, len(tasks))
+	sem := make(chan struct{}, max(f.workers, 1))
+	var mu sync.Mutex
+	done := 0
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			start := time.Now()
+			errs[i] = task.collect(ctx)
+			durations[i] = time.Since(start)
+
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			f.reportArtifact(done, len(tasks), task.path, durations[i], errs[i])
+		})
+	}
+	wg.Wait()
+
+	for i, task := range tasks {
+		archive.artifacts = append(archive.artifacts, fullArtifact{
+			Path:            task.path,
+			DurationSeconds: durations[i].Seconds(),
+			Failed:          errs[i] != nil,
+		})
+		archive.addError(task.path, errs[i])
+	}
+}
+
+func (f *fullLogExporter) reportArtifact(done, total int, artifactPath string, duration time.Duration, err error) {
+	if f.progress == nil {
+		return
+	}
+	duration = duration.Round(time.Millisecond)
+	if err != nil {
+		fmt.Fprintf(f.progress, "[%d/%d] %s failed after %s: %v\n", done, total, artifactPath, duration, err)
+		return
+	}
+	fmt.Fprintf(f.progress, "[%d/%d] %s (%s)\n", done, total, artifactPath, duration)
+}
+
+// fullArchive is a --full archive being written, with the artifacts that
+// could not be collected.
+type fullArchive struct {
+	*archiveWriter
+	artifacts []fullArtifact
+	errors    []fullArtifactError
+}
+
+func newFullArchive(zipPath string) (*fullArchive, error) {
+	archive, err := newArchiveWriter(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("create full log archive: %w", err)
+	}
+	return &fullArchive{archiveWriter: archive, artifacts: make([]fullArtifact, 0), errors: make([]fullArtifactError, 0)}, nil
+}
+
+// addError records an artifact that could not be collected, next to where it
+// would have been written.
+func (a *fullArchive) addError(artifactPath string, err error) {
+	if err == nil {
+		return
 	}
-	if err := archive.writeJSON("manifest.json", manifest); err != nil {
+	a.errors = append(a.errors, fullArtifactError{Path: artifactPath, Error: err.Error()})
+	_ = a.writeJSON(errorPathFor(artifactPath), map[string]string{"error": err.Error()})
+}
+
+// finish writes the manifest and closes the archive. The artifact errors are
+// returned together with the path of the archive, which is still usable.
+func (a *fullArchive) finish(zipPath string, manifest any) (string, error) {
+	if err := a.writeJSON("manifest.json", manifest); err != nil {
 		return zipPath, fmt.Errorf("write manifest.json: %w", err)
 	}
-	if err := archive.Close(); err != nil {
+	if err := a.Close(); err != nil {
 		return zipPath, fmt.Errorf("finalize full log archive: %w", err)
 	}
 
-	if len(artifactErrors) == 0 {
+	if len(a.errors) == 0 {
 		return zipPath, nil
 	}
 
-	joined := make([]error, 0, len(artifactErrors))
-	for _, artifactErr := range artifactErrors {
+	joined := make([]error, 0, len(a.errors))
+	for _, artifactErr := range a.errors {
 		joined = append(joined, fmt.Errorf("%s: %s", artifactErr.Path, artifactErr.Error))
 	}
-	return zipPath, fmt.Errorf("full log archive completed with %d artifact errors: %w", len(artifactErrors), errors.Join(joined...))
+	return zipPath, fmt.Errorf("full log archive completed with %d artifact errors: %w", len(a.errors), errors.Join(joined...))
 }
 
 func fullJobFilterPattern(jobID string, instanceIDs []string) string {
@@ -222,31 +482,30 @@ func (f *fullLogExporter) writeCloudWatchMessages(ctx context.Context, archive *
 		input.LogStreamNamePrefix = aws.String(request.LogStreamNamePrefix)
 	}
 
-	paginator := cloudwatchlogs.NewFilterLogEventsPaginator(f.cwl, input)
 	var buf bytes.Buffer
-	for paginator.HasMorePages() {
-		output, err := paginator.NextPage(ctx)
-		if err != nil {
-			return fmt.Errorf("fetch CloudWatch logs: %w", err)
+	err := newLogFetcher(f.cwl, f.concurrency).fetch(ctx, input, func(event cwltypes.FilteredLogEvent) error {
+		message := aws.ToString(event.Message)
+		if strings.TrimSpace(message) == "" {
+			return nil
 		}
-		for _, event := range output.Events {
-			message := aws.ToString(event.Message)
-			if strings.TrimSpace(message) == "" {
-				continue
-			}
-			buf.WriteString(message)
-			if !strings.HasSuffix(message, "\n") {
-				buf.WriteByte('\n')
-			}
+		buf.WriteString(message)
+		if !strings.HasSuffix(message, "\n") {
+			buf.WriteByte('\n')
 		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("fetch CloudWatch logs: %w", err)
 	}
 
 	return archive.writeBytes(path, buf.Bytes())
 }
 
-func (f *fullLogExporter) writeCloudTrailEvents(ctx context.Context, archive *archiveWriter, path, instanceID string, start, end time.Time) error {
+// instanceCloudTrailEvents returns the CloudTrail events of instanceID over
+// the window.
+func (f *fullLogExporter) instanceCloudTrailEvents(ctx context.Context, instanceID string, start, end time.Time) ([]cloudtrailtypes.Event, error) {
 	if f.cloudtrail == nil {
-		return fmt.Errorf("CloudTrail client is not configured")
+		return nil, fmt.Errorf("CloudTrail client is not configured")
 	}
 
 	input := &cloudtrail.LookupEventsInput{
@@ -265,12 +524,11 @@ func (f *fullLogExporter) writeCloudTrailEvents(ctx context.Context, archive *ar
 	for paginator.HasMorePages() {
 		output, err := paginator.NextPage(ctx)
 		if err != nil {
-			return fmt.Errorf("fetch CloudTrail events: %w", err)
+			return nil, fmt.Errorf("fetch CloudTrail events: %w", err)
 		}
 		events = append(events, output.Events...)
 	}
-
-	return archive.writeJSON(path, events)
+	return events, nil
 }
 
 func (f *fullLogExporter) writeConsoleLog(ctx context.Context, archive *archiveWriter, path, instanceID string) error {
@@ -0,0 +1,401 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	cloudtrailtypes "github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// ec2InstanceAPI describes the instances of a --full archive and the spot
+// market they ran in.
+type ec2InstanceAPI interface {
+	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+	DescribeSpotPriceHistory(ctx context.Context, params *ec2.DescribeSpotPriceHistoryInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSpotPriceHistoryOutput, error)
+	DescribeSpotInstanceRequests(ctx context.Context, params *ec2.DescribeSpotInstanceRequestsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSpotInstanceRequestsOutput, error)
+}
+
+// spotInterruptionEventName is the CloudTrail event of an instance
+// reclaimed by EC2 Spot.
+const spotInterruptionEventName = "BidEvictedEvent"
+
+// fullSpotEventsNote is the note of the manifest of an archive with instances
+// about what spot-events.json doesn't include.
+const fullSpotEventsNote = "spot-events.json doesn't include rebalance recommendations or interruption warnings: EC2 only sends them to the instance and EventBridge, so they are only in agent.jsonl when the agent logged them"
+
+// fullSpotEvents is the spot-events.json artifact of an instance.
+type fullSpotEvents struct {
+	InstanceLifecycle    string                         `json:"instance_lifecycle"`
+	Events               []fullSpotEvent                `json:"events"`
+	SpotInstanceRequests []ec2types.SpotInstanceRequest `json:"spot_instance_requests,omitempty"`
+}
+
+// fullSpotEvent is a sign that a spot instance was interrupted or about to
+// be: from the state reason of the instance, the status of its spot request,
+// or CloudTrail.
+type fullSpotEvent struct {
+	Time    *time.Time `json:"time,omitempty"`
+	Source  string     `json:"source"`
+	Code    string     `json:"code"`
+	Message string     `json:"message,omitempty"`
+}
+
+// instanceArtifactTasks returns the tasks collecting the CloudTrail events
+// and the description of an instance, the spot prices of its type and
+// availability zone over the window, and its spot interruptions. The
+// instance is described and its CloudTrail events are looked up once for all
+// of them. When the instance can't be described anymore, its type and
+// availability zone are taken from the RunInstances event that launched it,
+// and the spot interruptions are still looked up in evictions.
+func (f *fullLogExporter) instanceArtifactTasks(archive *fullArchive, instanceDir, instanceID string, windowStart, windowEnd time.Time, evictions *spotEvictions) []fullArtifactTask {
+	describe := f.instanceDescriber(instanceID)
+	cloudTrail := f.instanceCloudTrail(instanceID, windowStart, windowEnd)
+	cloudTrailPath := path.Join(instanceDir, "cloudtrail.json")
+	describePath := path.Join(instanceDir, "describe.json")
+	spotPricesPath := path.Join(instanceDir, "spot-price-history.json")
+	spotEventsPath := path.Join(instanceDir, "spot-events.json")
+	return []fullArtifactTask{
+		{path: cloudTrailPath, collect: func(ctx context.Context) error {
+			events, err := cloudTrail(ctx)
+			if err != nil {
+				return err
+			}
+			return archive.writeJSON(cloudTrailPath, events)
+		}},
+		{path: describePath, collect: func(ctx context.Context) error {
+			output, _, err := describe(ctx)
+			if err != nil {
+				return err
+			}
+			return archive.writeJSON(describePath, struct {
+				Reservations []ec2types.Reservation `json:"Reservations"`
+			}{output.Reservations})
+		}},
+		{path: spotPricesPath, collect: func(ctx context.Context) error {
+			_, instance, err := describe(ctx)
+			if err != nil {
+				if f.instances == nil {
+					return err
+				}
+				events, cloudTrailErr := cloudTrail(ctx)
+				instance = launchedInstance(events, instanceID)
+				if instance == nil {
+					return errors.Join(err, cloudTrailErr)
+				}
+			}
+			return f.writeSpotPriceHistory(ctx, archive.archiveWriter, spotPricesPath, instance, windowStart, windowEnd)
+		}},
+		{path: spotEventsPath, collect: func(ctx context.Context) error {
+			_, instance, err := describe(ctx)
+			return errors.Join(err, f.writeSpotEvents(ctx, archive.archiveWriter, spotEventsPath, instanceID, instance, evictions))
+		}},
+	}
+}
+
+// instanceDescriber returns a function calling DescribeInstances for
+// instanceID the first time it is called, and returning the same result
+// afterwards.
+func (f *fullLogExporter) instanceDescriber(instanceID string) func(ctx context.Context) (*ec2.DescribeInstancesOutput, *ec2types.Instance, error) {
+	var (
+		once     sync.Once
+		output   *ec2.DescribeInstancesOutput
+		instance *ec2types.Instance
+		err      error
+	)
+	return func(ctx context.Context) (*ec2.DescribeInstancesOutput, *ec2types.Instance, error) {
+		once.Do(func() {
+			if f.instances == nil {
+				err = fmt.Errorf("EC2 client is not configured")
+				return
+			}
+			output, err = f.instances.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+				InstanceIds: []string{instanceID},
+			})
+			var coded interface{ ErrorCode() string }
+			if errors.As(err, &coded) && coded.ErrorCode() == "InvalidInstanceID.NotFound" {
+				err = instanceNotDescribedError(instanceID)
+				return
+			}
+			if err != nil {
+				err = fmt.Errorf("describe instance: %w", err)
+				return
+			}
+			for _, reservation := range output.Reservations {
+				for i := range reservation.Instances {
+					if aws.ToString(reservation.Instances[i].InstanceId) == instanceID {
+						instance = &reservation.Instances[i]
+					}
+				}
+			}
+			if instance == nil {
+				err = instanceNotDescribedError(instanceID)
+			}
+		})
+		return output, instance, err
+	}
+}
+
+// instanceCloudTrail returns a function looking up the CloudTrail events of
+// instanceID over the window the first time it is called, and returning the
+// same result afterwards.
+func (f *fullLogExporter) instanceCloudTrail(instanceID string, start, end time.Time) func(ctx context.Context) ([]cloudtrailtypes.Event, error) {
+	var (
+		once   sync.Once
+		events []cloudtrailtypes.Event
+		err    error
+	)
+	return func(ctx context.Context) ([]cloudtrailtypes.Event, error) {
+		once.Do(func() {
+			events, err = f.instanceCloudTrailEvents(ctx, instanceID, start, end)
+		})
+		return events, err
+	}
+}
+
+// launchedInstance returns the type and availability zone of instanceID
+// from the RunInstances event among events that launched it, or nil when
+// there is none.
+func launchedInstance(events []cloudtrailtypes.Event, instanceID string) *ec2types.Instance {
+	for _, event := range events {
+		if aws.ToString(event.EventName) != "RunInstances" {
+			continue
+		}
+		var details struct {
+			ResponseElements struct {
+				InstancesSet struct {
+					Items []struct {
+						InstanceID   string `json:"instanceId"`
+						InstanceType string `json:"instanceType"`
+						Placement    struct {
+							AvailabilityZone string `json:"availabilityZone"`
+						} `json:"placement"`
+					} `json:"items"`
+				} `json:"instancesSet"`
+			} `json:"responseElements"`
+		}
+		if err := json.Unmarshal([]byte(aws.ToString(event.CloudTrailEvent)), &details); err != nil {
+			continue
+		}
+		for _, item := range details.ResponseElements.InstancesSet.Items {
+			if item.InstanceID == instanceID && item.InstanceType != "" {
+				return &ec2types.Instance{
+					InstanceId:   aws.String(instanceID),
+					InstanceType: ec2types.InstanceType(item.InstanceType),
+					Placement:    &ec2types.Placement{AvailabilityZone: aws.String(item.Placement.AvailabilityZone)},
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// instanceNotDescribedError is the error of an instance EC2 doesn't describe,
+// usually because it was terminated more than about an hour ago.
+func instanceNotDescribedError(instanceID string) error {
+	return fmt.Errorf("instance %s is no longer described by EC2, which only describes terminated instances for about an hour", instanceID)
+}
+
+func (f *fullLogExporter) writeSpotPriceHistory(ctx context.Context, archive *archiveWriter, path string, instance *ec2types.Instance, start, end time.Time) error {
+	if instance.Placement == nil || aws.ToString(instance.Placement.AvailabilityZone) == "" {
+		return fmt.Errorf("availability zone of instance %s is not known", aws.ToString(instance.InstanceId))
+	}
+
+	input := &ec2.DescribeSpotPriceHistoryInput{
+		InstanceTypes:    []ec2types.InstanceType{instance.InstanceType},
+		AvailabilityZone: instance.Placement.AvailabilityZone,
+		StartTime:        aws.Time(start),
+		EndTime:          aws.Time(end),
+	}
+	if platform := aws.ToString(instance.PlatformDetails); platform != "" {
+		input.ProductDescriptions = []string{platform}
+	}
+
+	paginator := ec2.NewDescribeSpotPriceHistoryPaginator(f.instances, input)
+	prices := make([]ec2types.SpotPrice, 0)
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("fetch spot price history: %w", err)
+		}
+		prices = append(prices, output.SpotPriceHistory...)
+	}
+	sort.SliceStable(prices, func(i, j int) bool {
+		return aws.ToTime(prices[i].Timestamp).Before(aws.ToTime(prices[j].Timestamp))
+	})
+
+	return archive.writeJSON(path, prices)
+}
+
+// writeSpotEvents writes the interruptions of a spot instance. EC2 only
+// sends rebalance recommendations and interruption warnings to the instance
+// and EventBridge, so they show up in the archive through the status of the
+// spot request (marked-for-termination) and the agent logs. instance is nil
+// when the instance can't be described anymore: its lifecycle is then
+// unknown, and only its CloudTrail interruptions are written.
+func (f *fullLogExporter) writeSpotEvents(ctx context.Context, archive *archiveWriter, path, instanceID string, instance *ec2types.Instance, evictions *spotEvictions) error {
+	spotEvents := fullSpotEvents{
+		InstanceLifecycle: "unknown",
+		Events:            make([]fullSpotEvent, 0),
+	}
+	var errs []error
+	if instance != nil {
+		spotEvents.InstanceLifecycle = string(instance.InstanceLifecycle)
+		if spotEvents.InstanceLifecycle == "" {
+			spotEvents.InstanceLifecycle = "on-demand"
+		}
+		if instance.InstanceLifecycle != ec2types.InstanceLifecycleTypeSpot {
+			return archive.writeJSON(path, spotEvents)
+		}
+		errs = append(errs, f.addInstanceSpotEvents(ctx, &spotEvents, instance))
+	}
+
+	events, err := evictions.of(ctx, instanceID)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, event := range events {
+		spotEvents.Events = append(spotEvents.Events, fullSpotEvent{
+			Time:   event.EventTime,
+			Source: "cloudtrail",
+			Code:   aws.ToString(event.EventName),
+		})
+	}
+
+	if err := archive.writeJSON(path, spotEvents); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// addInstanceSpotEvents adds the interruptions of a spot instance found in
+// its description and the status of its spot request.
+func (f *fullLogExporter) addInstanceSpotEvents(ctx context.Context, spotEvents *fullSpotEvents, instance *ec2types.Instance) error {
+	if reason := instance.StateReason; reason != nil && strings.HasPrefix(aws.ToString(reason.Code), "Server.SpotInstance") {
+		spotEvents.Events = append(spotEvents.Events, fullSpotEvent{
+			Source:  "state_reason",
+			Code:    aws.ToString(reason.Code),
+			Message: aws.ToString(reason.Message),
+		})
+	}
+
+	requestID := aws.ToString(instance.SpotInstanceRequestId)
+	if requestID == "" {
+		return nil
+	}
+	requests, err := f.spotInstanceRequests(ctx, requestID)
+	spotEvents.SpotInstanceRequests = requests
+	for _, request := range requests {
+		if status := request.Status; status != nil && isSpotInterruptionStatus(aws.ToString(status.Code)) {
+			spotEvents.Events = append(spotEvents.Events, fullSpotEvent{
+				Time:    status.UpdateTime,
+				Source:  "spot_instance_request",
+				Code:    aws.ToString(status.Code),
+				Message: aws.ToString(status.Message),
+			})
+		}
+	}
+	return err
+}
+
+// spotInstanceRequests returns the spot request of an instance. Requests are
+// only kept for a few hours after they are closed, so a request that is not
+// found anymore is not an error.
+func (f *fullLogExporter) spotInstanceRequests(ctx context.Context, requestID string) ([]ec2types.SpotInstanceRequest, error) {
+	output, err := f.instances.DescribeSpotInstanceRequests(ctx, &ec2.DescribeSpotInstanceRequestsInput{
+		SpotInstanceRequestIds: []string{requestID},
+	})
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) && coded.ErrorCode() == "InvalidSpotInstanceRequestID.NotFound" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch spot instance request: %w", err)
+	}
+	return output.SpotInstanceRequests, nil
+}
+
+// spotEvictions looks up the spot interruptions of every instance of an
+// export at once, over the window of the export, as CloudTrail only allows a
+// couple of LookupEvents requests per second. The lookup is only made when
+// an instance needs it.
+type spotEvictions struct {
+	cloudtrail cloudTrailLookupAPI
+	// start and end are the window of the export. They must be set before
+	// the tasks are collected.
+	start, end time.Time
+
+	once       sync.Once
+	byInstance map[string][]cloudtrailtypes.Event
+	err        error
+}
+
+// of returns the CloudTrail events of the instance being reclaimed by EC2
+// Spot.
+func (e *spotEvictions) of(ctx context.Context, instanceID string) ([]cloudtrailtypes.Event, error) {
+	e.once.Do(func() {
+		e.byInstance, e.err = e.lookup(ctx)
+	})
+	return e.byInstance[instanceID], e.err
+}
+
+func (e *spotEvictions) lookup(ctx context.Context) (map[string][]cloudtrailtypes.Event, error) {
+	if e.cloudtrail == nil {
+		return nil, fmt.Errorf("CloudTrail client is not configured")
+	}
+
+	paginator := cloudtrail.NewLookupEventsPaginator(e.cloudtrail, &cloudtrail.LookupEventsInput{
+		StartTime: aws.Time(e.start),
+		EndTime:   aws.Time(e.end),
+		LookupAttributes: []cloudtrailtypes.LookupAttribute{
+			{
+				AttributeKey:   cloudtrailtypes.LookupAttributeKeyEventName,
+				AttributeValue: aws.String(spotInterruptionEventName),
+			},
+		},
+	})
+	byInstance := make(map[string][]cloudtrailtypes.Event)
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("fetch CloudTrail spot interruptions: %w", err)
+		}
+		for _, event := range output.Events {
+			var record struct {
+				ServiceEventDetails struct {
+					InstanceIDSet []string `json:"instanceIdSet"`
+				} `json:"serviceEventDetails"`
+			}
+			if err := json.Unmarshal([]byte(aws.ToString(event.CloudTrailEvent)), &record); err != nil {
+				continue
+			}
+			for _, instanceID := range record.ServiceEventDetails.InstanceIDSet {
+				byInstance[instanceID] = append(byInstance[instanceID], event)
+			}
+		}
+	}
+	return byInstance, nil
+}
+
+// isSpotInterruptionStatus reports whether a spot request status code means
+// that EC2 interrupted, or is about to interrupt, the instance.
+func isSpotInterruptionStatus(code string) bool {
+	switch {
+	case strings.HasPrefix(code, "marked-for-"):
+		return true
+	case code == "instance-terminated-by-user" || code == "instance-stopped-by-user":
+		return false
+	default:
+		return strings.HasPrefix(code, "instance-terminated-") || strings.HasPrefix(code, "instance-stopped-")
+	}
+}
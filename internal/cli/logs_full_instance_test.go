@@ -0,0 +1,257 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	cloudtrailtypes "github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// mockEC2InstanceClient describes every instance as an m7i.large in
+// us-east-1a, with the lifecycle and spot request status given.
+type mockEC2InstanceClient struct {
+	mu               sync.Mutex
+	lifecycle        ec2types.InstanceLifecycleType
+	spotStatus       string
+	spotRequestError error
+	describeError    error
+	describeInputs   []*ec2.DescribeInstancesInput
+	priceInputs      []*ec2.DescribeSpotPriceHistoryInput
+}
+
+func (m *mockEC2InstanceClient) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.describeInputs = append(m.describeInputs, params)
+	if m.describeError != nil {
+		return nil, m.describeError
+	}
+	instance := ec2types.Instance{
+		InstanceId:        aws.String(params.InstanceIds[0]),
+		InstanceType:      ec2types.InstanceTypeM7iLarge,
+		InstanceLifecycle: m.lifecycle,
+		PlatformDetails:   aws.String("Linux/UNIX"),
+		Placement:         &ec2types.Placement{AvailabilityZone: aws.String("us-east-1a")},
+		Tags:              []ec2types.Tag{{Key: aws.String("runs-on-job-id"), Value: aws.String("42")}},
+	}
+	if m.lifecycle == ec2types.InstanceLifecycleTypeSpot {
+		instance.SpotInstanceRequestId = aws.String("sir-1")
+		instance.StateReason = &ec2types.StateReason{Code: aws.String("Server.SpotInstanceTermination"), Message: aws.String("Server.SpotInstanceTermination: Spot instance termination")}
+	}
+	return &ec2.DescribeInstancesOutput{Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{instance}}}}, nil
+}
+
+func (m *mockEC2InstanceClient) DescribeSpotPriceHistory(ctx context.Context, params *ec2.DescribeSpotPriceHistoryInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSpotPriceHistoryOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.priceInputs = append(m.priceInputs, params)
+	start := aws.ToTime(params.StartTime)
+	return &ec2.DescribeSpotPriceHistoryOutput{SpotPriceHistory: []ec2types.SpotPrice{
+		{SpotPrice: aws.String("0.0420"), Timestamp: aws.Time(start.Add(time.Hour))},
+		{SpotPrice: aws.String("0.0380"), Timestamp: aws.Time(start)},
+	}}, nil
+}
+
+func (m *mockEC2InstanceClient) DescribeSpotInstanceRequests(ctx context.Context, params *ec2.DescribeSpotInstanceRequestsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSpotInstanceRequestsOutput, error) {
+	if m.spotRequestError != nil {
+		return nil, m.spotRequestError
+	}
+	updatedAt := time.Date(2026, 5, 8, 12, 30, 0, 0, time.UTC)
+	return &ec2.DescribeSpotInstanceRequestsOutput{SpotInstanceRequests: []ec2types.SpotInstanceRequest{{
+		SpotInstanceRequestId: aws.String(params.SpotInstanceRequestIds[0]),
+		Status:                &ec2types.SpotInstanceStatus{Code: aws.String(m.spotStatus), UpdateTime: &updatedAt},
+	}}}, nil
+}
+
+type codedError string
+
+func (e codedError) Error() string     { return string(e) }
+func (e codedError) ErrorCode() string { return string(e) }
+
+// evictionCloudTrailClient returns a spot interruption of i-spot to lookups
+// by event name, and counts them. Lookups by resource name return the
+// RunInstances event of i-spot, an m7a.large in us-east-1b.
+type evictionCloudTrailClient struct {
+	mu      sync.Mutex
+	lookups int
+}
+
+func (c *evictionCloudTrailClient) LookupEvents(ctx context.Context, params *cloudtrail.LookupEventsInput, optFns ...func(*cloudtrail.Options)) (*cloudtrail.LookupEventsOutput, error) {
+	if params.LookupAttributes[0].AttributeKey != cloudtrailtypes.LookupAttributeKeyEventName {
+		if aws.ToString(params.LookupAttributes[0].AttributeValue) != "i-spot" {
+			return &cloudtrail.LookupEventsOutput{}, nil
+		}
+		launchedAt := time.Date(2026, 5, 8, 11, 55, 0, 0, time.UTC)
+		return &cloudtrail.LookupEventsOutput{Events: []cloudtrailtypes.Event{{
+			EventName:       aws.String("RunInstances"),
+			EventTime:       &launchedAt,
+			CloudTrailEvent: aws.String(`{"responseElements":{"instancesSet":{"items":[{"instanceId":"i-spot","instanceType":"m7a.large","placement":{"availabilityZone":"us-east-1b"}}]}}}`),
+		}}}, nil
+	}
+	c.mu.Lock()
+	c.lookups++
+	c.mu.Unlock()
+	evictedAt := time.Date(2026, 5, 8, 12, 32, 0, 0, time.UTC)
+	return &cloudtrail.LookupEventsOutput{Events: []cloudtrailtypes.Event{
+		{EventName: aws.String(spotInterruptionEventName), EventTime: &evictedAt, CloudTrailEvent: aws.String(`{"serviceEventDetails":{"instanceIdSet":["i-spot"]}}`)},
+		{EventName: aws.String(spotInterruptionEventName), EventTime: &evictedAt, CloudTrailEvent: aws.String(`{"serviceEventDetails":{"instanceIdSet":["i-other"]}}`)},
+	}}, nil
+}
+
+func collectInstanceArtifacts(t *testing.T, exporter *fullLogExporter, instanceIDs ...string) (*fullArchive, map[string]string) {
+	t.Helper()
+	zipPath := filepath.Join(t.TempDir(), "archive.zip")
+	archive, err := newFullArchive(zipPath)
+	if err != nil {
+		t.Fatalf("newFullArchive returned error: %v", err)
+	}
+	windowStart := time.Date(2026, 5, 8, 11, 0, 0, 0, time.UTC)
+	windowEnd := windowStart.Add(2 * time.Hour)
+	evictions := &spotEvictions{cloudtrail: exporter.cloudtrail, start: windowStart, end: windowEnd}
+	var tasks []fullArtifactTask
+	for _, instanceID := range instanceIDs {
+		tasks = append(tasks, exporter.instanceArtifactTasks(archive, "instances/"+instanceID, instanceID, windowStart, windowEnd, evictions)...)
+	}
+	exporter.collect(context.Background(), archive, tasks)
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	return archive, readZipFiles(t, zipPath)
+}
+
+func TestInstanceArtifactsOfSpotInstance(t *testing.T) {
+	instances := &mockEC2InstanceClient{lifecycle: ec2types.InstanceLifecycleTypeSpot, spotStatus: "instance-terminated-by-service"}
+	exporter := &fullLogExporter{instances: instances, cloudtrail: &evictionCloudTrailClient{}, workers: 3}
+	archive, files := collectInstanceArtifacts(t, exporter, "i-spot")
+	if len(archive.errors) != 0 {
+		t.Fatalf("unexpected artifact errors %+v", archive.errors)
+	}
+	if len(instances.describeInputs) != 1 {
+		t.Fatalf("expected the instance to be described once, got %d", len(instances.describeInputs))
+	}
+
+	var described struct {
+		Reservations []struct {
+			Instances []struct {
+				InstanceType string
+				Placement    struct{ AvailabilityZone string }
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(files["instances/i-spot/describe.json"]), &described); err != nil || described.Reservations[0].Instances[0].Placement.AvailabilityZone != "us-east-1a" {
+		t.Fatalf("unexpected describe.json (%v): %s", err, files["instances/i-spot/describe.json"])
+	}
+
+	input := instances.priceInputs[0]
+	if input.InstanceTypes[0] != ec2types.InstanceTypeM7iLarge || aws.ToString(input.AvailabilityZone) != "us-east-1a" || input.ProductDescriptions[0] != "Linux/UNIX" {
+		t.Fatalf("unexpected spot price history query %+v", input)
+	}
+	var prices []ec2types.SpotPrice
+	if err := json.Unmarshal([]byte(files["instances/i-spot/spot-price-history.json"]), &prices); err != nil || aws.ToString(prices[0].SpotPrice) != "0.0380" {
+		t.Fatalf("expected the spot prices sorted by time (%v): %s", err, files["instances/i-spot/spot-price-history.json"])
+	}
+
+	var spotEvents fullSpotEvents
+	if err := json.Unmarshal([]byte(files["instances/i-spot/spot-events.json"]), &spotEvents); err != nil {
+		t.Fatalf("invalid spot-events.json: %v", err)
+	}
+	var codes []string
+	for _, event := range spotEvents.Events {
+		codes = append(codes, event.Source+":"+event.Code)
+	}
+	want := []string{"state_reason:Server.SpotInstanceTermination", "spot_instance_request:instance-terminated-by-service", "cloudtrail:BidEvictedEvent"}
+	if spotEvents.InstanceLifecycle != "spot" || len(codes) != len(want) || codes[0] != want[0] || codes[1] != want[1] || codes[2] != want[2] {
+		t.Fatalf("expected spot events %v, got %v", want, codes)
+	}
+}
+
+func TestInstanceArtifactsOfOnDemandInstance(t *testing.T) {
+	exporter := &fullLogExporter{instances: &mockEC2InstanceClient{}, cloudtrail: &evictionCloudTrailClient{}, workers: 1}
+	archive, files := collectInstanceArtifacts(t, exporter, "i-ondemand")
+	if len(archive.errors) != 0 {
+		t.Fatalf("unexpected artifact errors %+v", archive.errors)
+	}
+	if files["instances/i-ondemand/spot-events.json"] != "{\n  \"instance_lifecycle\": \"on-demand\",\n  \"events\": []\n}\n" {
+		t.Fatalf("unexpected spot-events.json %q", files["instances/i-ondemand/spot-events.json"])
+	}
+}
+
+func TestInstanceArtifactsIgnoreExpiredSpotRequests(t *testing.T) {
+	instances := &mockEC2InstanceClient{lifecycle: ec2types.InstanceLifecycleTypeSpot, spotRequestError: codedError("InvalidSpotInstanceRequestID.NotFound")}
+	exporter := &fullLogExporter{instances: instances, cloudtrail: &evictionCloudTrailClient{}, workers: 1}
+	archive, _ := collectInstanceArtifacts(t, exporter, "i-spot")
+	if len(archive.errors) != 0 {
+		t.Fatalf("expected an expired spot request to be skipped, got %+v", archive.errors)
+	}
+
+	exporter.instances = nil
+	archive, _ = collectInstanceArtifacts(t, exporter, "i-spot")
+	if len(archive.errors) != 3 {
+		t.Fatalf("expected every instance artifact to fail without an EC2 client, got %+v", archive.errors)
+	}
+}
+
+func TestInstanceArtifactsLookUpSpotEvictionsOncePerExport(t *testing.T) {
+	cloudTrail := &evictionCloudTrailClient{}
+	exporter := &fullLogExporter{instances: &mockEC2InstanceClient{lifecycle: ec2types.InstanceLifecycleTypeSpot}, cloudtrail: cloudTrail, workers: 4}
+	archive, _ := collectInstanceArtifacts(t, exporter, "i-spot", "i-other", "i-third")
+	if len(archive.errors) != 0 {
+		t.Fatalf("unexpected artifact errors %+v", archive.errors)
+	}
+	if cloudTrail.lookups != 1 {
+		t.Fatalf("expected the spot interruptions to be looked up once for every instance, got %d lookups", cloudTrail.lookups)
+	}
+}
+
+func TestInstanceArtifactsOfInstanceNoLongerDescribed(t *testing.T) {
+	instances := &mockEC2InstanceClient{describeError: codedError("InvalidInstanceID.NotFound")}
+	exporter := &fullLogExporter{instances: instances, cloudtrail: &evictionCloudTrailClient{}, workers: 3}
+	archive, files := collectInstanceArtifacts(t, exporter, "i-spot")
+
+	if len(archive.errors) != 2 {
+		t.Fatalf("expected describe.json and spot-events.json to report the instance, got %+v", archive.errors)
+	}
+	for _, artifactErr := range archive.errors {
+		if artifactErr.Error != "instance i-spot is no longer described by EC2, which only describes terminated instances for about an hour" {
+			t.Fatalf("unexpected error for %s: %s", artifactErr.Path, artifactErr.Error)
+		}
+	}
+
+	var spotEvents fullSpotEvents
+	if err := json.Unmarshal([]byte(files["instances/i-spot/spot-events.json"]), &spotEvents); err != nil {
+		t.Fatalf("expected spot-events.json to be written anyway: %v", err)
+	}
+	if spotEvents.InstanceLifecycle != "unknown" || len(spotEvents.Events) != 1 || spotEvents.Events[0].Code != spotInterruptionEventName {
+		t.Fatalf("expected the CloudTrail interruption of an unknown lifecycle, got %+v", spotEvents)
+	}
+
+	if len(instances.priceInputs) != 1 || instances.priceInputs[0].InstanceTypes[0] != "m7a.large" || aws.ToString(instances.priceInputs[0].AvailabilityZone) != "us-east-1b" {
+		t.Fatalf("expected the spot prices of the launched instance type and zone, got %+v", instances.priceInputs)
+	}
+	if _, ok := files["instances/i-spot/spot-price-history.json"]; !ok {
+		t.Fatal("expected spot-price-history.json to be written")
+	}
+}
+
+func TestIsSpotInterruptionStatus(t *testing.T) {
+	for code, want := range map[string]bool{
+		"marked-for-termination":                true,
+		"instance-terminated-by-price":          true,
+		"instance-stopped-no-capacity":          true,
+		"instance-terminated-by-user":           false,
+		"fulfilled":                             false,
+		"request-canceled-and-instance-running": false,
+	} {
+		if got := isSpotInterruptionStatus(code); got != want {
+			t.Fatalf("isSpotInterruptionStatus(%q) = %v, want %v", code, got, want)
+		}
+	}
+}
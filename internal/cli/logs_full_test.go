@@ -2,13 +2,19 @@ package cli
 
 import (
 	"archive/zip"
+	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -26,6 +32,31 @@ import (
 type mockCloudWatchLogsClient struct {
 	mu     sync.Mutex
 	inputs []*cloudwatchlogs.FilterLogEventsInput
+	// liveTail starts the Live Tail sessions. Without it, Live Tail isn't
+	// available.
+	liveTail       func(*cloudwatchlogs.StartLiveTailInput) (liveTailStream, error)
+	liveTailInputs []*cloudwatchlogs.StartLiveTailInput
+	// laterEvents are returned with Live Tail, instead of the event at 123,
+	// to the requests starting after it, such as catch-ups, when they are in
+	// the time range of the request.
+	laterEvents []cwltypes.FilteredLogEvent
+}
+
+func (m *mockCloudWatchLogsClient) StartLiveTailStream(ctx context.Context, params *cloudwatchlogs.StartLiveTailInput) (liveTailStream, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.liveTailInputs = append(m.liveTailInputs, params)
+	if m.liveTail == nil {
+		return nil, errors.New("live tail not available")
+	}
+	return m.liveTail(params)
+}
+
+func (m *mockCloudWatchLogsClient) filterInputs() []*cloudwatchlogs.FilterLogEventsInput {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.inputs)
 }
 
 func (m *mockCloudWatchLogsClient) FilterLogEvents(ctx context.Context, params *cloudwatchlogs.FilterLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error) {
@@ -33,6 +64,15 @@ func (m *mockCloudWatchLogsClient) FilterLogEvents(ctx context.Context, params *
 	defer m.mu.Unlock()
 
 	m.inputs = append(m.inputs, params)
+	if m.liveTail != nil && aws.ToInt64(params.StartTime) > 123 {
+		output := &cloudwatchlogs.FilterLogEventsOutput{}
+		for _, event := range m.laterEvents {
+			if timestamp := aws.ToInt64(event.Timestamp); timestamp >= aws.ToInt64(params.StartTime) && (params.EndTime == nil || timestamp <= *params.EndTime) {
+				output.Events = append(output.Events, event)
+			}
+		}
+		return output, nil
+	}
 	message := "server"
 	if strings.Contains(aws.ToString(params.FilterPattern), "$.run_id") {
 		message = "run"
@@ -53,10 +93,13 @@ func (m *mockCloudWatchLogsClient) FilterLogEvents(ctx context.Context, params *
 }
 
 type mockCloudTrailClient struct {
+	mu     sync.Mutex
 	inputs []*cloudtrail.LookupEventsInput
 }
 
 func (m *mockCloudTrailClient) LookupEvents(ctx context.Context, params *cloudtrail.LookupEventsInput, optFns ...func(*cloudtrail.Options)) (*cloudtrail.LookupEventsOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.inputs = append(m.inputs, params)
 	return &cloudtrail.LookupEventsOutput{
 		Events: []cloudtrailtypes.Event{
@@ -69,10 +112,13 @@ func (m *mockCloudTrailClient) LookupEvents(ctx context.Context, params *cloudtr
 }
 
 type mockEC2ConsoleClient struct {
+	mu     sync.Mutex
 	inputs []*ec2.GetConsoleOutputInput
 }
 
 func (m *mockEC2ConsoleClient) GetConsoleOutput(ctx context.Context, params *ec2.GetConsoleOutputInput, optFns ...func(*ec2.Options)) (*ec2.GetConsoleOutputOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.inputs = append(m.inputs, params)
 	now := time.Now()
 	return &ec2.GetConsoleOutputOutput{
@@ -149,14 +195,61 @@ func TestLogsCommandFullModeValidationAndFlags(t *testing.T) {
 		t.Fatal("expected --full --watch to be rejected")
 	}
 
-	if cmd.Flags().Lookup("since") != nil {
-		t.Fatal("did not expect job-specific logs command to expose --since")
+	cmd = NewLogsCmd(&Stack{})
+	cmd.SetArgs([]string{"42", "--full", "--since", "2h", "--window-before", "30m"})
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected --full --since --window-before to be rejected")
+	}
+
+	cmd = NewLogsCmd(&Stack{})
+	cmd.SetArgs([]string{"42", "--full", "--run", "--job-name", "build"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "cannot be used with --job-name") {
+		t.Fatalf("expected --full --run --job-name to be rejected, got %v", err)
+	}
+
+	cmd = NewLogsCmd(&Stack{})
+	cmd.SetArgs([]string{"42", "--window-after", "2h"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "require --full") {
+		t.Fatalf("expected --window-after without --full to be rejected, got %v", err)
+	}
+
+	for _, name := range []string{"since", "until"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Fatalf("expected job-specific logs command to expose --%s", name)
+		}
 	}
 	if cmd.Flags().Lookup("full") == nil {
 		t.Fatal("expected job-specific logs command to expose --full")
 	}
 }
 
+func TestFullLogWindowBounds(t *testing.T) {
+	createdAt := time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC)
+	completedAt := createdAt.Add(5 * time.Hour)
+	now := createdAt.Add(3 * time.Hour)
+	padding := fullLogWindow{Before: time.Hour, After: 30 * time.Minute}
+	for _, test := range []struct {
+		name       string
+		window     fullLogWindow
+		facts      workflowJobFacts
+		start, end time.Time
+	}{
+		{"completed", padding, workflowJobFacts{Status: "completed", CreatedAt: createdAt, CompletedAt: completedAt}, createdAt.Add(-time.Hour), completedAt.Add(30 * time.Minute)},
+		{"running", padding, workflowJobFacts{Status: "in_progress", CreatedAt: createdAt}, createdAt.Add(-time.Hour), now},
+		{"no completion time", padding, workflowJobFacts{CreatedAt: createdAt}, createdAt.Add(-time.Hour), createdAt.Add(30 * time.Minute)},
+		{"since and until", fullLogWindow{Since: createdAt.Add(time.Hour), Until: createdAt.Add(2 * time.Hour)}, workflowJobFacts{CompletedAt: completedAt}, createdAt.Add(time.Hour), createdAt.Add(2 * time.Hour)},
+	} {
+		start, end, err := test.window.bounds(&test.facts, now)
+		if err != nil || !start.Equal(test.start) || !end.Equal(test.end) {
+			t.Fatalf("%s: bounds = %s, %s, %v; want %s, %s", test.name, start, end, err, test.start, test.end)
+		}
+	}
+
+	if _, _, err := (fullLogWindow{Until: createdAt.Add(-2 * time.Hour)}).bounds(&workflowJobFacts{CreatedAt: createdAt}, now); err == nil {
+		t.Fatal("expected a window ending before it starts to be rejected")
+	}
+}
+
 func TestFetchFullLogsCreatesArchive(t *testing.T) {
 	createdAt := time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC)
 	record := workflowJobFactsRecord{
@@ -188,10 +281,12 @@ func TestFetchFullLogsCreatesArchive(t *testing.T) {
 		cwl:        cwl,
 		jobs:       jobsClient,
 		ec2:        ec2Client,
+		instances:  &mockEC2InstanceClient{},
 		cloudtrail: trail,
 		jobsTable:  "workflow-jobs",
 		stackName:  "runs-on-dev",
 		region:     "us-east-1",
+		window:     fullLogWindow{Before: fullLogWindowPadding, After: fullLogWindowPadding},
 		outputs: &StackOutputs{
 			ServiceLogGroupName:    "/aws/ecs/runs-on/flexd",
 			EC2InstanceLogGroupArn: "arn:aws:logs:us-east-1:123456789012:log-group:runs-on/ec2/instances",
@@ -215,6 +310,9 @@ func TestFetchFullLogsCreatesArchive(t *testing.T) {
 		"instances/i-active/agent.jsonl",
 		"instances/i-old/cloudtrail.json",
 		"instances/i-runner/agent.jsonl",
+		"instances/i-runner/describe.json",
+		"instances/i-old/spot-price-history.json",
+		"instances/i-active/spot-events.json",
 	} {
 		if _, ok := files[path]; !ok {
 			t.Fatalf("expected archive to contain %s; files: %v", path, sortedZipFileNames(files))
@@ -224,6 +322,9 @@ func TestFetchFullLogsCreatesArchive(t *testing.T) {
 		!strings.Contains(files["manifest.json"], `"window_end": "2026-05-08T13:00:00Z"`) {
 		t.Fatalf("manifest did not contain derived window: %s", files["manifest.json"])
 	}
+	if !strings.Contains(files["manifest.json"], `"path": "instances/i-old/agent.jsonl"`) {
+		t.Fatalf("manifest did not record the collected artifacts: %s", files["manifest.json"])
+	}
 	if !strings.Contains(files["instances/i-active/console.log"], "console line") {
 		t.Fatalf("console log missing decoded output: %q", files["instances/i-active/console.log"])
 	}
@@ -242,6 +343,123 @@ func TestFetchFullLogsCreatesArchive(t *testing.T) {
 	}
 }
 
+func TestExportRunCreatesOneArchive(t *testing.T) {
+	createdAt := time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC)
+	completedAt := createdAt.Add(2 * time.Hour)
+	jobs := []*workflowJobFacts{
+		workflowJobFactsFromRecord(workflowJobFactsRecord{JobID: 41, RunID: 1234, JobName: "test (linux)", Status: "completed", CreatedAt: &createdAt, CompletedAt: &completedAt, RunnerName: "runs-on--i-linux--job"}, map[string]dynamodbtypes.AttributeValue{}),
+		workflowJobFactsFromRecord(workflowJobFactsRecord{JobID: 42, RunID: 1234, JobName: "test (windows)", Status: "completed", CreatedAt: &createdAt, RunnerName: "runs-on--i-windows--job"}, map[string]dynamodbtypes.AttributeValue{}),
+		workflowJobFactsFromRecord(workflowJobFactsRecord{JobID: 43, RunID: 1234, JobName: "lint"}, nil),
+	}
+	cwl := &mockCloudWatchLogsClient{}
+	exporter := &fullLogExporter{
+		cwl:        cwl,
+		ec2:        &mockEC2ConsoleClient{},
+		instances:  &mockEC2InstanceClient{},
+		cloudtrail: &mockCloudTrailClient{},
+		stackName:  "runs-on-dev",
+		region:     "us-east-1",
+		window:     fullLogWindow{Before: fullLogWindowPadding, After: fullLogWindowPadding},
+		outputs: &StackOutputs{
+			ServiceLogGroupName:    "/aws/ecs/runs-on/flexd",
+			EC2InstanceLogGroupArn: "arn:aws:logs:us-east-1:123456789012:log-group:runs-on/ec2/instances",
+		},
+	}
+
+	t.Chdir(t.TempDir())
+	zipPath, err := exporter.ExportRun(context.Background(), 1234, jobs)
+	if err == nil || !strings.Contains(err.Error(), "jobs/43: workflow job 43 has no usable created_at") {
+		t.Fatalf("expected the job without a creation time to be reported, got %v", err)
+	}
+	if !strings.HasPrefix(zipPath, "roc-logs-run-1234-") {
+		t.Fatalf("unexpected archive name %q", zipPath)
+	}
+
+	files := readZipFiles(t, zipPath)
+	for _, path := range []string{
+		"manifest.json",
+		"server/run-1234.jsonl",
+		"jobs/41/dynamodb/job-41.ddb.json",
+		"jobs/41/server/job-41.jsonl",
+		"jobs/41/instances/i-linux/agent.jsonl",
+		"jobs/42/server/job-42.jsonl",
+		"jobs/42/instances/i-windows/console.log",
+		"jobs/43.error.json",
+	} {
+		if _, ok := files[path]; !ok {
+			t.Fatalf("expected archive to contain %s; files: %v", path, sortedZipFileNames(files))
+		}
+	}
+
+	var manifest fullRunLogManifest
+	if err := json.Unmarshal([]byte(files["manifest.json"]), &manifest); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	if manifest.RunID != 1234 || len(manifest.Jobs) != 2 || manifest.Jobs[1].AttemptedInstances[0] != "i-windows" || len(manifest.Errors) != 1 {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+	if !manifest.WindowStart.Equal(createdAt.Add(-time.Hour)) || !manifest.WindowEnd.Equal(completedAt.Add(time.Hour)) {
+		t.Fatalf("expected the run window to cover every job, got %s to %s", manifest.WindowStart, manifest.WindowEnd)
+	}
+
+	runFetches := 0
+	for _, input := range cwl.filterInputs() {
+		if strings.Contains(aws.ToString(input.FilterPattern), "$.run_id") {
+			runFetches++
+		}
+	}
+	if runFetches != 1 {
+		t.Fatalf("expected the run logs to be fetched once, got %d", runFetches)
+	}
+}
+
+func TestFullLogExporterCollectsArtifactsConcurrently(t *testing.T) {
+	archive, err := newFullArchive(filepath.Join(t.TempDir(), "archive.zip"))
+	if err != nil {
+		t.Fatalf("newFullArchive returned error: %v", err)
+	}
+	defer archive.Close()
+
+	var active, maxActive atomic.Int32
+	var tasks []fullArtifactTask
+	for i := range 5 {
+		artifactPath := fmt.Sprintf("artifact-%d.txt", i)
+		tasks = append(tasks, fullArtifactTask{path: artifactPath, collect: func(context.Context) error {
+			current := active.Add(1)
+			defer active.Add(-1)
+			for {
+				seen := maxActive.Load()
+				if current <= seen || maxActive.CompareAndSwap(seen, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			if i == 3 {
+				return errors.New("access denied")
+			}
+			return archive.writeText(artifactPath, "ok\n")
+		}})
+	}
+
+	var progress bytes.Buffer
+	exporter := &fullLogExporter{workers: 2, progress: &progress}
+	exporter.collect(context.Background(), archive, tasks)
+
+	if got := maxActive.Load(); got != 2 {
+		t.Fatalf("expected 2 artifacts to be collected at once, got %d", got)
+	}
+	if len(archive.artifacts) != 5 || archive.artifacts[2].Path != "artifact-2.txt" || !archive.artifacts[3].Failed || archive.artifacts[0].DurationSeconds <= 0 {
+		t.Fatalf("expected the artifacts to be recorded in order with their durations, got %+v", archive.artifacts)
+	}
+	if len(archive.errors) != 1 || archive.errors[0].Path != "artifact-3.txt" {
+		t.Fatalf("expected the failed artifact to be recorded, got %+v", archive.errors)
+	}
+	lines := strings.Split(strings.TrimSpace(progress.String()), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[4], "[5/5] ") || !strings.Contains(progress.String(), "artifact-3.txt failed after") {
+		t.Fatalf("expected a progress line per artifact, got %q", progress.String())
+	}
+}
+
 func marshalFullWorkflowJobItem(t *testing.T, record workflowJobFactsRecord) map[string]dynamodbtypes.AttributeValue {
 	t.Helper()
 
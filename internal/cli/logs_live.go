@@ -0,0 +1,311 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// liveTailStream is the event stream of a CloudWatch Logs Live Tail session.
+// *cloudwatchlogs.StartLiveTailEventStream implements it.
+type liveTailStream interface {
+	Events() <-chan cwltypes.StartLiveTailResponseStream
+	Close() error
+	Err() error
+}
+
+// cloudWatchLogsClient adds StartLiveTailStream to the CloudWatch Logs client,
+// since the stream of a StartLiveTail output can't be built outside the SDK.
+type cloudWatchLogsClient struct {
+	*cloudwatchlogs.Client
+}
+
+func newCloudWatchLogsClient(cfg aws.Config) *cloudWatchLogsClient {
+	return &cloudWatchLogsClient{Client: cloudwatchlogs.NewFromConfig(cfg)}
+}
+
+func (c *cloudWatchLogsClient) StartLiveTailStream(ctx context.Context, params *cloudwatchlogs.StartLiveTailInput) (liveTailStream, error) {
+	output, err := c.StartLiveTail(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return output.GetStream(), nil
+}
+
+// liveTailResumeOverlap is how far before the latest event the catch-up of a
+// new session starts. Events are not always ingested in timestamp order, so a
+// late event may have an earlier timestamp than the latest one streamed.
+const liveTailResumeOverlap = time.Minute
+
+// errLiveTailRestart ends a Live Tail session that must be started again.
+var errLiveTailRestart = errors.New("live tail session must be restarted")
+
+// logGroupArn returns the ARN of a log group. Live Tail only accepts ARNs, so
+// the ARN of a log group name is built from the account and region of the EC2
+// instance log group.
+func (o *StackOutputs) logGroupArn(identifier string) (string, error) {
+	identifier = normalizeCloudWatchLogGroupIdentifier(identifier)
+	if strings.HasPrefix(identifier, "arn:") {
+		return identifier, nil
+	}
+	if o != nil {
+		if prefix, _, ok := strings.Cut(o.EC2InstanceLogGroupArn, ":log-group:"); ok && strings.HasPrefix(prefix, "arn:") {
+			return prefix + ":log-group:" + identifier, nil
+		}
+	}
+	return "", fmt.Errorf("cannot resolve the ARN of log group %s", identifier)
+}
+
+// liveTailInput returns the Live Tail request matching the events of input.
+func liveTailInput(outputs *StackOutputs, input *cloudwatchlogs.FilterLogEventsInput) (*cloudwatchlogs.StartLiveTailInput, error) {
+	logGroupArn, err := outputs.logGroupArn(aws.ToString(input.LogGroupIdentifier))
+	if err != nil {
+		return nil, err
+	}
+	liveInput := &cloudwatchlogs.StartLiveTailInput{LogGroupIdentifiers: []string{logGroupArn}}
+	if prefix := aws.ToString(input.LogStreamNamePrefix); prefix != "" {
+		liveInput.LogStreamNamePrefixes = []string{prefix}
+	}
+	if pattern := aws.ToString(input.FilterPattern); pattern != "" {
+		liveInput.LogEventFilterPattern = aws.String(pattern)
+	}
+	return liveInput, nil
+}
+
+func sameLiveTailInput(a, b *cloudwatchlogs.StartLiveTailInput) bool {
+	return slices.Equal(a.LogGroupIdentifiers, b.LogGroupIdentifiers) &&
+		slices.Equal(a.LogStreamNamePrefixes, b.LogStreamNamePrefixes) &&
+		aws.ToString(a.LogEventFilterPattern) == aws.ToString(b.LogEventFilterPattern)
+}
+
+// cloudWatchLiveTail follows the events of a log stream of the session with
+// Live Tail sessions, instead of polling FilterLogEvents.
+type cloudWatchLiveTail struct {
+	session     *streamedLogSession
+	source      *logSource
+	label       string
+	prefix      string
+	cwl         cloudWatchLogsAPI
+	outputs     *StackOutputs
+	input       *cloudwatchlogs.FilterLogEventsInput
+	match       func(string) bool
+	updateInput func(*cloudwatchlogs.FilterLogEventsInput) error
+	// startTime is where the initial fetch ended. Catch-ups never start
+	// before it, as the events of the initial fetch have other IDs.
+	startTime int64
+	// lastTimestamp is the timestamp of the latest event, from which the gap
+	// before a new session is filled in.
+	lastTimestamp int64
+	// sampledWarned is set once the user was warned that Live Tail samples
+	// the events.
+	sampledWarned bool
+}
+
+// run follows the events until ctx is done. Sessions are started again when
+// they time out, or when updateInput changes the request, e.g. once the
+// instance of a job is known. It returns an error when a session can't be
+// started, for the caller to fall back to polling from t.input.StartTime.
+func (t *cloudWatchLiveTail) run(ctx context.Context) error {
+	logger := t.session.logger
+	t.startTime = aws.ToInt64(t.input.StartTime)
+	t.lastTimestamp = t.startTime - 1
+	for {
+		liveInput, err := liveTailInput(t.outputs, t.input)
+		if err != nil {
+			return err
+		}
+		sessionCtx, cancel := context.WithCancel(ctx)
+		stream, err := t.cwl.StartLiveTailStream(sessionCtx, liveInput)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("start live tail session: %w", err)
+		}
+		logger.Printf("[%s]: Live Tail session requested for %v", t.prefix, liveInput.LogGroupIdentifiers)
+		started, err := t.follow(sessionCtx, stream, liveInput)
+		stream.Close()
+		cancel()
+		t.input.StartTime = aws.Int64(t.lastTimestamp + 1)
+
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case !started && err == nil:
+			return fmt.Errorf("live tail session ended before it started")
+		case !started:
+			return fmt.Errorf("live tail session failed: %w", err)
+		case err != nil && !errors.Is(err, errLiveTailRestart):
+			logger.Printf("[%s]: Live Tail session ended: %v", t.prefix, err)
+		}
+	}
+}
+
+// follow adds the events of a session to the source until the session ends.
+// Once the session has started, the events since the previous session are
+// fetched, so that none are lost in between. Live Tail only sends a sample of
+// the events above 500 per second: the seconds of a sampled update are
+// fetched too.
+func (t *cloudWatchLiveTail) follow(ctx context.Context, stream liveTailStream, liveInput *cloudwatchlogs.StartLiveTailInput) (bool, error) {
+	interval := t.session.opts.WatchInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	started := false
+	var occurrences liveTailOccurrences
+	for {
+		select {
+		case <-ctx.Done():
+			return started, ctx.Err()
+		case <-ticker.C:
+			if t.refresh(liveInput) {
+				return started, errLiveTailRestart
+			}
+		case event, ok := <-stream.Events():
+			if !ok {
+				return started, stream.Err()
+			}
+			switch event := event.(type) {
+			case *cwltypes.StartLiveTailResponseStreamMemberSessionStart:
+				started = true
+				t.session.logger.Printf("[%s]: Live Tail session %s started", t.prefix, aws.ToString(event.Value.SessionId))
+				start := max(t.lastTimestamp+1-liveTailResumeOverlap.Milliseconds(), t.startTime)
+				if err := t.catchUp(ctx, start, time.Now().UnixMilli()); err != nil {
+					return started, err
+				}
+			case *cwltypes.StartLiveTailResponseStreamMemberSessionUpdate:
+				results := event.Value.SessionResults
+				for _, result := range results {
+					t.add(aws.ToInt64(result.Timestamp), aws.ToString(result.LogStreamName), aws.ToString(result.Message), &occurrences)
+				}
+				if metadata := event.Value.SessionMetadata; metadata != nil && metadata.Sampled && len(results) > 0 {
+					if err := t.fillSampled(ctx, results); err != nil {
+						return started, err
+					}
+				}
+			}
+		}
+	}
+}
+
+// refresh reports whether updateInput changed the request of the session. The
+// new request is used by the next session.
+func (t *cloudWatchLiveTail) refresh(current *cloudwatchlogs.StartLiveTailInput) bool {
+	next := *t.input
+	match, err := t.session.prepareInput(t.prefix, &next, t.updateInput)
+	if err != nil {
+		return false
+	}
+	nextLiveInput, err := liveTailInput(t.outputs, &next)
+	if err != nil || sameLiveTailInput(current, nextLiveInput) {
+		return false
+	}
+	t.session.logger.Printf("[%s]: Live Tail request changed, restarting the session", t.prefix)
+	t.input, t.match = &next, match
+	return true
+}
+
+// fillSampled fetches the seconds of the results of a sampled update, and
+// warns the user the first time.
+func (t *cloudWatchLiveTail) fillSampled(ctx context.Context, results []cwltypes.LiveTailSessionLogEvent) error {
+	if !t.sampledWarned {
+		t.sampledWarned = true
+		fmt.Fprintf(t.session.errOut, "Warning: [%s] more than 500 events per second, Live Tail only sends a sample of them; fetching the others\n", t.prefix)
+	}
+	first, last := aws.ToInt64(results[0].Timestamp), aws.ToInt64(results[0].Timestamp)
+	for _, result := range results {
+		first = min(first, aws.ToInt64(result.Timestamp))
+		last = max(last, aws.ToInt64(result.Timestamp))
+	}
+	second := time.Second.Milliseconds()
+	return t.catchUp(ctx, max(first/second*second, t.startTime), last/second*second+second-1)
+}
+
+// catchUp fetches the events from startTime to endTime: from the previous
+// session, or from the initial fetch, up to the start of the session, or the
+// events missing from a sampled update. The events it fetches may also have
+// been streamed, so they are added with the same IDs as streamed events, for
+// the collector to drop the copies.
+func (t *cloudWatchLiveTail) catchUp(ctx context.Context, startTime, endTime int64) error {
+	input := *t.input
+	input.NextToken = nil
+	input.StartTime = aws.Int64(startTime)
+	input.EndTime = aws.Int64(endTime)
+
+	var occurrences liveTailOccurrences
+	fetcher := newLogFetcher(t.cwl, t.session.opts.Concurrency)
+	fetcher.logger, fetcher.prefix = t.session.logger, t.prefix
+	err := fetcher.fetch(ctx, &input, func(event cwltypes.FilteredLogEvent) error {
+		t.add(aws.ToInt64(event.Timestamp), aws.ToString(event.LogStreamName), aws.ToString(event.Message), &occurrences)
+		return nil
+	})
+	if err != nil {
+		t.session.logger.Printf("[%s]: Error fetching logs: %v", t.prefix, err)
+	}
+	return err
+}
+
+func (t *cloudWatchLiveTail) add(timestamp int64, stream, message string, occurrences *liveTailOccurrences) {
+	t.lastTimestamp = max(t.lastTimestamp, timestamp)
+	eventId := occurrences.id(timestamp, stream, message)
+	if t.match != nil && !t.match(message) {
+		return
+	}
+	t.source.add(logEvent{
+		message:   message,
+		prefix:    t.prefix,
+		label:     t.label,
+		stream:    stream,
+		timestamp: timestamp,
+		eventId:   eventId,
+		noColor:   t.session.opts.NoColor,
+	})
+}
+
+// liveTailOccurrences identifies events by their timestamp, log stream,
+// message and occurrence, since Live Tail events have no ID. Each session and
+// each catch-up numbers the copies of an event in the order it gets them, so
+// that an event both fetched and streamed is only printed once, while lines
+// repeated within the same millisecond are all printed. The counts are kept
+// for seenEventsWindow behind the latest event.
+type liveTailOccurrences struct {
+	counts map[liveTailEventKey]int
+	order  []liveTailEventKey
+	latest int64
+}
+
+type liveTailEventKey struct {
+	timestamp int64
+	stream    string
+	message   uint64
+}
+
+func (o *liveTailOccurrences) id(timestamp int64, stream, message string) string {
+	if o.counts == nil {
+		o.counts = make(map[liveTailEventKey]int)
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(message))
+	key := liveTailEventKey{timestamp: timestamp, stream: stream, message: hash.Sum64()}
+	o.counts[key]++
+	occurrence := o.counts[key]
+	if occurrence == 1 {
+		o.order = append(o.order, key)
+	}
+
+	o.latest = max(o.latest, timestamp)
+	for len(o.order) > 0 && o.order[0].timestamp < o.latest-seenEventsWindow.Milliseconds() {
+		delete(o.counts, o.order[0])
+		o.order = o.order[1:]
+	}
+	return fmt.Sprintf("live-%d-%s-%x-%d", key.timestamp, key.stream, key.message, occurrence)
+}
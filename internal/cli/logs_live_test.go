@@ -0,0 +1,243 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+type fakeLiveTailStream struct {
+	events chan cwltypes.StartLiveTailResponseStream
+	err    error
+}
+
+func (s *fakeLiveTailStream) Events() <-chan cwltypes.StartLiveTailResponseStream {
+	return s.events
+}
+
+func (s *fakeLiveTailStream) Close() error {
+	return nil
+}
+
+func (s *fakeLiveTailStream) Err() error {
+	return s.err
+}
+
+var testLiveTailOutputs = &StackOutputs{
+	ServiceLogGroupName:    "/aws/ecs/runs-on/flexd",
+	EC2InstanceLogGroupArn: "arn:aws:logs:us-east-1:123456789012:log-group:runs-on/ec2/instances",
+}
+
+func TestStackOutputsLogGroupArn(t *testing.T) {
+	for identifier, want := range map[string]string{
+		"/aws/ecs/runs-on/flexd":                                "arn:aws:logs:us-east-1:123456789012:log-group:/aws/ecs/runs-on/flexd",
+		"arn:aws:logs:eu-west-1:123456789012:log-group:other:*": "arn:aws:logs:eu-west-1:123456789012:log-group:other",
+	} {
+		got, err := testLiveTailOutputs.logGroupArn(identifier)
+		if err != nil || got != want {
+			t.Fatalf("logGroupArn(%q) = %q, %v; want %q", identifier, got, err, want)
+		}
+	}
+	if _, err := (&StackOutputs{ServiceLogGroupName: "/aws/ecs/runs-on/flexd"}).logGroupArn("/aws/ecs/runs-on/flexd"); err == nil {
+		t.Fatal("expected a log group name without a known account and region to fail")
+	}
+}
+
+// streamWatchedApplicationLogs streams the application logs in watch mode, and
+// returns the printed lines and a function stopping the stream.
+func streamWatchedApplicationLogs(t *testing.T, cwl *mockCloudWatchLogsClient, opts *LogOptions) (*bufio.Scanner, func()) {
+	t.Helper()
+
+	reader, writer := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	streamer := &applicationLogStreamer{cwl: cwl, outputs: testLiveTailOutputs}
+	opts.Watch, opts.Format, opts.NoColor, opts.Out = true, logFormatShort, true, writer
+	go func() {
+		defer close(done)
+		streamer.Stream(ctx, opts)
+		writer.Close()
+	}()
+	return bufio.NewScanner(reader), func() {
+		cancel()
+		go io.Copy(io.Discard, reader)
+		<-done
+	}
+}
+
+func nextLogLine(t *testing.T, lines *bufio.Scanner) string {
+	t.Helper()
+	if !lines.Scan() {
+		t.Fatalf("expected another log line: %v", lines.Err())
+	}
+	return lines.Text()
+}
+
+func TestWatchFollowsNewEventsWithLiveTail(t *testing.T) {
+	stream := &fakeLiveTailStream{events: make(chan cwltypes.StartLiveTailResponseStream, 2)}
+	stream.events <- &cwltypes.StartLiveTailResponseStreamMemberSessionStart{Value: cwltypes.LiveTailSessionStart{SessionId: aws.String("session-1")}}
+	stream.events <- &cwltypes.StartLiveTailResponseStreamMemberSessionUpdate{Value: cwltypes.LiveTailSessionUpdate{
+		SessionResults: []cwltypes.LiveTailSessionLogEvent{
+			{Message: aws.String(`{"message":"live"}`), Timestamp: aws.Int64(456), LogStreamName: aws.String("live-stream")},
+		},
+	}}
+	cwl := &mockCloudWatchLogsClient{liveTail: func(*cloudwatchlogs.StartLiveTailInput) (liveTailStream, error) {
+		return stream, nil
+	}}
+
+	lines, stop := streamWatchedApplicationLogs(t, cwl, &LogOptions{StartTime: 1, WatchInterval: time.Hour})
+	defer stop()
+	if line := nextLogLine(t, lines); !strings.Contains(line, "server") {
+		t.Fatalf("expected the past events first, got %q", line)
+	}
+	if line := nextLogLine(t, lines); !strings.Contains(line, "live") {
+		t.Fatalf("expected the Live Tail event, got %q", line)
+	}
+
+	liveInput := cwl.liveTailInputs[0]
+	if liveInput.LogGroupIdentifiers[0] != "arn:aws:logs:us-east-1:123456789012:log-group:/aws/ecs/runs-on/flexd" || liveInput.LogEventFilterPattern != nil {
+		t.Fatalf("unexpected Live Tail request %+v", liveInput)
+	}
+	inputs := cwl.filterInputs()
+	if len(inputs) != 2 {
+		t.Fatalf("expected the initial fetch and the catch-up fetch only, got %d fetches", len(inputs))
+	}
+	if aws.ToInt64(inputs[1].StartTime) != 124 || inputs[1].EndTime == nil {
+		t.Fatalf("expected the catch-up to fetch from the last event to the session start, got %+v", inputs[1])
+	}
+}
+
+func TestWatchPrintsEventsCaughtUpAndStreamedOnce(t *testing.T) {
+	liveEvent := func(message string, timestamp int64) cwltypes.LiveTailSessionLogEvent {
+		return cwltypes.LiveTailSessionLogEvent{Message: aws.String(`{"message":"` + message + `"}`), Timestamp: aws.Int64(timestamp), LogStreamName: aws.String("live-stream")}
+	}
+	session := func(results ...cwltypes.LiveTailSessionLogEvent) *fakeLiveTailStream {
+		stream := &fakeLiveTailStream{events: make(chan cwltypes.StartLiveTailResponseStream, 2)}
+		stream.events <- &cwltypes.StartLiveTailResponseStreamMemberSessionStart{Value: cwltypes.LiveTailSessionStart{SessionId: aws.String("session")}}
+		stream.events <- &cwltypes.StartLiveTailResponseStreamMemberSessionUpdate{Value: cwltypes.LiveTailSessionUpdate{SessionResults: results}}
+		return stream
+	}
+	// The first session ends after its events, and the second one catches up
+	// from before them.
+	first := session(liveEvent("both", 100000), liveEvent("after", 100001))
+	close(first.events)
+	streams := []*fakeLiveTailStream{first, session(liveEvent("last", 100002))}
+	cwl := &mockCloudWatchLogsClient{
+		liveTail: func(*cloudwatchlogs.StartLiveTailInput) (liveTailStream, error) {
+			stream := streams[0]
+			streams = streams[1:]
+			return stream, nil
+		},
+		laterEvents: []cwltypes.FilteredLogEvent{{
+			Message:       aws.String(`{"message":"both"}`),
+			Timestamp:     aws.Int64(100000),
+			EventId:       aws.String("both-event"),
+			LogStreamName: aws.String("live-stream"),
+		}},
+	}
+
+	lines, stop := streamWatchedApplicationLogs(t, cwl, &LogOptions{StartTime: 1, WatchInterval: time.Hour})
+	defer stop()
+	var got []string
+	for _, want := range []string{"server", "both", "after", "last"} {
+		line := nextLogLine(t, lines)
+		got = append(got, line)
+		if !strings.Contains(line, want) {
+			t.Fatalf("expected %q to be printed once, in order, got %q", want, got)
+		}
+	}
+
+	inputs := cwl.filterInputs()
+	if len(inputs) != 3 {
+		t.Fatalf("expected the initial fetch and a catch-up per session, got %d fetches", len(inputs))
+	}
+	if got, want := aws.ToInt64(inputs[2].StartTime), int64(100002)-liveTailResumeOverlap.Milliseconds(); got != want {
+		t.Fatalf("expected the second catch-up to start %v before the latest event, got %d", liveTailResumeOverlap, got)
+	}
+}
+
+func TestWatchFetchesTheEventsMissingFromSampledUpdates(t *testing.T) {
+	// The events are after the start of the session, so that only the fetch
+	// of the sampled second returns them.
+	second := time.Now().Add(time.Hour).Truncate(time.Second).UnixMilli()
+	event := func(message string, timestamp int64) cwltypes.FilteredLogEvent {
+		return cwltypes.FilteredLogEvent{Message: aws.String(`{"message":"` + message + `"}`), Timestamp: aws.Int64(timestamp), LogStreamName: aws.String("busy-stream")}
+	}
+	stream := &fakeLiveTailStream{events: make(chan cwltypes.StartLiveTailResponseStream, 2)}
+	stream.events <- &cwltypes.StartLiveTailResponseStreamMemberSessionStart{Value: cwltypes.LiveTailSessionStart{SessionId: aws.String("session")}}
+	stream.events <- &cwltypes.StartLiveTailResponseStreamMemberSessionUpdate{Value: cwltypes.LiveTailSessionUpdate{
+		SessionMetadata: &cwltypes.LiveTailSessionMetadata{Sampled: true},
+		SessionResults: []cwltypes.LiveTailSessionLogEvent{
+			{Message: aws.String(`{"message":"busy"}`), Timestamp: aws.Int64(second + 10), LogStreamName: aws.String("busy-stream")},
+		},
+	}}
+	cwl := &mockCloudWatchLogsClient{
+		liveTail: func(*cloudwatchlogs.StartLiveTailInput) (liveTailStream, error) {
+			return stream, nil
+		},
+		// The sampled event is logged twice in the same millisecond.
+		laterEvents: []cwltypes.FilteredLogEvent{event("busy", second+10), event("busy", second+10), event("missed", second+500)},
+	}
+
+	var stderr bytes.Buffer
+	lines, stop := streamWatchedApplicationLogs(t, cwl, &LogOptions{StartTime: 1, WatchInterval: time.Hour, ErrOut: &stderr})
+	var got []string
+	for _, want := range []string{"server", "busy", "busy", "missed"} {
+		line := nextLogLine(t, lines)
+		got = append(got, line)
+		if !strings.Contains(line, want) {
+			t.Fatalf("expected %q next, got %q", want, got)
+		}
+	}
+	stop()
+
+	inputs := cwl.filterInputs()
+	if last := inputs[len(inputs)-1]; aws.ToInt64(last.StartTime) != second || aws.ToInt64(last.EndTime) != second+999 {
+		t.Fatalf("expected the sampled second to be fetched, got %+v", last)
+	}
+	if !strings.Contains(stderr.String(), "Warning: [application] more than 500 events per second") {
+		t.Fatalf("expected a warning about the sampled events, got %q", stderr.String())
+	}
+}
+
+func TestWatchFallsBackToPollingWithoutLiveTail(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		opts *LogOptions
+		cwl  *mockCloudWatchLogsClient
+	}{
+		{"unavailable", &LogOptions{StartTime: 1, WatchInterval: time.Millisecond}, &mockCloudWatchLogsClient{}},
+		{"disabled", &LogOptions{StartTime: 1, WatchInterval: time.Millisecond, NoLiveTail: true}, &mockCloudWatchLogsClient{liveTail: func(*cloudwatchlogs.StartLiveTailInput) (liveTailStream, error) {
+			t.Error("did not expect a Live Tail session with --no-live-tail")
+			return nil, io.EOF
+		}}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			lines, stop := streamWatchedApplicationLogs(t, test.cwl, test.opts)
+			if line := nextLogLine(t, lines); !strings.Contains(line, "server") {
+				t.Fatalf("expected the past events first, got %q", line)
+			}
+			deadline := time.Now().Add(5 * time.Second)
+			for len(test.cwl.filterInputs()) < 3 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			stop()
+
+			inputs := test.cwl.filterInputs()
+			if len(inputs) < 3 {
+				t.Fatalf("expected new events to be polled for, got %d fetches", len(inputs))
+			}
+			if aws.ToInt64(inputs[1].StartTime) != 124 {
+				t.Fatalf("expected polling to resume after the last event, got %d", aws.ToInt64(inputs[1].StartTime))
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// maxReplayLineSize is the size of the longest line read from an archive.
+const maxReplayLineSize = 16 * 1024 * 1024
+
+// replayEntry is a log file of a --full archive.
+type replayEntry struct {
+	file   *zip.File
+	prefix string
+	stream string
+}
+
+// replayEntries returns the server and agent logs of an archive, including
+// those of the jobs/<job_id> directories of a --run archive. The application
+// logs of the jobs are also in the logs of their run; see replayRunLines.
+func replayEntries(files []*zip.File) []replayEntry {
+	var entries []replayEntry
+	for _, file := range files {
+		dir, name := path.Split(file.Name)
+		switch {
+		case path.Base(dir) == "server" && path.Ext(name) == ".jsonl":
+			entries = append(entries, replayEntry{file: file, prefix: "application", stream: strings.TrimSuffix(name, ".jsonl")})
+		case path.Base(path.Dir(path.Clean(dir))) == "instances" && name == "agent.jsonl":
+			entries = append(entries, replayEntry{file: file, prefix: "instance", stream: path.Base(dir)})
+		}
+	}
+	return entries
+}
+
+// isRunLogs reports whether the entry holds the application logs of a run.
+func (e replayEntry) isRunLogs() bool {
+	return e.prefix == "application" && strings.HasPrefix(e.stream, "run-")
+}
+
+// replayRunLines counts the lines of the run logs of an archive. The lines of
+// the job logs that are also in the run logs are only printed from the run
+// logs, as many times as the run logs have them, so that repeated lines are
+// not merged.
+func replayRunLines(entries []replayEntry) (map[string]int, error) {
+	lines := make(map[string]int)
+	for _, entry := range entries {
+		if !entry.isRunLogs() {
+			continue
+		}
+		err := scanReplayEntry(entry, func(line string) {
+			lines[line]++
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
+
+// scanReplayEntry calls handle with each line of an entry that isn't blank.
+func scanReplayEntry(entry replayEntry, handle func(line string)) error {
+	file, err := entry.file.Open()
+	if err != nil {
+		return fmt.Errorf("open %s: %w", entry.file.Name, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxReplayLineSize)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.TrimSpace(line) != "" {
+			handle(line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read %s: %w", entry.file.Name, err)
+	}
+	return nil
+}
+
+// replayLogArchive prints the logs of a --full archive, merged by timestamp
+// as drainAndWatch prints the streamed logs.
+func replayLogArchive(ctx context.Context, archivePath string, errOut io.Writer, opts *LogOptions) error {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("open log archive: %w", err)
+	}
+	defer reader.Close()
+
+	manifest, err := readReplayManifest(reader.File)
+	if err != nil {
+		return err
+	}
+	for _, artifactErr := range manifest.Errors {
+		fmt.Fprintf(errOut, "Warning: %s could not be collected: %s\n", artifactErr.Path, artifactErr.Error)
+	}
+	entries := replayEntries(reader.File)
+	if len(entries) == 0 {
+		return fmt.Errorf("no server or agent logs found in %s", archivePath)
+	}
+	runLines, err := replayRunLines(entries)
+	if err != nil {
+		return err
+	}
+
+	var windowStart int64
+	if !manifest.WindowStart.IsZero() {
+		windowStart = manifest.WindowStart.UnixMilli()
+	}
+	session := newStreamedLogSession(opts, nil)
+	var mu sync.Mutex
+	var errs []error
+	for _, entry := range entries {
+		session.startOnce(entry.file.Name, func(source *logSource) error {
+			err := replayArchiveEntry(entry, windowStart, runLines, source, opts)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+			return err
+		})
+	}
+	if err := session.drainAndWatch(ctx); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return errors.Join(errs...)
+}
+
+func readReplayManifest(files []*zip.File) (*fullLogManifest, error) {
+	manifest := &fullLogManifest{}
+	for _, file := range files {
+		if file.Name != "manifest.json" {
+			continue
+		}
+		entry, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open manifest.json: %w", err)
+		}
+		defer entry.Close()
+		if err := json.NewDecoder(entry).Decode(manifest); err != nil {
+			return nil, fmt.Errorf("read manifest.json: %w", err)
+		}
+		break
+	}
+	return manifest, nil
+}
+
+// replayArchiveEntry adds the lines of an entry to source. The archive only
+// holds the messages, so their time field is used as timestamp; lines without
+// one get the timestamp of the line before. The lines of job logs that are
+// also in runLines are skipped.
+func replayArchiveEntry(entry replayEntry, timestamp int64, runLines map[string]int, source *logSource, opts *LogOptions) error {
+	inRunLogs := make(map[string]int)
+	lineNumber := 0
+	return scanReplayEntry(entry, func(line string) {
+		lineNumber++
+		if parsed, ok := replayLineTimestamp(line); ok {
+			timestamp = parsed
+		}
+		if entry.prefix == "application" && !entry.isRunLogs() && inRunLogs[line] < runLines[line] {
+			inRunLogs[line]++
+			return
+		}
+		if !opts.Filter.matchText(line) {
+			return
+		}
+		source.add(logEvent{
+			message:   line,
+			prefix:    entry.prefix,
+			stream:    entry.stream,
+			timestamp: timestamp,
+			eventId:   fmt.Sprintf("%s:%d", entry.file.Name, lineNumber),
+			noColor:   opts.NoColor,
+		})
+	})
+}
+
+// replayLineTimestamp returns the time field of a JSON log line, in Unix
+// milliseconds.
+func replayLineTimestamp(line string) (int64, bool) {
+	var fields struct {
+		Time      string `json:"time"`
+		Timestamp string `json:"timestamp"`
+	}
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return 0, false
+	}
+	for _, value := range []string{fields.Time, fields.Timestamp} {
+		if parsed, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return parsed.UnixMilli(), true
+		}
+	}
+	return 0, false
+}
+
+func NewLogsReplayCmd() *cobra.Command {
+	var (
+		format    string
+		fields    []string
+		noColor   bool
+		grep      string
+		maxEvents int
+	)
+
+	cmd := &cobra.Command{
+		Use:         "replay ARCHIVE",
+		Short:       "Print the logs of a --full archive as roc logs streams them",
+		Annotations: map[string]string{noContextAnnotation: "true"},
+		Long: `Print the server and agent logs of an archive exported with roc logs --full,
+merged by timestamp as roc logs streams them, without AWS access.
+
+The archive only keeps the log messages, so the time field of the JSON log
+lines is used to order them. The lines of the job logs that are also in the
+run logs are printed once. Artifacts that could not be collected when
+exporting the archive are reported on stderr.`,
+		Example: `  roc logs replay roc-logs-34661958899-2026-05-08-12-00-00.zip
+  roc logs replay roc-logs-34661958899-2026-05-08-12-00-00.zip --format pretty --grep timeout`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := newLogFormatter(format, fields); err != nil {
+				return err
+			}
+			if err := validateMaxEvents(maxEvents); err != nil {
+				return err
+			}
+			filter, err := newLogFilter(nil, grep, "")
+			if err != nil {
+				return err
+			}
+			return replayLogArchive(cmd.Context(), args[0], cmd.ErrOrStderr(), &LogOptions{
+				Format:    format,
+				Fields:    fields,
+				NoColor:   noColor,
+				Output:    outputFormat(cmd),
+				Out:       cmd.OutOrStdout(),
+				Filter:    filter,
+				MaxEvents: maxEvents,
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "long", "Output format: long, short, pretty, or template=<Go template>")
+	cmd.Flags().StringSliceVar(&fields, "fields", nil, "Fields of the JSON application logs to show with --format pretty (e.g. job_id,run_id,repo)")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "Disable color output")
+	cmd.Flags().StringVar(&grep, "grep", "", "Only show log events matching this regular expression")
+	cmd.Flags().IntVar(&maxEvents, "max-events", 0, "Stop after printing this many log events (default: no limit)")
+
+	return cmd
+}
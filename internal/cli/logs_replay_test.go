@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"archive/zip"
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeReplayArchive(t *testing.T) string {
+	t.Helper()
+	archivePath := filepath.Join(t.TempDir(), "roc-logs-42.zip")
+	archive, err := newArchiveWriter(archivePath)
+	if err != nil {
+		t.Fatalf("newArchiveWriter returned error: %v", err)
+	}
+	entries := map[string]string{
+		"server/job-42.jsonl": `{"time":"2026-05-08T12:00:01Z","level":"info","message":"job scheduled"}` + "\n" +
+			`{"time":"2026-05-08T12:00:03.5Z","level":"warn","message":"retrying"}` + "\n" +
+			`{"time":"2026-05-08T12:00:03.5Z","level":"warn","message":"retrying"}` + "\n" +
+			`{"time":"2026-05-08T12:00:04Z","level":"error","message":"job failed"}` + "\n" +
+			`{"time":"2026-05-08T12:00:05Z","level":"info","message":"job cleaned up"}` + "\n",
+		"server/run-7.jsonl": `{"time":"2026-05-08T12:00:00Z","level":"info","message":"run started"}` + "\n" +
+			`{"time":"2026-05-08T12:00:01Z","level":"info","message":"job scheduled"}` + "\n" +
+			`{"time":"2026-05-08T12:00:03.5Z","level":"warn","message":"retrying"}` + "\n" +
+			`{"time":"2026-05-08T12:00:03.5Z","level":"warn","message":"retrying"}` + "\n" +
+			`{"time":"2026-05-08T12:00:04Z","level":"error","message":"job failed"}` + "\n",
+		"instances/i-123/agent.jsonl": `{"time":"2026-05-08T12:00:02Z","message":"agent started"}` + "\n" +
+			"plain line after the agent start\n" +
+			`{"time":"2026-05-08T12:00:03Z","message":"step done"}` + "\n",
+		"instances/i-123/console.log": "console line\n",
+	}
+	for entryPath, text := range entries {
+		if err := archive.writeText(entryPath, text); err != nil {
+			t.Fatalf("writeText returned error: %v", err)
+		}
+	}
+	if err := archive.writeJSON("manifest.json", fullLogManifest{
+		JobID:       42,
+		WindowStart: time.Date(2026, 5, 8, 11, 0, 0, 0, time.UTC),
+		Errors:      []fullArtifactError{{Path: "instances/i-123/cloudtrail.json", Error: "access denied"}},
+	}); err != nil {
+		t.Fatalf("writeJSON returned error: %v", err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	return archivePath
+}
+
+func TestLogsReplayMergesArchiveEntries(t *testing.T) {
+	archivePath := writeReplayArchive(t)
+	var stdout, stderr bytes.Buffer
+	cmd := NewLogsReplayCmd()
+	cmd.SetArgs([]string{archivePath, "--format", "short", "--no-color"})
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("replay returned error: %v", err)
+	}
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		_, message, _ := strings.Cut(line, "] ")
+		messages = append(messages, message)
+	}
+	// The lines of the job logs are printed once, as many times as the run
+	// logs have them, and those missing from the run logs are kept.
+	want := []string{"run started", "job scheduled", "agent started", "plain line after the agent start", "step done", "retrying", "retrying", "job failed", "job cleaned up"}
+	if len(messages) != len(want) {
+		t.Fatalf("expected %d events, got %q", len(want), stdout.String())
+	}
+	for i, message := range messages {
+		if !strings.Contains(message, want[i]) {
+			t.Fatalf("event %d: got %q, want %q", i, message, want[i])
+		}
+	}
+	if !strings.Contains(stdout.String(), "[i-123]") {
+		t.Fatalf("expected the agent logs to be labeled with their instance, got %q", stdout.String())
+	}
+	if stderr.String() != "Warning: instances/i-123/cloudtrail.json could not be collected: access denied\n" {
+		t.Fatalf("expected the artifact errors of the manifest on stderr, got %q", stderr.String())
+	}
+}
+
+func TestLogsReplayGrep(t *testing.T) {
+	archivePath := writeReplayArchive(t)
+	var stdout bytes.Buffer
+	cmd := NewLogsReplayCmd()
+	cmd.SetArgs([]string{archivePath, "--grep", "failed", "--no-color"})
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("replay returned error: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(stdout.String()), "\n"); len(lines) != 1 || !strings.Contains(lines[0], "job failed") {
+		t.Fatalf("expected only the failed event, got %q", stdout.String())
+	}
+}
+
+func TestReplayEntriesOfRunArchive(t *testing.T) {
+	var files []*zip.File
+	for _, name := range []string{
+		"server/run-7.jsonl",
+		"jobs/41/server/job-41.jsonl",
+		"jobs/41/dynamodb/job-41.ddb.json",
+		"jobs/41/instances/i-123/agent.jsonl",
+		"jobs/41/instances/i-123/console.log",
+		"manifest.json",
+	} {
+		files = append(files, &zip.File{FileHeader: zip.FileHeader{Name: name}})
+	}
+
+	var got []string
+	for _, entry := range replayEntries(files) {
+		got = append(got, entry.prefix+":"+entry.stream)
+	}
+	if strings.Join(got, ",") != "application:run-7,application:job-41,instance:i-123" {
+		t.Fatalf("unexpected replay entries %v", got)
+	}
+}
+
+func TestLogsReplayRejectsOtherFiles(t *testing.T) {
+	cmd := NewLogsReplayCmd()
+	cmd.SetArgs([]string{filepath.Join(t.TempDir(), "missing.zip")})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "open log archive") {
+		t.Fatalf("expected a missing archive to fail, got %v", err)
+	}
+}
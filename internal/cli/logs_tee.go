@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// teeMaxBackups is the number of rotated --tee files kept next to the current
+// one, as FILE.1 (the most recent) to FILE.5.
+const teeMaxBackups = 5
+
+// logTee copies the streamed log events to a file, without colors, as text in
+// the --format of the command or as NDJSON.
+type logTee struct {
+	file   *rotatingFile
+	events *eventWriter
+}
+
+func addLogTeeFlags(cmd *cobra.Command) {
+	cmd.Flags().String("tee", "", "Also write the streamed logs to this file, without colors")
+	cmd.Flags().String("tee-format", "text", "Format of the --tee file: text or json (NDJSON)")
+	cmd.Flags().String("tee-max-size", "", "Rotate the --tee file when it reaches this size (e.g. 100MB; default: no rotation)")
+}
+
+// logTeeFromFlags opens the --tee file. It returns nil without --tee.
+func logTeeFromFlags(cmd *cobra.Command) (*logTee, error) {
+	path, _ := cmd.Flags().GetString("tee")
+	format, _ := cmd.Flags().GetString("tee-format")
+	maxSize, _ := cmd.Flags().GetString("tee-max-size")
+	if strings.TrimSpace(path) == "" {
+		if cmd.Flags().Changed("tee-format") || cmd.Flags().Changed("tee-max-size") {
+			return nil, fmt.Errorf("--tee-format and --tee-max-size require --tee")
+		}
+		return nil, nil
+	}
+	return newLogTee(path, format, maxSize)
+}
+
+func newLogTee(path, format, maxSize string) (*logTee, error) {
+	if format != "text" && format != outputJSON {
+		return nil, fmt.Errorf("invalid --tee-format %q (valid: text, json)", format)
+	}
+	var size int64
+	if strings.TrimSpace(maxSize) != "" {
+		var err error
+		if size, err = parseByteSize(maxSize); err != nil {
+			return nil, fmt.Errorf("invalid --tee-max-size: %w", err)
+		}
+	}
+	file, err := openRotatingFile(path, size)
+	if err != nil {
+		return nil, err
+	}
+	tee := &logTee{file: file}
+	if format == outputJSON {
+		tee.events = newEventWriter(file, outputJSON)
+	}
+	return tee, nil
+}
+
+func (t *logTee) write(event logEvent, formatter *logFormatter) error {
+	if t.events != nil {
+		return t.events.write(event.record())
+	}
+	event.noColor = true
+	return formatter.write(t.file, &event)
+}
+
+func (t *logTee) Close() error {
+	if t == nil {
+		return nil
+	}
+	return t.file.Close()
+}
+
+// rotatingFile appends to a file, and renames it to FILE.1 when a write would
+// make it bigger than maxSize. A zero maxSize never rotates.
+type rotatingFile struct {
+	path    string
+	maxSize int64
+	file    *os.File
+	size    int64
+}
+
+func openRotatingFile(path string, maxSize int64) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxSize: maxSize}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("open --tee file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("open --tee file: %w", err)
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("rotate --tee file: %w", err)
+	}
+	os.Remove(fmt.Sprintf("%s.%d", f.path, teeMaxBackups))
+	for i := teeMaxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+	}
+	if err := os.Rename(f.path, f.path+".1"); err != nil {
+		return fmt.Errorf("rotate --tee file: %w", err)
+	}
+	return f.open()
+}
+
+func (f *rotatingFile) Close() error {
+	return f.file.Close()
+}
+
+// parseByteSize parses sizes like 512, 64KB, 100MB or 1G. Units are powers of
+// 1024.
+func parseByteSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	number := strings.TrimRight(value, "KMGIB")
+	unit := strings.TrimPrefix(value, number)
+	multiplier := int64(1)
+	switch strings.TrimSuffix(strings.TrimSuffix(unit, "B"), "I") {
+	case "":
+	case "K":
+		multiplier = 1 << 10
+	case "M":
+		multiplier = 1 << 20
+	case "G":
+		multiplier = 1 << 30
+	default:
+		return 0, fmt.Errorf("unknown size unit in %q", value)
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return size * multiplier, nil
+}
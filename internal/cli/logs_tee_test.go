@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	for value, want := range map[string]int64{
+		"512":   512,
+		"64KB":  64 << 10,
+		"100mb": 100 << 20,
+		"1G":    1 << 30,
+		"2MiB":  2 << 20,
+	} {
+		got, err := parseByteSize(value)
+		if err != nil || got != want {
+			t.Fatalf("parseByteSize(%q) = %d, %v; want %d", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "MB", "10TB", "-1", "1.5MB"} {
+		if _, err := parseByteSize(value); err == nil {
+			t.Fatalf("expected parseByteSize(%q) to fail", value)
+		}
+	}
+}
+
+func TestRotatingFileRotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "roc.log")
+	file, err := openRotatingFile(path, 10)
+	if err != nil {
+		t.Fatalf("openRotatingFile returned error: %v", err)
+	}
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	for name, want := range map[string]string{"roc.log": "third\n", "roc.log.1": "second\n", "roc.log.2": "first\n"} {
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(path), name))
+		if err != nil || string(data) != want {
+			t.Fatalf("expected %s to contain %q, got %q (%v)", name, want, data, err)
+		}
+	}
+}
+
+func TestLogTeeWritesUncoloredTextAndNDJSON(t *testing.T) {
+	dir := t.TempDir()
+	event := logEvent{message: `{"level":"error","message":"launch failed"}`, prefix: "application", stream: "flexd/app", eventId: "1", timestamp: 1}
+	formatter, err := newLogFormatter(logFormatPretty, nil)
+	if err != nil {
+		t.Fatalf("newLogFormatter returned error: %v", err)
+	}
+
+	textPath := filepath.Join(dir, "roc.log")
+	tee, err := newLogTee(textPath, "text", "")
+	if err != nil {
+		t.Fatalf("newLogTee returned error: %v", err)
+	}
+	if err := tee.write(event, formatter); err != nil {
+		t.Fatalf("write returned error: %v", err)
+	}
+	tee.Close()
+	data, _ := os.ReadFile(textPath)
+	if strings.Contains(string(data), "\033[") || !strings.Contains(string(data), "ERROR launch failed") {
+		t.Fatalf("expected an uncolored pretty line, got %q", data)
+	}
+
+	jsonPath := filepath.Join(dir, "roc.ndjson")
+	tee, err = newLogTee(jsonPath, "json", "1MB")
+	if err != nil {
+		t.Fatalf("newLogTee returned error: %v", err)
+	}
+	if err := tee.write(event, formatter); err != nil {
+		t.Fatalf("write returned error: %v", err)
+	}
+	tee.Close()
+	data, _ = os.ReadFile(jsonPath)
+	var record logEventRecord
+	if err := json.Unmarshal(data, &record); err != nil || record.Message != "launch failed" || record.EventID != "1" {
+		t.Fatalf("expected an NDJSON record, got %q (%v)", data, err)
+	}
+
+	if _, err := newLogTee(textPath, "yaml", ""); err == nil {
+		t.Fatal("expected an invalid --tee-format to be rejected")
+	}
+}
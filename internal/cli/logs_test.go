@@ -3,6 +3,8 @@ package cli
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"io"
 	"strings"
 	"testing"
 	"time"
@@ -156,6 +158,168 @@ func TestStreamedLogSessionUsesExpectedJobAndStackFilters(t *testing.T) {
 	}
 }
 
+func TestStreamRunMergesJobInstanceStreamsWithRunApplicationLogs(t *testing.T) {
+	createdAt := time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC)
+	jobsClient := &mockWorkflowJobsLookupClient{t: t}
+	for jobID, instanceID := range map[int64]string{41: "i-first", 42: "i-second"} {
+		jobsClient.records = append(jobsClient.records, workflowJobFactsRecord{
+			JobID:     jobID,
+			RunID:     1234,
+			CreatedAt: &createdAt,
+			ActiveAttempt: &struct {
+				InstanceID string `dynamodbav:"instance_id"`
+			}{InstanceID: instanceID},
+		})
+	}
+	cwl := &mockCloudWatchLogsClient{}
+	streamer := &jobLogStreamer{
+		cwl: cwl,
+		outputs: &StackOutputs{
+			ServiceLogGroupName:    "/aws/ecs/runs-on/flexd",
+			EC2InstanceLogGroupArn: "arn:aws:logs:us-east-1:123456789012:log-group:runs-on/ec2/instances",
+		},
+	}
+	jobs := map[string]*workflowJobFactsProvider{}
+	for _, jobID := range []string{"41", "42"} {
+		jobs[jobID] = &workflowJobFactsProvider{jobs: jobsClient, tableName: "workflow-jobs", jobID: jobID}
+	}
+
+	if err := streamer.StreamRun(context.Background(), 1234, jobs, nil, &LogOptions{StartTime: 1, NoColor: true}); err != nil {
+		t.Fatalf("StreamRun returned error: %v", err)
+	}
+
+	seen := map[string]bool{}
+	for _, input := range cwl.inputs {
+		if prefix := aws.ToString(input.LogStreamNamePrefix); prefix != "" {
+			seen[prefix] = true
+		}
+		if strings.Contains(aws.ToString(input.FilterPattern), `$.run_id = "1234"`) {
+			seen["run"] = true
+		}
+	}
+	if !seen["i-first/"] || !seen["i-second/"] || !seen["run"] {
+		t.Fatalf("expected instance streams for both jobs and a run application filter, got %#v", cwl.inputs)
+	}
+}
+
+func TestLogCollectorDeduplicatesEventsPerStackLabel(t *testing.T) {
+	collector := newLogCollector()
+	source := collector.newSource()
+	go func() {
+		source.add(logEvent{label: "runs-on/us-east-1", eventId: "1"})
+		source.add(logEvent{label: "runs-on/us-east-1", eventId: "1"})
+		source.add(logEvent{label: "runs-on/eu-west-1", eventId: "1"})
+		source.pastCollected()
+	}()
+
+	var events []logEvent
+	if err := collector.mergePast(context.Background(), func(event logEvent) bool {
+		events = append(events, event)
+		return true
+	}); err != nil {
+		t.Fatalf("mergePast returned error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected the same event ID from two stacks to be kept, got %+v", events)
+	}
+}
+
+func TestStreamedLogSessionWritesNDJSON(t *testing.T) {
+	var output bytes.Buffer
+	session := newStreamedLogSession(&LogOptions{Output: outputJSON, Out: &output}, nil)
+	session.emit(logEvent{
+		timestamp: time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC).UnixMilli(),
+		message:   `{"level":"error","message":"launch failed","job_id":"42"}`,
+		stream:    "flexd/app",
+		prefix:    "app",
+		eventId:   "1",
+	}, &logFormatter{format: logFormatLong})
+	session.emit(logEvent{timestamp: 1, message: "plain line", stream: "i-123/cloud-init", eventId: "2"}, &logFormatter{format: logFormatLong})
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one JSON object per event, got:\n%s", output.String())
+	}
+	var record logEventRecord
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	if record.Message != "launch failed" || record.Source != "app" || record.Fields["level"] != "error" || record.EventID != "1" {
+		t.Fatalf("unexpected application record %+v", record)
+	}
+	record = logEventRecord{}
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	if record.Message != "plain line" || record.Source != "instance" || record.Fields != nil {
+		t.Fatalf("unexpected instance record %+v", record)
+	}
+}
+
+func TestJobLogOptionsStartFromJobCreation(t *testing.T) {
+	createdAt := time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC)
+	first := &workflowJobFactsProvider{facts: &workflowJobFacts{CreatedAt: createdAt}}
+	second := &workflowJobFactsProvider{facts: &workflowJobFacts{CreatedAt: createdAt.Add(time.Hour)}}
+	unknown := &workflowJobFactsProvider{}
+
+	opts := &LogOptions{EndTime: 5}
+	got := jobLogOptions(opts, second, unknown, first)
+	if want := createdAt.Add(-fullLogWindowPadding).UnixMilli(); got.StartTime != want || got.EndTime != 5 {
+		t.Fatalf("expected logs from %d, got %+v", want, got)
+	}
+	if opts.StartTime != 0 {
+		t.Fatal("expected the caller's options to be left untouched")
+	}
+
+	if got := jobLogOptions(&LogOptions{StartTime: 42}, first); got.StartTime != 42 {
+		t.Fatalf("expected --since to win over the job creation time, got %d", got.StartTime)
+	}
+	if got := jobLogOptions(&LogOptions{}, unknown); time.Since(time.UnixMilli(got.StartTime)) < defaultLogLookback-time.Minute {
+		t.Fatalf("expected the default lookback without a creation time, got %d", got.StartTime)
+	}
+}
+
+func TestParseLogTimeRange(t *testing.T) {
+	start, end, err := parseLogTimeRange("2026-05-08T12:00:00Z", "2026-05-08T13:00:00Z", false)
+	if err != nil {
+		t.Fatalf("parseLogTimeRange returned error: %v", err)
+	}
+	if start != time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC).UnixMilli() || end != time.Date(2026, 5, 8, 13, 0, 0, 0, time.UTC).UnixMilli() {
+		t.Fatalf("unexpected range %d-%d", start, end)
+	}
+
+	if start, end, err := parseLogTimeRange("", "", true); err != nil || start != 0 || end != 0 {
+		t.Fatalf("expected an empty range, got %d-%d (%v)", start, end, err)
+	}
+	for _, test := range []struct {
+		since, until string
+		watch        bool
+		want         string
+	}{
+		{"1h", "2h", false, "--until must not be before --since"},
+		{"", "1h", true, "--until cannot be used with --watch"},
+		{"yesterday", "", false, "invalid --since value"},
+	} {
+		if _, _, err := parseLogTimeRange(test.since, test.until, test.watch); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Fatalf("parseLogTimeRange(%q, %q) error = %v, want %q", test.since, test.until, err, test.want)
+		}
+	}
+}
+
+func TestApplicationLogsUseEndTime(t *testing.T) {
+	cwl := &mockCloudWatchLogsClient{}
+	streamer := &applicationLogStreamer{
+		cwl:     cwl,
+		outputs: &StackOutputs{ServiceLogGroupName: "/aws/ecs/runs-on/flexd"},
+	}
+	if err := streamer.Stream(context.Background(), &LogOptions{StartTime: 1, EndTime: 2, Out: io.Discard}); err != nil {
+		t.Fatalf("Stream returned error: %v", err)
+	}
+	if aws.ToInt64(cwl.inputs[0].StartTime) != 1 || aws.ToInt64(cwl.inputs[0].EndTime) != 2 {
+		t.Fatalf("expected the time range to be sent, got %+v", cwl.inputs[0])
+	}
+}
+
 func TestNoColorFlagIsLogCommandOnly(t *testing.T) {
 	rootHelp := rootCommandHelp(t, "--help")
 	if strings.Contains(rootHelp, "--no-color") {
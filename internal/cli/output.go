@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+// Formats of the global --output flag. text is the human output; json and
+// yaml are meant for scripts and never contain colors or emoji.
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+func validateOutputFormat(format string) error {
+	switch format {
+	case outputText, outputJSON, outputYAML:
+		return nil
+	default:
+		return fmt.Errorf("invalid --output %q (valid: text, json, yaml)", format)
+	}
+}
+
+// outputFormat returns the --output format of the command, text when unset.
+func outputFormat(cmd *cobra.Command) string {
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		return outputText
+	}
+	return format
+}
+
+func structuredOutput(cmd *cobra.Command) bool {
+	return outputFormat(cmd) != outputText
+}
+
+// writeStructured writes value as a single indented JSON or YAML document.
+func writeStructured(w io.Writer, format string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode %s output: %w", format, err)
+	}
+	if format == outputYAML {
+		data, err = jsonToYAML(data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// jsonToYAML converts a JSON document to block-style YAML. Going through JSON
+// keeps the json struct tags as the only field naming, and the field order.
+func jsonToYAML(data []byte) ([]byte, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("encode yaml output: %w", err)
+	}
+	resetYAMLStyle(&node)
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, fmt.Errorf("encode yaml output: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("encode yaml output: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
+
+// eventWriter writes a stream of structured events: one JSON object per line
+// (NDJSON), or one YAML document per event. It is safe for concurrent use.
+type eventWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format string
+}
+
+func newEventWriter(w io.Writer, format string) *eventWriter {
+	return &eventWriter{w: w, format: format}
+}
+
+func (e *eventWriter) write(event any) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", e.format, err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.format == outputYAML {
+		data, err = jsonToYAML(data)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(e.w, "---\n%s", data)
+		return err
+	}
+	_, err = fmt.Fprintf(e.w, "%s\n", data)
+	return err
+}
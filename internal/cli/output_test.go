@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testOutputRecord struct {
+	Name    string    `json:"name"`
+	Time    time.Time `json:"time"`
+	Count   int       `json:"count,omitempty"`
+	Entries []string  `json:"entries"`
+}
+
+func TestWriteStructuredUsesJSONFieldNamesInYAML(t *testing.T) {
+	record := testOutputRecord{Name: "runs-on", Time: time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC), Entries: []string{"a", "b"}}
+
+	var output bytes.Buffer
+	if err := writeStructured(&output, outputYAML, record); err != nil {
+		t.Fatalf("writeStructured returned error: %v", err)
+	}
+	want := "name: runs-on\ntime: \"2026-05-08T12:00:00Z\"\nentries:\n  - a\n  - b\n"
+	if output.String() != want {
+		t.Fatalf("unexpected YAML output:\n%s\nwant:\n%s", output.String(), want)
+	}
+
+	output.Reset()
+	if err := writeStructured(&output, outputJSON, record); err != nil {
+		t.Fatalf("writeStructured returned error: %v", err)
+	}
+	var decoded testOutputRecord
+	if err := json.Unmarshal(output.Bytes(), &decoded); err != nil || decoded.Name != "runs-on" || len(decoded.Entries) != 2 {
+		t.Fatalf("expected JSON output to round-trip, got %+v (%v):\n%s", decoded, err, output.String())
+	}
+}
+
+func TestEventWriterWritesNDJSONAndYAMLDocuments(t *testing.T) {
+	var output bytes.Buffer
+	events := newEventWriter(&output, outputJSON)
+	for _, name := range []string{"first", "second"} {
+		if err := events.write(testOutputRecord{Name: name}); err != nil {
+			t.Fatalf("write returned error: %v", err)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], `{"name":"first"`) || !strings.HasPrefix(lines[1], `{"name":"second"`) {
+		t.Fatalf("expected one JSON object per line, got:\n%s", output.String())
+	}
+
+	output.Reset()
+	events = newEventWriter(&output, outputYAML)
+	for _, name := range []string{"first", "second"} {
+		if err := events.write(testOutputRecord{Name: name}); err != nil {
+			t.Fatalf("write returned error: %v", err)
+		}
+	}
+	if got := strings.Count(output.String(), "---\n"); got != 2 || !strings.Contains(output.String(), "name: second") {
+		t.Fatalf("expected one YAML document per event, got:\n%s", output.String())
+	}
+}
+
+func TestOutputFlag(t *testing.T) {
+	if _, err := runRootCommand(t, "version", "--output", "xml"); err == nil || !strings.Contains(err.Error(), `invalid --output "xml"`) {
+		t.Fatalf("expected an invalid --output error, got %v", err)
+	}
+
+	output, err := runRootCommand(t, "version", "-o", "json")
+	if err != nil {
+		t.Fatalf("version returned error: %v", err)
+	}
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil || decoded["version"] == "" {
+		t.Fatalf("expected a JSON version record, got %q (%v)", output, err)
+	}
+}
@@ -1,7 +1,7 @@
 package cli
 
 import (
-	"os"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/spf13/cobra"
@@ -13,7 +13,13 @@ type RunsOnConfig struct {
 	ServiceLogGroupName    string
 	EC2InstanceLogGroupArn string
 	WorkflowJobsTable      string
-	AWSConfig              aws.Config
+	// Sources records where each discovered value came from, keyed by field
+	// name (e.g. "IngressURL": "stack-config secret").
+	Sources map[string]string
+	// AccountID is the AWS account of the stack when the stack config cache
+	// resolved it. It keys the cached readiness checks of the stack.
+	AccountID string
+	AWSConfig aws.Config
 }
 
 func NewRootCmd(stack *Stack) *cobra.Command {
@@ -25,27 +31,38 @@ func NewRootCmd(stack *Stack) *cobra.Command {
 			if cmd.Name() == "help" {
 				return nil
 			}
+			if err := validateOutputFormat(outputFormat(cmd)); err != nil {
+				return err
+			}
+			if cmd.Annotations[noContextAnnotation] != "" {
+				return nil
+			}
 
-			return nil
+			return stack.applyContext(cmd)
 		},
 	}
 
 	defaultStack := "runs-on"
-	for _, envVar := range []string{"RUNS_ON_STACK_NAME", "RUNS_ON_STACK"} {
-		if stackName, ok := os.LookupEnv(envVar); ok {
-			defaultStack = stackName
-			break
-		}
+	if stackName := envStackName(); stackName != "" {
+		defaultStack = stackName
 	}
 
-	cmd.PersistentFlags().String("stack", defaultStack, "CloudFormation stack name")
+	cmd.PersistentFlags().StringSlice("stack", strings.Split(defaultStack, ","), "CloudFormation stack name (comma-separated or repeated to target several stacks)")
+	cmd.PersistentFlags().StringSlice("regions", nil, "AWS regions to look for the stacks in (default: the region of the AWS config)")
+	cmd.PersistentFlags().Bool("refresh-stack-config", false, "Discover the stack config again instead of using the local cache")
+	cmd.PersistentFlags().Bool("strict-version", false, "Fail instead of warning when roc and the stack run different major/minor versions")
+	cmd.PersistentFlags().StringP("output", "o", outputText, "Output format: text, json, or yaml (json streams log events as NDJSON)")
+	cmd.PersistentFlags().String("context", "", "Named context of the roc config file to use (default: current-context, or ROC_CONTEXT)")
 
 	cmd.AddCommand(
 		NewLogsCmd(stack),
 		NewConnectCmd(stack),
 		NewInterruptCmd(stack),
+		NewJobsCmd(stack),
 		NewStackCmd(stack),
+		NewContextCmd(),
 		NewLintCmd(),
+		NewSelfUpdateCmd(stack),
 		NewVersionCmd(),
 	)
 
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+type workflowJobsLookupAPI interface {
+	workflowJobsAPI
+	workflowJobsScanAPI
+}
+
+// workflowJobSelector decides which jobs of a workflow run a command operates
+// on when it is given a run instead of a single job.
+type workflowJobSelector struct {
+	// Run looks up a bare numeric ID, or the run of a job URL, as a workflow
+	// run. Without it, only run URLs are looked up as runs, so that a job that
+	// is not in the table yet doesn't scan the table for a run.
+	Run     bool
+	JobName string
+	All     bool
+	// Prompt asks the user to pick one job. It is nil when stdin is not a
+	// terminal, in which case ambiguous runs are reported as an error.
+	Prompt func(jobs []*workflowJobFacts) (*workflowJobFacts, error)
+}
+
+type resolvedWorkflowJobs struct {
+	RunID  int64
+	JobIDs []string
+}
+
+func newWorkflowJobSelector(jobName string, all bool) workflowJobSelector {
+	selector := workflowJobSelector{
+		JobName: strings.TrimSpace(jobName),
+		All:     all,
+	}
+	if isInteractiveInput(os.Stdin) {
+		selector.Prompt = func(jobs []*workflowJobFacts) (*workflowJobFacts, error) {
+			return promptWorkflowJobSelection(os.Stdin, os.Stderr, jobs)
+		}
+	}
+	return selector
+}
+
+func isInteractiveInput(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// isWorkflowRunURL reports whether input is a GitHub Actions run URL that does
+// not point at a specific job.
+func isWorkflowRunURL(input string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(input))
+	if err != nil || parsed.Scheme != "https" {
+		return false
+	}
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	hasRun := false
+	for _, part := range parts {
+		switch part {
+		case "runs":
+			hasRun = true
+		case "job":
+			return false
+		}
+	}
+	return hasRun
+}
+
+// resolveWorkflowJobs turns a job ID, job URL, run ID or run URL into the job
+// IDs a command should operate on. Run URLs, and any reference with
+// selector.Run, are resolved to the jobs of the run. Job IDs and job URLs are
+// returned unchanged, without a lookup, so callers can wait for the job to
+// show up.
+func resolveWorkflowJobs(ctx context.Context, client workflowJobsLookupAPI, tableName, ref string, selector workflowJobSelector) (*resolvedWorkflowJobs, error) {
+	ref = strings.TrimSpace(ref)
+	if !isWorkflowRunRef(ref, selector.Run) {
+		return &resolvedWorkflowJobs{JobIDs: []string{extractJobID(ref)}}, nil
+	}
+
+	runID, jobs, err := resolveWorkflowRun(ctx, client, tableName, ref)
+	if err != nil {
+		return nil, err
+	}
+	return selectWorkflowRunJobs(runID, jobs, selector)
+}
+
+// isWorkflowRunRef reports whether ref is looked up as a run: a run URL, or
+// any reference when run is set.
+func isWorkflowRunRef(ref string, run bool) bool {
+	return run || isWorkflowRunURL(ref)
+}
+
+// resolveWorkflowRun returns the run that ref points at and all its jobs. A
+// bare ID is a run ID; job URLs resolve to the run of the job.
+func resolveWorkflowRun(ctx context.Context, client workflowJobsLookupAPI, tableName, ref string) (int64, []*workflowJobFacts, error) {
+	ref = strings.TrimSpace(ref)
+	runID, err := strconv.ParseInt(extractRunID(ref), 10, 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid run ID %q: %w", ref, err)
+	}
+	jobs, err := listWorkflowRunJobs(ctx, client, tableName, runID)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(jobs) == 0 {
+		return 0, nil, fmt.Errorf("no jobs found for run %d in workflow jobs table", runID)
+	}
+	return runID, jobs, nil
+}
+
+// workflowJobRefExists reports whether the workflow jobs table knows about the
+// job or run that ref points at. Runs are only looked for when ref is a run
+// reference, as the table is scanned for them.
+func workflowJobRefExists(ctx context.Context, client workflowJobsLookupAPI, tableName, ref string, run bool) (bool, error) {
+	ref = strings.TrimSpace(ref)
+	if isWorkflowRunRef(ref, run) {
+		runID, err := strconv.ParseInt(extractRunID(ref), 10, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid run ID %q: %w", ref, err)
+		}
+		jobs, err := listWorkflowRunJobs(ctx, client, tableName, runID)
+		return len(jobs) > 0, err
+	}
+
+	facts, err := findWorkflowJobFacts(ctx, client, tableName, extractJobID(ref))
+	return facts != nil, err
+}
+
+func listWorkflowRunJobs(ctx context.Context, client workflowJobsScanAPI, tableName string, runID int64) ([]*workflowJobFacts, error) {
+	jobs, err := listWorkflowJobs(ctx, client, tableName, workflowJobFilter{RunID: runID})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].JobID < jobs[j].JobID
+	})
+	return jobs, nil
+}
+
+func selectWorkflowRunJobs(runID int64, jobs []*workflowJobFacts, selector workflowJobSelector) (*resolvedWorkflowJobs, error) {
+	candidates := jobs
+	if selector.JobName != "" {
+		candidates = nil
+		for _, job := range jobs {
+			if strings.EqualFold(strings.TrimSpace(job.JobName), selector.JobName) {
+				candidates = append(candidates, job)
+			}
+		}
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("no job named %q in run %d. Available jobs:\n%s", selector.JobName, runID, describeWorkflowRunJobs(jobs))
+		}
+	}
+
+	resolved := &resolvedWorkflowJobs{RunID: runID}
+	switch {
+	case selector.All || len(candidates) == 1:
+		for _, job := range candidates {
+			resolved.JobIDs = append(resolved.JobIDs, strconv.FormatInt(job.JobID, 10))
+		}
+		return resolved, nil
+	case selector.Prompt != nil:
+		job, err := selector.Prompt(candidates)
+		if err != nil {
+			return nil, err
+		}
+		resolved.JobIDs = []string{strconv.FormatInt(job.JobID, 10)}
+		return resolved, nil
+	default:
+		return nil, fmt.Errorf("run %d has %d jobs; select one with --job-name or a job URL. Available jobs:\n%s", runID, len(candidates), describeWorkflowRunJobs(candidates))
+	}
+}
+
+func describeWorkflowRunJobs(jobs []*workflowJobFacts) string {
+	var builder strings.Builder
+	writer := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	for i, job := range jobs {
+		name := job.JobName
+		if name == "" {
+			name = "-"
+		}
+		status := job.Status
+		if status == "" {
+			status = "-"
+		}
+		fmt.Fprintf(writer, "  %d)\t%d\t%s\t%s\n", i+1, job.JobID, name, status)
+	}
+	_ = writer.Flush()
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+func promptWorkflowJobSelection(in io.Reader, out io.Writer, jobs []*workflowJobFacts) (*workflowJobFacts, error) {
+	fmt.Fprintf(out, "This run has %d jobs:\n%s\n", len(jobs), describeWorkflowRunJobs(jobs))
+	reader := bufio.NewReader(in)
+	for {
+		fmt.Fprintf(out, "Select a job [1-%d]: ", len(jobs))
+		line, err := reader.ReadString('\n')
+		if choice, convErr := strconv.Atoi(strings.TrimSpace(line)); convErr == nil && choice >= 1 && choice <= len(jobs) {
+			return jobs[choice-1], nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("no job selected")
+		}
+		fmt.Fprintf(out, "Invalid choice %q\n", strings.TrimSpace(line))
+	}
+}
@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type mockWorkflowJobsLookupClient struct {
+	records []workflowJobFactsRecord
+	t       *testing.T
+	scans   int
+}
+
+func (m *mockWorkflowJobsLookupClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	key := params.Key["job_id"].(*dynamodbtypes.AttributeValueMemberN).Value
+	for _, record := range m.records {
+		if strconv.FormatInt(record.JobID, 10) == key {
+			return &dynamodb.GetItemOutput{Item: marshalWorkflowJobItem(m.t, record)}, nil
+		}
+	}
+	return &dynamodb.GetItemOutput{}, nil
+}
+
+func (m *mockWorkflowJobsLookupClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	m.scans++
+	runID := params.ExpressionAttributeValues[":run_id"].(*dynamodbtypes.AttributeValueMemberN).Value
+	output := &dynamodb.ScanOutput{}
+	for _, record := range m.records {
+		if strconv.FormatInt(record.RunID, 10) == runID {
+			output.Items = append(output.Items, marshalWorkflowJobItem(m.t, record))
+		}
+	}
+	return output, nil
+}
+
+func newRunLookupClient(t *testing.T) *mockWorkflowJobsLookupClient {
+	return &mockWorkflowJobsLookupClient{
+		t: t,
+		records: []workflowJobFactsRecord{
+			{JobID: 12, RunID: 100, JobName: "test (linux)", Status: "completed"},
+			{JobID: 11, RunID: 100, JobName: "build", Status: "in_progress"},
+			{JobID: 13, RunID: 100, JobName: "test (windows)", Status: "queued"},
+			{JobID: 21, RunID: 200, JobName: "lint", Status: "completed"},
+		},
+	}
+}
+
+func TestIsWorkflowRunURL(t *testing.T) {
+	for input, want := range map[string]bool{
+		"https://github.com/runs-on/runs-on/actions/runs/100":             true,
+		"https://github.com/runs-on/runs-on/actions/runs/100/attempts/2":  true,
+		"https://github.com/runs-on/runs-on/actions/runs/100/job/12":      false,
+		"https://github.com/runs-on/runs-on/actions/runs/100/job/12?pr=1": false,
+		"100": false,
+	} {
+		if got := isWorkflowRunURL(input); got != want {
+			t.Fatalf("isWorkflowRunURL(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestResolveWorkflowJobsKeepsJobReferences(t *testing.T) {
+	client := newRunLookupClient(t)
+
+	resolved, err := resolveWorkflowJobs(context.Background(), client, "workflow-jobs", "https://github.com/runs-on/runs-on/actions/runs/100/job/12", workflowJobSelector{})
+	if err != nil {
+		t.Fatalf("resolveWorkflowJobs returned error: %v", err)
+	}
+	if strings.Join(resolved.JobIDs, ",") != "12" || client.scans != 0 {
+		t.Fatalf("expected job URL to resolve without scanning, got %+v (scans=%d)", resolved, client.scans)
+	}
+
+	// Bare IDs are jobs, even when they are the ID of a run or of nothing
+	// known yet: runs are only looked up with --run.
+	for _, ref := range []string{"21", "100", "999"} {
+		resolved, err = resolveWorkflowJobs(context.Background(), client, "workflow-jobs", ref, workflowJobSelector{})
+		if err != nil {
+			t.Fatalf("resolveWorkflowJobs returned error: %v", err)
+		}
+		if strings.Join(resolved.JobIDs, ",") != ref || client.scans != 0 {
+			t.Fatalf("expected %s to be returned unchanged without scanning, got %+v (scans=%d)", ref, resolved, client.scans)
+		}
+	}
+}
+
+func TestResolveWorkflowJobsSelectsFromRun(t *testing.T) {
+	client := newRunLookupClient(t)
+
+	resolved, err := resolveWorkflowJobs(context.Background(), client, "workflow-jobs", "https://github.com/runs-on/runs-on/actions/runs/100", workflowJobSelector{All: true})
+	if err != nil {
+		t.Fatalf("resolveWorkflowJobs returned error: %v", err)
+	}
+	if got := strings.Join(resolved.JobIDs, ","); got != "11,12,13" || resolved.RunID != 100 {
+		t.Fatalf("expected every job of run 100 sorted by ID, got %q (run %d)", got, resolved.RunID)
+	}
+
+	resolved, err = resolveWorkflowJobs(context.Background(), client, "workflow-jobs", "100", workflowJobSelector{Run: true, JobName: "Test (Windows)"})
+	if err != nil {
+		t.Fatalf("resolveWorkflowJobs returned error: %v", err)
+	}
+	if got := strings.Join(resolved.JobIDs, ","); got != "13" {
+		t.Fatalf("expected --job-name to select job 13, got %q", got)
+	}
+
+	var prompted []int64
+	resolved, err = resolveWorkflowJobs(context.Background(), client, "workflow-jobs", "100", workflowJobSelector{
+		Run: true,
+		Prompt: func(jobs []*workflowJobFacts) (*workflowJobFacts, error) {
+			for _, job := range jobs {
+				prompted = append(prompted, job.JobID)
+			}
+			return jobs[1], nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("resolveWorkflowJobs returned error: %v", err)
+	}
+	if len(prompted) != 3 || strings.Join(resolved.JobIDs, ",") != "12" {
+		t.Fatalf("expected prompt to pick job 12 out of 3, got %+v (prompted %v)", resolved, prompted)
+	}
+
+	resolved, err = resolveWorkflowJobs(context.Background(), client, "workflow-jobs", "https://github.com/runs-on/runs-on/actions/runs/100/job/12", workflowJobSelector{Run: true, All: true})
+	if err != nil {
+		t.Fatalf("resolveWorkflowJobs returned error: %v", err)
+	}
+	if got := strings.Join(resolved.JobIDs, ","); got != "11,12,13" {
+		t.Fatalf("expected --run to look up the run of a job URL, got %q", got)
+	}
+
+	resolved, err = resolveWorkflowJobs(context.Background(), client, "workflow-jobs", "200", workflowJobSelector{Run: true})
+	if err != nil {
+		t.Fatalf("resolveWorkflowJobs returned error: %v", err)
+	}
+	if got := strings.Join(resolved.JobIDs, ","); got != "21" {
+		t.Fatalf("expected single-job run to resolve without selection, got %q", got)
+	}
+}
+
+func TestResolveWorkflowRun(t *testing.T) {
+	client := newRunLookupClient(t)
+	for _, ref := range []string{"100", "https://github.com/runs-on/runs-on/actions/runs/100/job/12", "https://github.com/runs-on/runs-on/actions/runs/100"} {
+		runID, jobs, err := resolveWorkflowRun(context.Background(), client, "workflow-jobs", ref)
+		if err != nil {
+			t.Fatalf("%s: resolveWorkflowRun returned error: %v", ref, err)
+		}
+		if runID != 100 || len(jobs) != 3 || jobs[0].JobID != 11 {
+			t.Fatalf("%s: expected the 3 jobs of run 100, got run %d with %d jobs", ref, runID, len(jobs))
+		}
+	}
+
+	if _, _, err := resolveWorkflowRun(context.Background(), client, "workflow-jobs", "999"); err == nil || !strings.Contains(err.Error(), "no jobs found for run 999") {
+		t.Fatalf("expected an unknown run to fail, got %v", err)
+	}
+}
+
+func TestResolveWorkflowJobsReportsAmbiguousRuns(t *testing.T) {
+	client := newRunLookupClient(t)
+
+	_, err := resolveWorkflowJobs(context.Background(), client, "workflow-jobs", "100", workflowJobSelector{Run: true})
+	if err == nil || !strings.Contains(err.Error(), "--job-name") || !strings.Contains(err.Error(), "test (windows)") {
+		t.Fatalf("expected ambiguous run error listing jobs, got %v", err)
+	}
+
+	_, err = resolveWorkflowJobs(context.Background(), client, "workflow-jobs", "100", workflowJobSelector{Run: true, JobName: "deploy"})
+	if err == nil || !strings.Contains(err.Error(), `no job named "deploy"`) {
+		t.Fatalf("expected unknown job name error, got %v", err)
+	}
+
+	_, err = resolveWorkflowJobs(context.Background(), client, "workflow-jobs", "https://github.com/runs-on/runs-on/actions/runs/300", workflowJobSelector{All: true})
+	if err == nil || !strings.Contains(err.Error(), "no jobs found for run 300") {
+		t.Fatalf("expected missing run error, got %v", err)
+	}
+}
+
+func TestPromptWorkflowJobSelectionRetriesInvalidChoices(t *testing.T) {
+	jobs := []*workflowJobFacts{{JobID: 11, JobName: "build"}, {JobID: 12, JobName: "test"}}
+	var output bytes.Buffer
+
+	job, err := promptWorkflowJobSelection(strings.NewReader("7\nabc\n2\n"), &output, jobs)
+	if err != nil {
+		t.Fatalf("promptWorkflowJobSelection returned error: %v", err)
+	}
+	if job.JobID != 12 {
+		t.Fatalf("expected job 12, got %d", job.JobID)
+	}
+	if strings.Count(output.String(), "Invalid choice") != 2 {
+		t.Fatalf("expected two invalid choices to be reported:\n%s", output.String())
+	}
+
+	if _, err := promptWorkflowJobSelection(strings.NewReader(""), &output, jobs); err == nil {
+		t.Fatal("expected prompt to fail when input is closed")
+	}
+}
@@ -0,0 +1,312 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"roc/internal/version"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultReleaseURL hosts the roc releases. ROC_RELEASE_URL overrides it, e.g.
+// for a mirror.
+const defaultReleaseURL = "https://github.com/runs-on/cli/releases"
+
+// errReleaseAssetNotFound is returned when a release doesn't have an asset.
+var errReleaseAssetNotFound = errors.New("HTTP 404")
+
+// selfUpdateRecord is the --output json|yaml result of self-update.
+type selfUpdateRecord struct {
+	Version string `json:"version"`
+	Path    string `json:"path"`
+	Updated bool   `json:"updated"`
+}
+
+// selfUpdater downloads a roc release and replaces the running binary with
+// it. Releases are laid out like GitHub releases:
+//
+//	<base>/latest                                -> redirects to <base>/tag/<version>
+//	<base>/download/<version>/roc_<version>_<os>_<arch>
+//	<base>/download/<version>/roc_<version>_checksums.txt
+type selfUpdater struct {
+	baseURL    string
+	httpClient *http.Client
+	goos       string
+	goarch     string
+	executable string
+	out        io.Writer
+	// skipVerify installs releases published without a checksums file.
+	skipVerify bool
+}
+
+func newSelfUpdater(out io.Writer) (*selfUpdater, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("locate roc binary: %w", err)
+	}
+	executable, err = filepath.EvalSymlinks(executable)
+	if err != nil {
+		return nil, fmt.Errorf("locate roc binary: %w", err)
+	}
+
+	baseURL := strings.TrimSpace(os.Getenv("ROC_RELEASE_URL"))
+	if baseURL == "" {
+		baseURL = defaultReleaseURL
+	}
+	return &selfUpdater{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+		goos:       runtime.GOOS,
+		goarch:     runtime.GOARCH,
+		executable: executable,
+		out:        out,
+	}, nil
+}
+
+func normalizeReleaseVersion(release string) string {
+	release = strings.TrimSpace(release)
+	if release == "" {
+		return ""
+	}
+	return "v" + strings.TrimPrefix(release, "v")
+}
+
+func (u *selfUpdater) assetName(release string) string {
+	name := fmt.Sprintf("roc_%s_%s_%s", release, u.goos, u.goarch)
+	if u.goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// latestRelease returns the version the latest release redirects to.
+func (u *selfUpdater) latestRelease(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.baseURL+"/latest", nil)
+	if err != nil {
+		return "", err
+	}
+	client := *u.httpClient
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("find latest roc release: %w", err)
+	}
+	resp.Body.Close()
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return "", fmt.Errorf("find latest roc release: %s returned HTTP %d without a redirect", req.URL, resp.StatusCode)
+	}
+	release := path.Base(strings.TrimRight(location, "/"))
+	if release == "" || release == "." || release == "/" {
+		return "", fmt.Errorf("find latest roc release: unexpected redirect to %s", location)
+	}
+	return normalizeReleaseVersion(release), nil
+}
+
+func (u *selfUpdater) download(ctx context.Context, release, name string) ([]byte, error) {
+	url := fmt.Sprintf("%s/download/%s/%s", u.baseURL, release, name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("download %s: %w", url, errReleaseAssetNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download %s: HTTP %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", url, err)
+	}
+	return data, nil
+}
+
+// releaseChecksumsAsset is the name of the sha256sum file `make release`
+// publishes next to the binaries of a release.
+func releaseChecksumsAsset(release string) string {
+	return fmt.Sprintf("roc_%s_checksums.txt", release)
+}
+
+// releaseChecksum returns the SHA-256 of asset listed in a checksums file in
+// the sha256sum format.
+func releaseChecksum(checksums []byte, asset string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no checksum listed for %s", asset)
+}
+
+// update installs the given release in place of the running binary.
+func (u *selfUpdater) update(ctx context.Context, release string) error {
+	asset := u.assetName(release)
+	fmt.Fprintf(u.out, "Downloading roc %s (%s)...\n", release, asset)
+	binary, err := u.download(ctx, release, asset)
+	if err != nil {
+		return err
+	}
+	checksumsAsset := releaseChecksumsAsset(release)
+	checksums, err := u.download(ctx, release, checksumsAsset)
+	switch {
+	case errors.Is(err, errReleaseAssetNotFound):
+		// Releases made before `make release` wrote the checksums file
+		// can only be installed unverified, which takes --skip-verify.
+		if !u.skipVerify {
+			return fmt.Errorf("%s is not published with roc %s, so the download can't be verified; use --skip-verify to install it anyway", checksumsAsset, release)
+		}
+		fmt.Fprintf(u.out, "Warning: %s is not published with roc %s, so the download can't be verified\n", checksumsAsset, release)
+	case err != nil:
+		return err
+	default:
+		want, err := releaseChecksum(checksums, asset)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(binary)
+		if got := hex.EncodeToString(sum[:]); got != want {
+			return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", asset, want, got)
+		}
+	}
+
+	if err := replaceExecutable(u.executable, binary); err != nil {
+		return err
+	}
+	fmt.Fprintf(u.out, "Updated %s to roc %s\n", u.executable, release)
+	return nil
+}
+
+// replaceExecutable swaps the binary at path for data. The new binary is
+// written next to the old one and renamed over it, so path always holds a
+// complete binary.
+func replaceExecutable(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, ".roc-update-*")
+	if err != nil {
+		return fmt.Errorf("write new roc binary: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write new roc binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write new roc binary: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return fmt.Errorf("write new roc binary: %w", err)
+	}
+
+	// Windows can't replace a running executable, but it can rename it.
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return fmt.Errorf("replace roc binary: %w", err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replace roc binary: %w", err)
+	}
+	return nil
+}
+
+func NewSelfUpdateCmd(stack *Stack) *cobra.Command {
+	var (
+		release    string
+		matchStack bool
+		skipVerify bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "self-update",
+		Short: "Replace roc with another release",
+		Long: `Download a roc release and replace the running binary with it.
+
+Without flags, the latest release is installed. --version installs a given
+release, and --match-stack installs the release matching the RunsOn version of
+the selected stack (the app_tag of its /readyz endpoint).
+
+The downloaded binary is verified against the checksums published with the
+release before it replaces the current one. Releases published without
+checksums are only installed with --skip-verify. Releases are downloaded from
+` + defaultReleaseURL + `, or from ROC_RELEASE_URL when set.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			progress := cmd.OutOrStdout()
+			if structuredOutput(cmd) {
+				progress = cmd.ErrOrStderr()
+			}
+			updater, err := newSelfUpdater(progress)
+			if err != nil {
+				return err
+			}
+			updater.skipVerify = skipVerify
+
+			release = normalizeReleaseVersion(release)
+			switch {
+			case matchStack:
+				config, err := stack.discoverResources(cmd)
+				if err != nil {
+					return err
+				}
+				appTag, err := fetchStackAppTag(ctx, &http.Client{Timeout: 10 * time.Second}, config.IngressURL)
+				if err != nil {
+					return fmt.Errorf("read the RunsOn version of stack %s: %w", config.label(), err)
+				}
+				release = normalizeReleaseVersion(appTag)
+			case release == "":
+				release, err = updater.latestRelease(ctx)
+				if err != nil {
+					return err
+				}
+			}
+
+			updated := release != normalizeReleaseVersion(version.String())
+			if updated {
+				if err := updater.update(ctx, release); err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprintf(progress, "roc %s is already installed\n", release)
+			}
+			if structuredOutput(cmd) {
+				return writeStructured(cmd.OutOrStdout(), outputFormat(cmd), selfUpdateRecord{Version: release, Path: updater.executable, Updated: updated})
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&release, "version", "", "Release to install (e.g. v3.0.7; default: the latest release)")
+	cmd.Flags().BoolVar(&matchStack, "match-stack", false, "Install the release matching the RunsOn version of the stack")
+	cmd.Flags().BoolVar(&skipVerify, "skip-verify", false, "Install a release published without a checksums file, without verifying the download")
+	cmd.MarkFlagsMutuallyExclusive("version", "match-stack")
+
+	return cmd
+}
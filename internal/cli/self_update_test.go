@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestReleaseServer(t *testing.T, binary []byte, checksum string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/releases/tag/v9.1.0", http.StatusFound)
+	})
+	mux.HandleFunc("/releases/download/v9.1.0/roc_v9.1.0_linux_arm64", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(binary)
+	})
+	mux.HandleFunc("/releases/download/v9.1.0/roc_v9.1.0_checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "0000  roc_v9.1.0_darwin_arm64\n%s  roc_v9.1.0_linux_arm64\n", checksum)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestSelfUpdater(t *testing.T, server *httptest.Server) *selfUpdater {
+	t.Helper()
+	executable := filepath.Join(t.TempDir(), "roc")
+	if err := os.WriteFile(executable, []byte("old roc"), 0755); err != nil {
+		t.Fatalf("write executable: %v", err)
+	}
+	return &selfUpdater{
+		baseURL:    server.URL + "/releases",
+		httpClient: server.Client(),
+		goos:       "linux",
+		goarch:     "arm64",
+		executable: executable,
+		out:        &bytes.Buffer{},
+	}
+}
+
+func TestSelfUpdaterInstallsVerifiedRelease(t *testing.T) {
+	binary := []byte("new roc")
+	sum := sha256.Sum256(binary)
+	updater := newTestSelfUpdater(t, newTestReleaseServer(t, binary, hex.EncodeToString(sum[:])))
+
+	release, err := updater.latestRelease(context.Background())
+	if err != nil || release != "v9.1.0" {
+		t.Fatalf("expected latest release v9.1.0, got %q (%v)", release, err)
+	}
+	if err := updater.update(context.Background(), release); err != nil {
+		t.Fatalf("update returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(updater.executable)
+	if err != nil || string(data) != "new roc" {
+		t.Fatalf("expected the binary to be replaced, got %q (%v)", data, err)
+	}
+	info, err := os.Stat(updater.executable)
+	if err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Fatalf("expected the new binary to be executable, got %v (%v)", info.Mode(), err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(updater.executable)); len(entries) != 1 {
+		t.Fatalf("expected no leftover temporary files, got %d entries", len(entries))
+	}
+}
+
+func TestSelfUpdaterRejectsChecksumMismatch(t *testing.T) {
+	updater := newTestSelfUpdater(t, newTestReleaseServer(t, []byte("tampered roc"), strings.Repeat("ab", 32)))
+
+	err := updater.update(context.Background(), "v9.1.0")
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch for roc_v9.1.0_linux_arm64") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+	if data, _ := os.ReadFile(updater.executable); string(data) != "old roc" {
+		t.Fatalf("expected the binary to be left untouched, got %q", data)
+	}
+
+	if err := updater.update(context.Background(), "v9.2.0"); err == nil || !strings.Contains(err.Error(), "HTTP 404") {
+		t.Fatalf("expected missing release error, got %v", err)
+	}
+}
+
+func TestSelfUpdaterInstallsReleasesWithoutChecksumsOnlyWithSkipVerify(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/releases/download/v2.12.5/roc_v2.12.5_linux_arm64", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "older roc")
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	updater := newTestSelfUpdater(t, server)
+
+	if err := updater.update(context.Background(), "v2.12.5"); err == nil || !strings.Contains(err.Error(), "use --skip-verify") {
+		t.Fatalf("expected the unverified release to be refused, got %v", err)
+	}
+	if data, _ := os.ReadFile(updater.executable); string(data) == "older roc" {
+		t.Fatal("expected the binary to be left untouched without --skip-verify")
+	}
+
+	updater.skipVerify = true
+	if err := updater.update(context.Background(), "v2.12.5"); err != nil {
+		t.Fatalf("update returned error: %v", err)
+	}
+	if data, _ := os.ReadFile(updater.executable); string(data) != "older roc" {
+		t.Fatalf("expected the binary to be replaced, got %q", data)
+	}
+	if out := updater.out.(*bytes.Buffer).String(); !strings.Contains(out, "Warning: roc_v2.12.5_checksums.txt is not published with roc v2.12.5") {
+		t.Fatalf("expected a warning about the unverified download, got %q", out)
+	}
+}
+
+// TestReleaseProcessPublishesSelfUpdateAssets checks that `make release` and
+// install.sh use the asset names self-update downloads.
+func TestReleaseProcessPublishesSelfUpdateAssets(t *testing.T) {
+	makefile, err := os.ReadFile("../../Makefile")
+	if err != nil {
+		t.Fatalf("read Makefile: %v", err)
+	}
+	for _, want := range []string{"-o dist/release/roc_$(VERSION)_$${os}_$${arch}$$ext", "> " + releaseChecksumsAsset("$(VERSION)")} {
+		if !strings.Contains(string(makefile), want) {
+			t.Fatalf("expected the release target to write %q", want)
+		}
+	}
+
+	installScript, err := os.ReadFile("../../install.sh")
+	if err != nil {
+		t.Fatalf("read install.sh: %v", err)
+	}
+	for _, want := range []string{`BINARY_NAME="roc_${VERSION}_${OS}_${ARCH}"`, `CHECKSUMS_NAME="` + releaseChecksumsAsset("${VERSION}") + `"`} {
+		if !strings.Contains(string(installScript), want) {
+			t.Fatalf("expected install.sh to download %q", want)
+		}
+	}
+}
+
+func TestReleaseChecksumAndAssetNames(t *testing.T) {
+	checksums := []byte("abc123  roc_v3.0.7_linux_amd64\nDEF456 *roc_v3.0.7_windows_amd64.exe\n")
+	if got, err := releaseChecksum(checksums, "roc_v3.0.7_windows_amd64.exe"); err != nil || got != "def456" {
+		t.Fatalf("unexpected checksum %q (%v)", got, err)
+	}
+	if _, err := releaseChecksum(checksums, "roc_v3.0.7_darwin_arm64"); err == nil {
+		t.Fatal("expected missing checksum error")
+	}
+
+	updater := &selfUpdater{goos: "windows", goarch: "amd64"}
+	if got := updater.assetName("v3.0.7"); got != "roc_v3.0.7_windows_amd64.exe" {
+		t.Fatalf("unexpected asset name %q", got)
+	}
+	if got := normalizeReleaseVersion("3.0.7"); got != "v3.0.7" {
+		t.Fatalf("unexpected normalized version %q", got)
+	}
+}
@@ -1,22 +1,272 @@
 package cli
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/spf13/cobra"
 )
 
 type Stack struct {
 	cfg aws.Config
+	// loadAWSConfig reloads the AWS config with the profile and region of the
+	// selected roc context. It is nil in tests.
+	loadAWSConfig AWSConfigLoader
+}
+
+// AWSConfigLoader loads the AWS SDK config with additional load options.
+type AWSConfigLoader func(ctx context.Context, optFns ...func(*awsconfig.LoadOptions) error) (aws.Config, error)
+
+// stackTarget is one stack/region pair selected with --stack and --regions.
+type stackTarget struct {
+	StackName string
+	Region    string
+}
+
+func (t stackTarget) String() string {
+	if t.Region == "" {
+		return t.StackName
+	}
+	return t.StackName + "/" + t.Region
+}
+
+// stackTargetConfig is the outcome of loading the stack config of one target.
+type stackTargetConfig struct {
+	Target stackTarget
+	Config *RunsOnConfig
+	Err    error
+}
+
+// getJobStackOutputs returns the config of the stack that tracks the given job
+// or run. With several stacks or regions selected, each one is searched in
+// order until the job is found.
+func (s *Stack) getJobStackOutputs(cmd *cobra.Command, ref string) (*RunsOnConfig, error) {
+	return s.jobStackOutputs(cmd, ref, false)
+}
+
+// getSingleJobStackOutputs is getJobStackOutputs for commands that change the
+// state of the stack: every selected stack is searched, and the job or run
+// must be found in exactly one of them.
+func (s *Stack) getSingleJobStackOutputs(cmd *cobra.Command, ref string) (*RunsOnConfig, error) {
+	return s.jobStackOutputs(cmd, ref, true)
+}
+
+func (s *Stack) jobStackOutputs(cmd *cobra.Command, ref string, single bool) (*RunsOnConfig, error) {
+	results, err := s.loadStackTargets(cmd)
+	if err != nil {
+		return nil, err
+	}
+	configs, err := usableStackConfigs(results, cmd.ErrOrStderr())
+	if err != nil {
+		return nil, err
+	}
+	config := configs[0]
+	if len(configs) > 1 {
+		// Commands that take runs by ID have a --run flag.
+		run, _ := cmd.Flags().GetBool("run")
+		config, err = findWorkflowJobStack(cmd.Context(), configs, func(config *RunsOnConfig) workflowJobsLookupAPI {
+			return dynamodb.NewFromConfig(config.AWSConfig)
+		}, ref, run, single)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := checkStackVersions(cmd, []*RunsOnConfig{config}); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// getAllStackOutputs loads the config of every selected stack/region pair.
+// Targets that fail to load are reported as warnings on stderr; an error is
+// only returned when none of them could be loaded.
+func (s *Stack) getAllStackOutputs(cmd *cobra.Command) ([]*RunsOnConfig, error) {
+	results, err := s.loadStackTargets(cmd)
+	if err != nil {
+		return nil, err
+	}
+	configs, err := usableStackConfigs(results, cmd.ErrOrStderr())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStackVersions(cmd, configs); err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+func (s *Stack) stackTargets(cmd *cobra.Command) ([]stackTarget, error) {
+	stackNames, err := cmd.Flags().GetStringSlice("stack")
+	if err != nil {
+		return nil, err
+	}
+	regions, err := cmd.Flags().GetStringSlice("regions")
+	if err != nil {
+		return nil, err
+	}
+	return expandStackTargets(stackNames, regions, s.cfg.Region)
+}
+
+// expandStackTargets returns every stack/region pair, in flag order. When no
+// region is given, the region of the AWS config is used.
+func expandStackTargets(stackNames, regions []string, defaultRegion string) ([]stackTarget, error) {
+	stackNames = uniqueNonEmpty(stackNames)
+	if len(stackNames) == 0 {
+		return nil, fmt.Errorf("stack name is required")
+	}
+	regions = uniqueNonEmpty(regions)
+	if len(regions) == 0 {
+		regions = []string{strings.TrimSpace(defaultRegion)}
+	}
+
+	targets := make([]stackTarget, 0, len(stackNames)*len(regions))
+	for _, stackName := range stackNames {
+		for _, region := range regions {
+			targets = append(targets, stackTarget{StackName: stackName, Region: region})
+		}
+	}
+	return targets, nil
+}
+
+func uniqueNonEmpty(values []string) []string {
+	var unique []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value != "" && !slices.Contains(unique, value) {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+func (s *Stack) loadStackTargets(cmd *cobra.Command) ([]stackTargetConfig, error) {
+	targets, err := s.stackTargets(cmd)
+	if err != nil {
+		return nil, err
+	}
+	refresh := refreshStackConfig(cmd)
+	return loadStackTargetConfigs(cmd.Context(), targets, func(ctx context.Context, target stackTarget) (*RunsOnConfig, error) {
+		return s.loadStackTarget(ctx, target, refresh)
+	}), nil
+}
+
+// loadStackTargetConfigs loads every target concurrently and returns the
+// results in target order.
+func loadStackTargetConfigs(ctx context.Context, targets []stackTarget, load func(context.Context, stackTarget) (*RunsOnConfig, error)) []stackTargetConfig {
+	results := make([]stackTargetConfig, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Go(func() {
+			config, err := load(ctx, target)
+			results[i] = stackTargetConfig{Target: target, Config: config, Err: err}
+		})
+	}
+	wg.Wait()
+	return results
 }
 
-// getStackOutputs loads the stable stack metadata that roc needs from the
-// standard stack config secret for the selected stack.
-func (s *Stack) getStackOutputs(cmd *cobra.Command) (*RunsOnConfig, error) {
-	return s.discoverResources(cmd)
+// usableStackConfigs drops the targets that failed to load, warning about each
+// of them. A single selected target keeps its original error.
+func usableStackConfigs(results []stackTargetConfig, warnings io.Writer) ([]*RunsOnConfig, error) {
+	if len(results) == 1 {
+		if results[0].Err != nil {
+			return nil, results[0].Err
+		}
+		return []*RunsOnConfig{results[0].Config}, nil
+	}
+
+	var configs []*RunsOnConfig
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Fprintf(warnings, "Warning: skipping %s: %v\n", result.Target, result.Err)
+			continue
+		}
+		configs = append(configs, result.Config)
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("none of the %d selected stacks could be loaded", len(results))
+	}
+	return configs, nil
 }
 
-func NewStack(cfg aws.Config) *Stack {
-	return &Stack{cfg: cfg}
+// findWorkflowJobStack returns the first config whose workflow jobs table
+// knows about the job or run. With single, every config is searched and a
+// job or run known to more than one stack is an error.
+func findWorkflowJobStack(ctx context.Context, configs []*RunsOnConfig, clientFor func(*RunsOnConfig) workflowJobsLookupAPI, ref string, run, single bool) (*RunsOnConfig, error) {
+	var searched, matched []string
+	var match *RunsOnConfig
+	for _, config := range configs {
+		if config.validateJobLookup() != nil {
+			continue
+		}
+		searched = append(searched, config.label())
+		found, err := workflowJobRefExists(ctx, clientFor(config), config.WorkflowJobsTable, ref, run)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", config.label(), err)
+		}
+		if !found {
+			continue
+		}
+		if !single {
+			return config, nil
+		}
+		if match == nil {
+			match = config
+		}
+		matched = append(matched, config.label())
+	}
+	if len(matched) > 1 {
+		return nil, fmt.Errorf("job %s found in several stacks (%s). Select one with --stack and --regions", extractJobID(ref), strings.Join(matched, ", "))
+	}
+	if match != nil {
+		return match, nil
+	}
+	if len(searched) == 0 {
+		return nil, fmt.Errorf("none of the selected stacks has a workflow jobs table")
+	}
+	return nil, fmt.Errorf("job %s not found in stacks %s. Select a single stack with --stack to wait for it", extractJobID(ref), strings.Join(searched, ", "))
+}
+
+func NewStack(cfg aws.Config, loadAWSConfig AWSConfigLoader) *Stack {
+	return &Stack{cfg: cfg, loadAWSConfig: loadAWSConfig}
+}
+
+// applyContext applies the selected roc context, if any, to the command flags
+// and the AWS config. AWS_PROFILE and AWS_REGION/AWS_DEFAULT_REGION take
+// precedence over the profile and region of the context.
+func (s *Stack) applyContext(cmd *cobra.Command) error {
+	_, selected, err := selectedContext(cmd)
+	if err != nil || selected == nil {
+		return err
+	}
+	if err := applyContextFlagDefaults(cmd, selected); err != nil {
+		return err
+	}
+
+	var optFns []func(*awsconfig.LoadOptions) error
+	if selected.Profile != "" && os.Getenv("AWS_PROFILE") == "" {
+		optFns = append(optFns, awsconfig.WithSharedConfigProfile(selected.Profile))
+	}
+	if selected.Region != "" && os.Getenv("AWS_REGION") == "" && os.Getenv("AWS_DEFAULT_REGION") == "" {
+		optFns = append(optFns, awsconfig.WithRegion(selected.Region))
+	}
+	if len(optFns) == 0 || s.loadAWSConfig == nil {
+		return nil
+	}
+	cfg, err := s.loadAWSConfig(cmd.Context(), optFns...)
+	if err != nil {
+		return fmt.Errorf("load AWS config for context: %w", err)
+	}
+	s.cfg = cfg
+	return nil
 }
 
 func NewStackCmd(stack *Stack) *cobra.Command {
@@ -29,12 +279,16 @@ These commands operate on your deployed RunsOn CloudFormation stack to help you
 manage, monitor, and troubleshoot your RunsOn infrastructure.
 
 The stack name can be specified using the --stack flag or by setting the
-RUNS_ON_STACK_NAME environment variable (defaults to "runs-on").`,
+RUNS_ON_STACK_NAME environment variable (defaults to "runs-on"). Read-only
+commands such as doctor and logs accept several stacks (--stack a,b) and
+regions (--regions us-east-1,eu-west-1) and run against every pair.`,
 	}
 
 	cmd.AddCommand(
 		NewDoctorCmd(stack),
+		NewStackInfoCmd(stack),
 		NewStackLogsCmd(stack),
+		NewStackQueryCmd(stack),
 	)
 
 	return cmd
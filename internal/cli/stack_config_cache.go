@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// stackConfigCacheTTL is how long a cached stack config is used before it is
+// discovered again.
+const stackConfigCacheTTL = time.Hour
+
+type callerIdentityAPI interface {
+	GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+}
+
+// stackConfigCache keeps the discovered stack config of each
+// account/region/stack on disk, so that commands don't need
+// secretsmanager:GetSecretValue on every run.
+type stackConfigCache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+type stackConfigCacheEntry struct {
+	FetchedAt time.Time              `json:"fetched_at"`
+	Value     stackConfigSecretValue `json:"value"`
+	Sources   map[string]string      `json:"sources,omitempty"`
+}
+
+// stackAppTagCacheEntry is the app_tag of the last successful readiness
+// check of a stack. Failed checks are not cached, so they are made again by
+// the next command.
+type stackAppTagCacheEntry struct {
+	CheckedAt time.Time `json:"checked_at"`
+	AppTag    string    `json:"app_tag"`
+}
+
+// newStackConfigCache returns the cache under the user cache directory
+// (ROC_CACHE_DIR overrides it), or nil when there is no usable directory.
+func newStackConfigCache() *stackConfigCache {
+	dir := strings.TrimSpace(os.Getenv("ROC_CACHE_DIR"))
+	if dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil
+		}
+		dir = filepath.Join(userCacheDir, "roc")
+	}
+	return &stackConfigCache{
+		dir: filepath.Join(dir, "stack-config"),
+		ttl: stackConfigCacheTTL,
+		now: time.Now,
+	}
+}
+
+func (c *stackConfigCache) path(accountID, region, stackName string) string {
+	return filepath.Join(c.dir, url.PathEscape(accountID), url.PathEscape(region), url.PathEscape(stackName)+".json")
+}
+
+// appTagPath is the path of the readiness check of a stack, next to its
+// config. Stack names can't contain dots, so it never clashes with a config.
+func (c *stackConfigCache) appTagPath(accountID, region, stackName string) string {
+	return filepath.Join(c.dir, url.PathEscape(accountID), url.PathEscape(region), url.PathEscape(stackName)+".app-tag.json")
+}
+
+func (c *stackConfigCache) fresh(at time.Time) bool {
+	age := c.now().Sub(at)
+	return age >= 0 && age < c.ttl
+}
+
+// get returns the cached stack config when it is younger than the TTL.
+// Unreadable entries are treated as missing.
+func (c *stackConfigCache) get(accountID, region, stackName string) (*discoveredStackConfig, bool) {
+	data, err := os.ReadFile(c.path(accountID, region, stackName))
+	if err != nil {
+		return nil, false
+	}
+	var entry stackConfigCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	if !c.fresh(entry.FetchedAt) {
+		return nil, false
+	}
+	if entry.Sources == nil {
+		// Entries written before sources were recorded only ever came from
+		// the stack config secret.
+		discovered := newDiscoveredStackConfig()
+		discovered.merge(entry.Value, configSourceSecret)
+		return discovered, true
+	}
+	return &discoveredStackConfig{Value: entry.Value, Sources: entry.Sources}, true
+}
+
+func (c *stackConfigCache) put(accountID, region, stackName string, value *discoveredStackConfig) error {
+	return c.write(c.path(accountID, region, stackName), stackConfigCacheEntry{FetchedAt: c.now(), Value: value.Value, Sources: value.Sources})
+}
+
+// getAppTag returns the last successful readiness check of the stack when it
+// is younger than the TTL.
+func (c *stackConfigCache) getAppTag(accountID, region, stackName string) (*stackAppTagCacheEntry, bool) {
+	data, err := os.ReadFile(c.appTagPath(accountID, region, stackName))
+	if err != nil {
+		return nil, false
+	}
+	var entry stackAppTagCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.AppTag == "" || !c.fresh(entry.CheckedAt) {
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (c *stackConfigCache) putAppTag(accountID, region, stackName, appTag string) error {
+	return c.write(c.appTagPath(accountID, region, stackName), stackAppTagCacheEntry{CheckedAt: c.now(), AppTag: appTag})
+}
+
+func (c *stackConfigCache) write(path string, entry any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create stack config cache directory: %w", err)
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode stack config cache entry: %w", err)
+	}
+
+	// Write to a temporary file first so concurrent commands never read a
+	// partial entry.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".stack-config-*")
+	if err != nil {
+		return fmt.Errorf("write stack config cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write stack config cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write stack config cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write stack config cache entry: %w", err)
+	}
+	return nil
+}
+
+type stackConfigLoader struct {
+	secrets stackConfigSecretAPI
+	// stacks and tagging are the fallbacks used when the secret is missing
+	// or incomplete. Either may be nil.
+	stacks   cloudFormationStacksAPI
+	tagging  taggedResourcesAPI
+	identity callerIdentityAPI
+	cache    *stackConfigCache
+	// refresh skips the cached value, but still updates the cache.
+	refresh bool
+}
+
+// loadCachedRunsOnConfig returns the stack config from the cache when it is
+// fresh, and otherwise discovers it. Only configs found in the stack config
+// secret and the stack outputs are cached. When the AWS account can't be
+// determined, the cache is bypassed.
+func loadCachedRunsOnConfig(ctx context.Context, loader stackConfigLoader, stackName string, cfg aws.Config) (*RunsOnConfig, error) {
+	stackName = strings.TrimSpace(stackName)
+	if loader.cache == nil {
+		return discoverRunsOnConfig(ctx, loader, stackName, cfg)
+	}
+	accountID, err := resolveAccountID(ctx, cfg, loader.identity)
+	if err != nil || accountID == "" {
+		return discoverRunsOnConfig(ctx, loader, stackName, cfg)
+	}
+
+	if !loader.refresh {
+		if discovered, ok := loader.cache.get(accountID, cfg.Region, stackName); ok {
+			config := runsOnConfigFromDiscovery(stackName, cfg, discovered)
+			config.AccountID = accountID
+			return config, nil
+		}
+	}
+
+	discovered, err := discoverStackConfig(ctx, loader, stackName, cfg)
+	if err != nil {
+		return nil, err
+	}
+	// A cache that can't be written only costs a few AWS calls next time.
+	if discovered.cacheable() {
+		_ = loader.cache.put(accountID, cfg.Region, stackName, discovered)
+	}
+	config := runsOnConfigFromDiscovery(stackName, cfg, discovered)
+	config.AccountID = accountID
+	return config, nil
+}
+
+func discoverRunsOnConfig(ctx context.Context, loader stackConfigLoader, stackName string, cfg aws.Config) (*RunsOnConfig, error) {
+	discovered, err := discoverStackConfig(ctx, loader, stackName, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return runsOnConfigFromDiscovery(stackName, cfg, discovered), nil
+}
+
+// resolveAccountID returns the AWS account of the credentials, asking STS only
+// when the credentials provider doesn't report it.
+func resolveAccountID(ctx context.Context, cfg aws.Config, identity callerIdentityAPI) (string, error) {
+	if cfg.Credentials != nil {
+		credentials, err := cfg.Credentials.Retrieve(ctx)
+		if err != nil {
+			return "", fmt.Errorf("retrieve AWS credentials: %w", err)
+		}
+		if credentials.AccountID != "" {
+			return credentials.AccountID, nil
+		}
+	}
+	if identity == nil {
+		return "", fmt.Errorf("caller identity client is required")
+	}
+	output, err := identity.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", fmt.Errorf("get caller identity: %w", err)
+	}
+	return aws.ToString(output.Account), nil
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	tagtypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+type mockCallerIdentityClient struct {
+	account string
+	calls   int
+}
+
+func (m *mockCallerIdentityClient) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	m.calls++
+	if m.account == "" {
+		return nil, errors.New("no credentials")
+	}
+	return &sts.GetCallerIdentityOutput{Account: aws.String(m.account)}, nil
+}
+
+func countingStackConfigSecretsClient(calls *int, table string) *mockStackConfigSecretsClient {
+	return &mockStackConfigSecretsClient{
+		getSecretValue: func(context.Context, *secretsmanager.GetSecretValueInput, ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+			*calls++
+			return &secretsmanager.GetSecretValueOutput{
+				SecretString: aws.String(`{"WorkflowJobsTable":"` + table + `","ServiceLogGroupName":"/aws/ecs/runs-on/flexd"}`),
+			}, nil
+		},
+	}
+}
+
+func TestLoadCachedRunsOnConfigUsesFreshCacheEntries(t *testing.T) {
+	now := time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC)
+	cache := &stackConfigCache{dir: t.TempDir(), ttl: time.Hour, now: func() time.Time { return now }}
+	identity := &mockCallerIdentityClient{account: "123456789012"}
+	var secretCalls int
+	loader := stackConfigLoader{
+		secrets:  countingStackConfigSecretsClient(&secretCalls, "workflow-jobs"),
+		identity: identity,
+		cache:    cache,
+	}
+	cfg := aws.Config{Region: "us-east-1"}
+
+	for range 2 {
+		config, err := loadCachedRunsOnConfig(context.Background(), loader, "runs-on", cfg)
+		if err != nil {
+			t.Fatalf("loadCachedRunsOnConfig returned error: %v", err)
+		}
+		if config.WorkflowJobsTable != "workflow-jobs" || config.StackName != "runs-on" || config.AWSConfig.Region != "us-east-1" || config.AccountID != "123456789012" {
+			t.Fatalf("unexpected config %+v", config)
+		}
+	}
+	if secretCalls != 1 {
+		t.Fatalf("expected the second load to hit the cache, got %d secret calls", secretCalls)
+	}
+	if _, err := os.Stat(filepath.Join(cache.dir, "123456789012", "us-east-1", "runs-on.json")); err != nil {
+		t.Fatalf("expected cache entry per account/region/stack: %v", err)
+	}
+
+	if _, err := loadCachedRunsOnConfig(context.Background(), loader, "runs-on", aws.Config{Region: "eu-west-1"}); err != nil {
+		t.Fatalf("loadCachedRunsOnConfig returned error: %v", err)
+	}
+	if secretCalls != 2 {
+		t.Fatalf("expected another region to miss the cache, got %d secret calls", secretCalls)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := loadCachedRunsOnConfig(context.Background(), loader, "runs-on", cfg); err != nil {
+		t.Fatalf("loadCachedRunsOnConfig returned error: %v", err)
+	}
+	if secretCalls != 3 {
+		t.Fatalf("expected an expired entry to be reloaded, got %d secret calls", secretCalls)
+	}
+}
+
+func TestLoadCachedRunsOnConfigRefreshBypassesCache(t *testing.T) {
+	cache := &stackConfigCache{dir: t.TempDir(), ttl: time.Hour, now: time.Now}
+	identity := &mockCallerIdentityClient{account: "123456789012"}
+	cfg := aws.Config{Region: "us-east-1"}
+	var secretCalls int
+
+	loader := stackConfigLoader{secrets: countingStackConfigSecretsClient(&secretCalls, "old-table"), identity: identity, cache: cache}
+	if _, err := loadCachedRunsOnConfig(context.Background(), loader, "runs-on", cfg); err != nil {
+		t.Fatalf("loadCachedRunsOnConfig returned error: %v", err)
+	}
+
+	loader.secrets = countingStackConfigSecretsClient(&secretCalls, "new-table")
+	loader.refresh = true
+	config, err := loadCachedRunsOnConfig(context.Background(), loader, "runs-on", cfg)
+	if err != nil {
+		t.Fatalf("loadCachedRunsOnConfig returned error: %v", err)
+	}
+	if config.WorkflowJobsTable != "new-table" || secretCalls != 2 {
+		t.Fatalf("expected refresh to reload the secret, got table %q after %d calls", config.WorkflowJobsTable, secretCalls)
+	}
+
+	loader.refresh = false
+	config, err = loadCachedRunsOnConfig(context.Background(), loader, "runs-on", cfg)
+	if err != nil {
+		t.Fatalf("loadCachedRunsOnConfig returned error: %v", err)
+	}
+	if config.WorkflowJobsTable != "new-table" || secretCalls != 2 {
+		t.Fatalf("expected refresh to update the cache, got table %q after %d calls", config.WorkflowJobsTable, secretCalls)
+	}
+}
+
+func TestLoadCachedRunsOnConfigSkipsCacheWithoutAccount(t *testing.T) {
+	dir := t.TempDir()
+	cache := &stackConfigCache{dir: dir, ttl: time.Hour, now: time.Now}
+	var secretCalls int
+	loader := stackConfigLoader{
+		secrets:  countingStackConfigSecretsClient(&secretCalls, "workflow-jobs"),
+		identity: &mockCallerIdentityClient{},
+		cache:    cache,
+	}
+
+	for range 2 {
+		if _, err := loadCachedRunsOnConfig(context.Background(), loader, "runs-on", aws.Config{Region: "us-east-1"}); err != nil {
+			t.Fatalf("loadCachedRunsOnConfig returned error: %v", err)
+		}
+	}
+	if secretCalls != 2 {
+		t.Fatalf("expected every load to read the secret, got %d calls", secretCalls)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("expected nothing to be cached, got %d entries", len(entries))
+	}
+}
+
+func TestLoadCachedRunsOnConfigDoesNotCacheTaggedResources(t *testing.T) {
+	dir := t.TempDir()
+	var taggingCalls int
+	loader := stackConfigLoader{
+		secrets: missingStackConfigSecretClient(),
+		tagging: &mockTaggedResourcesClient{
+			getResources: func(context.Context, *resourcegroupstaggingapi.GetResourcesInput, ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.GetResourcesOutput, error) {
+				taggingCalls++
+				return &resourcegroupstaggingapi.GetResourcesOutput{ResourceTagMappingList: []tagtypes.ResourceTagMapping{
+					taggedResource("arn:aws:dynamodb:us-east-1:123456789012:table/runs-on-jobs", "WorkflowJobsTable"),
+				}}, nil
+			},
+		},
+		identity: &mockCallerIdentityClient{account: "123456789012"},
+		cache:    &stackConfigCache{dir: dir, ttl: time.Hour, now: time.Now},
+	}
+
+	for range 2 {
+		config, err := loadCachedRunsOnConfig(context.Background(), loader, "runs-on", aws.Config{Region: "us-east-1"})
+		if err != nil {
+			t.Fatalf("loadCachedRunsOnConfig returned error: %v", err)
+		}
+		if config.WorkflowJobsTable != "runs-on-jobs" {
+			t.Fatalf("unexpected config %+v", config)
+		}
+	}
+	if taggingCalls != 2 {
+		t.Fatalf("expected a config from tags to be discovered again, got %d tagging calls", taggingCalls)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("expected nothing to be cached, got %d entries", len(entries))
+	}
+}
+
+func TestStackConfigCacheKeepsSources(t *testing.T) {
+	cache := &stackConfigCache{dir: t.TempDir(), ttl: time.Hour, now: time.Now}
+	discovered := newDiscoveredStackConfig()
+	discovered.merge(stackConfigSecretValue{WorkflowJobsTable: "workflow-jobs"}, configSourceCloudFormation)
+	if err := cache.put("123456789012", "us-east-1", "runs-on", discovered); err != nil {
+		t.Fatalf("put returned error: %v", err)
+	}
+	cached, ok := cache.get("123456789012", "us-east-1", "runs-on")
+	if !ok || cached.Sources["WorkflowJobsTable"] != configSourceCloudFormation {
+		t.Fatalf("expected cached sources, got %+v", cached)
+	}
+
+	// Entries written before sources were recorded came from the secret.
+	legacy := `{"fetched_at":"` + time.Now().Format(time.RFC3339) + `","value":{"WorkflowJobsTable":"workflow-jobs"}}`
+	if err := os.WriteFile(cache.path("123456789012", "us-east-1", "legacy"), []byte(legacy), 0600); err != nil {
+		t.Fatalf("write legacy entry: %v", err)
+	}
+	cached, ok = cache.get("123456789012", "us-east-1", "legacy")
+	if !ok || cached.Value.WorkflowJobsTable != "workflow-jobs" || cached.Sources["WorkflowJobsTable"] != configSourceSecret {
+		t.Fatalf("expected legacy entry attributed to the secret, got %+v", cached)
+	}
+}
+
+func TestResolveAccountIDPrefersCredentialsAccount(t *testing.T) {
+	identity := &mockCallerIdentityClient{account: "210987654321"}
+	cfg := aws.Config{Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret", AccountID: "123456789012"}, nil
+	})}
+
+	accountID, err := resolveAccountID(context.Background(), cfg, identity)
+	if err != nil || accountID != "123456789012" || identity.calls != 0 {
+		t.Fatalf("expected account from credentials without STS call, got %q (%v, %d calls)", accountID, err, identity.calls)
+	}
+
+	accountID, err = resolveAccountID(context.Background(), aws.Config{}, identity)
+	if err != nil || accountID != "210987654321" || identity.calls != 1 {
+		t.Fatalf("expected STS account fallback, got %q (%v, %d calls)", accountID, err, identity.calls)
+	}
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"text/tabwriter"
+	"time"
+
+	"roc/internal/version"
+
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/spf13/cobra"
+)
+
+// stackInfo is what roc knows about a stack: the discovered config, plus the
+// values looked up live. A lookup that fails leaves its value empty and
+// records the error instead, so that the rest can still be shown.
+type stackInfo struct {
+	Config     *RunsOnConfig
+	AccountID  string
+	AccountErr error
+	ServiceARN string
+	ServiceErr error
+	AppTag     string
+	AppTagErr  error
+}
+
+// stackInfoRecord is the --output json|yaml form of stackInfo.
+type stackInfoRecord struct {
+	Stack        string                    `json:"stack"`
+	Region       string                    `json:"region"`
+	Account      string                    `json:"account,omitempty"`
+	AccountError string                    `json:"account_error,omitempty"`
+	Version      string                    `json:"version,omitempty"`
+	VersionError string                    `json:"version_error,omitempty"`
+	CLIVersion   string                    `json:"cli_version"`
+	Resources    []stackInfoResourceRecord `json:"resources"`
+}
+
+type stackInfoResourceRecord struct {
+	Name   string `json:"name"`
+	Value  string `json:"value,omitempty"`
+	Source string `json:"source,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type stackInfoClients struct {
+	identity   callerIdentityAPI
+	tagging    taggedResourcesAPI
+	httpClient *http.Client
+}
+
+func NewStackInfoCmd(stack *Stack) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "info",
+		Short: "Show the resources and version of the stack",
+		Long: `Show the resources roc discovered for the RunsOn stack, where each one was
+found, and the version of RunsOn the stack is running.
+
+roc reads the /runs-on/<stack>/stack-config secret first. Values the secret
+doesn't provide are taken from the CloudFormation stack outputs, then from the
+resources tagged with runs-on-stack-name=<stack>, matched by their
+CloudFormation logical ID.
+
+The running version is the app_tag reported by the service's /readyz endpoint.
+A warning is printed when its major or minor version doesn't match the version
+of roc, since each stack must be managed with the matching CLI version.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := stack.discoverResources(cmd)
+			if err != nil {
+				return err
+			}
+
+			info := collectStackInfo(cmd.Context(), config, stackInfoClients{
+				identity:   sts.NewFromConfig(config.AWSConfig),
+				tagging:    resourcegroupstaggingapi.NewFromConfig(config.AWSConfig),
+				httpClient: &http.Client{Timeout: 10 * time.Second},
+			})
+			if structuredOutput(cmd) {
+				err = writeStructured(cmd.OutOrStdout(), outputFormat(cmd), info.record())
+			} else {
+				err = writeStackInfo(cmd.OutOrStdout(), info)
+			}
+			if err != nil {
+				return err
+			}
+			return reportStackVersions(cmd.ErrOrStderr(), []*RunsOnConfig{config}, []string{info.AppTag}, []error{info.AppTagErr}, version.String(), strictVersion(cmd))
+		},
+	}
+
+	return cmd
+}
+
+func collectStackInfo(ctx context.Context, config *RunsOnConfig, clients stackInfoClients) *stackInfo {
+	info := &stackInfo{Config: config}
+	info.AccountID, info.AccountErr = resolveAccountID(ctx, config.AWSConfig, clients.identity)
+	info.ServiceARN, info.ServiceErr = discoverTaggedECSServiceARN(ctx, clients.tagging, config.StackName)
+	info.AppTag, info.AppTagErr = fetchStackAppTag(ctx, clients.httpClient, config.IngressURL)
+	return info
+}
+
+func writeStackInfo(w io.Writer, info *stackInfo) error {
+	config := info.Config
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "Stack:\t%s\n", config.StackName)
+	fmt.Fprintf(writer, "Region:\t%s\n", config.AWSConfig.Region)
+	fmt.Fprintf(writer, "Account:\t%s\n", stackInfoValue(info.AccountID, info.AccountErr))
+	fmt.Fprintf(writer, "Version:\t%s (roc %s)\n", stackInfoValue(info.AppTag, info.AppTagErr), version.String())
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+
+	writer = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "RESOURCE\tVALUE\tSOURCE")
+	for _, field := range stackConfigFields {
+		value, source := config.fieldValue(field.Name), config.Sources[field.Name]
+		if value == "" {
+			value, source = "-", "not found"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", field.Label, value, source)
+	}
+	source := configSourceTags
+	if info.ServiceARN == "" {
+		source = "not found"
+	}
+	fmt.Fprintf(writer, "ECS service\t%s\t%s\n", stackInfoValue(info.ServiceARN, info.ServiceErr), source)
+	return writer.Flush()
+}
+
+func (info *stackInfo) record() stackInfoRecord {
+	config := info.Config
+	record := stackInfoRecord{
+		Stack:        config.StackName,
+		Region:       config.AWSConfig.Region,
+		Account:      info.AccountID,
+		AccountError: errorString(info.AccountErr),
+		Version:      info.AppTag,
+		VersionError: errorString(info.AppTagErr),
+		CLIVersion:   version.String(),
+		Resources:    []stackInfoResourceRecord{},
+	}
+	for _, field := range stackConfigFields {
+		record.Resources = append(record.Resources, stackInfoResourceRecord{
+			Name:   field.Name,
+			Value:  config.fieldValue(field.Name),
+			Source: config.Sources[field.Name],
+		})
+	}
+	service := stackInfoResourceRecord{Name: "ECSService", Value: info.ServiceARN, Error: errorString(info.ServiceErr)}
+	if info.ServiceARN != "" {
+		service.Source = configSourceTags
+	}
+	record.Resources = append(record.Resources, service)
+	return record
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func stackInfoValue(value string, err error) string {
+	switch {
+	case value != "":
+		return value
+	case err != nil:
+		return fmt.Sprintf("- (%v)", err)
+	default:
+		return "-"
+	}
+}
+
+func (c *RunsOnConfig) fieldValue(name string) string {
+	switch name {
+	case "IngressURL":
+		return c.IngressURL
+	case "ServiceLogGroupName":
+		return c.ServiceLogGroupName
+	case "EC2InstanceLogGroupArn":
+		return c.EC2InstanceLogGroupArn
+	case "WorkflowJobsTable":
+		return c.WorkflowJobsTable
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	tagtypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+)
+
+func TestCollectStackInfo(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/prod/readyz" {
+			t.Fatalf("unexpected readiness path %q", r.URL.Path)
+		}
+		io.WriteString(w, `{"app_tag":"v2.12.5","github_app_configured":true}`)
+	}))
+	defer server.Close()
+
+	config := &RunsOnConfig{StackName: "runs-on", IngressURL: server.URL + "/prod", AWSConfig: aws.Config{Region: "us-east-1"}}
+	info := collectStackInfo(context.Background(), config, stackInfoClients{
+		identity: &mockCallerIdentityClient{account: "123456789012"},
+		tagging: &mockTaggedResourcesClient{
+			getResources: func(context.Context, *resourcegroupstaggingapi.GetResourcesInput, ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.GetResourcesOutput, error) {
+				return &resourcegroupstaggingapi.GetResourcesOutput{
+					ResourceTagMappingList: []tagtypes.ResourceTagMapping{
+						{ResourceARN: aws.String("arn:aws:ecs:us-east-1:123456789012:service/runs-on/flexd")},
+					},
+				}, nil
+			},
+		},
+		httpClient: server.Client(),
+	})
+
+	if info.AccountID != "123456789012" || info.ServiceARN != "arn:aws:ecs:us-east-1:123456789012:service/runs-on/flexd" || info.AppTag != "v2.12.5" {
+		t.Fatalf("unexpected stack info %+v", info)
+	}
+	if info.AccountErr != nil || info.ServiceErr != nil || info.AppTagErr != nil {
+		t.Fatalf("unexpected lookup errors %+v", info)
+	}
+}
+
+func TestWriteStackInfoShowsSourcesAndLookupErrors(t *testing.T) {
+	info := &stackInfo{
+		Config: &RunsOnConfig{
+			StackName:         "runs-on",
+			IngressURL:        "https://example.com",
+			WorkflowJobsTable: "workflow-jobs",
+			Sources: map[string]string{
+				"IngressURL":        configSourceSecret,
+				"WorkflowJobsTable": configSourceCloudFormation,
+			},
+			AWSConfig: aws.Config{Region: "us-east-1"},
+		},
+		AccountID:  "123456789012",
+		ServiceErr: errors.New("access denied"),
+		AppTag:     "v2.12.5",
+	}
+
+	var output bytes.Buffer
+	if err := writeStackInfo(&output, info); err != nil {
+		t.Fatalf("writeStackInfo returned error: %v", err)
+	}
+	for _, want := range []string{
+		"Region:   us-east-1",
+		"Account:  123456789012",
+		"Version:  v2.12.5 (roc ",
+		"https://example.com",
+		"cloudformation outputs",
+		"not found",
+		"- (access denied)",
+	} {
+		if !strings.Contains(output.String(), want) {
+			t.Fatalf("expected output to contain %q:\n%s", want, output.String())
+		}
+	}
+}
+
+func TestStackInfoRecord(t *testing.T) {
+	info := &stackInfo{
+		Config: &RunsOnConfig{
+			StackName:         "runs-on",
+			WorkflowJobsTable: "workflow-jobs",
+			Sources:           map[string]string{"WorkflowJobsTable": configSourceTags},
+			AWSConfig:         aws.Config{Region: "us-east-1"},
+		},
+		AccountID:  "123456789012",
+		ServiceARN: "arn:aws:ecs:us-east-1:123456789012:service/runs-on/flexd",
+		AppTagErr:  errors.New("timeout"),
+	}
+
+	record := info.record()
+	if record.Stack != "runs-on" || record.Account != "123456789012" || record.VersionError != "timeout" || record.Version != "" {
+		t.Fatalf("unexpected stack info record %+v", record)
+	}
+	if len(record.Resources) != len(stackConfigFields)+1 {
+		t.Fatalf("expected a resource per stack config field and the ECS service, got %+v", record.Resources)
+	}
+	for _, resource := range record.Resources {
+		switch resource.Name {
+		case "WorkflowJobsTable":
+			if resource.Value != "workflow-jobs" || resource.Source != configSourceTags {
+				t.Fatalf("unexpected workflow jobs table resource %+v", resource)
+			}
+		case "ECSService":
+			if resource.Source != configSourceTags || resource.Error != "" {
+				t.Fatalf("unexpected ECS service resource %+v", resource)
+			}
+		}
+	}
+}
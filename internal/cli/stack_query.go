@@ -0,0 +1,401 @@
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/spf13/cobra"
+)
+
+type logsInsightsAPI interface {
+	StartQuery(ctx context.Context, params *cloudwatchlogs.StartQueryInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StartQueryOutput, error)
+	GetQueryResults(ctx context.Context, params *cloudwatchlogs.GetQueryResultsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetQueryResultsOutput, error)
+	StopQuery(ctx context.Context, params *cloudwatchlogs.StopQueryInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StopQueryOutput, error)
+}
+
+// Log groups of roc stack query --log-group.
+const (
+	queryLogGroupApplication = "application"
+	queryLogGroupInstances   = "instances"
+)
+
+// maxQueryLimit is the maximum number of rows a Logs Insights query returns.
+const maxQueryLimit = 10000
+
+// queryPollInterval is how often the status of a running query is checked.
+var queryPollInterval = time.Second
+
+// logsQueryPreset is a canned query of roc stack query --preset.
+type logsQueryPreset struct {
+	Name        string
+	Description string
+	LogGroup    string
+	Query       string
+}
+
+var logsQueryPresets = []logsQueryPreset{
+	{
+		Name:        "errors",
+		Description: "latest error events of the application",
+		LogGroup:    queryLogGroupApplication,
+		Query:       `fields @timestamp, repo, job_id, coalesce(message, msg) as message | filter level = "error" | sort @timestamp desc | limit 100`,
+	},
+	{
+		Name:        "errors-by-repo",
+		Description: "number of error events per repository",
+		LogGroup:    queryLogGroupApplication,
+		Query:       `filter level = "error" | stats count(*) as errors by repo | sort errors desc`,
+	},
+	{
+		Name:        "errors-over-time",
+		Description: "number of error events per 5 minutes",
+		LogGroup:    queryLogGroupApplication,
+		Query:       `filter level = "error" | stats count(*) as errors by bin(5m) as time | sort time asc`,
+	},
+	{
+		Name:        "jobs-by-repo",
+		Description: "number of jobs that logged events, per repository",
+		LogGroup:    queryLogGroupApplication,
+		Query:       `filter ispresent(job_id) | stats count_distinct(job_id) as jobs by repo | sort jobs desc`,
+	},
+	{
+		Name:        "instance-errors",
+		Description: "latest instance log lines mentioning an error",
+		LogGroup:    queryLogGroupInstances,
+		Query:       `fields @timestamp, @logStream, @message | filter @message like /(?i)error/ | sort @timestamp desc | limit 100`,
+	},
+}
+
+func findLogsQueryPreset(name string) (logsQueryPreset, error) {
+	names := make([]string, 0, len(logsQueryPresets))
+	for _, preset := range logsQueryPresets {
+		if preset.Name == name {
+			return preset, nil
+		}
+		names = append(names, preset.Name)
+	}
+	return logsQueryPreset{}, fmt.Errorf("unknown --preset %q (valid: %s)", name, strings.Join(names, ", "))
+}
+
+func logsQueryPresetsHelp() string {
+	var help strings.Builder
+	for _, preset := range logsQueryPresets {
+		fmt.Fprintf(&help, "  %-18s %s (%s log group)\n", preset.Name, preset.Description, preset.LogGroup)
+	}
+	return help.String()
+}
+
+// logsQuery is a Logs Insights query of one log group of the stacks.
+type logsQuery struct {
+	Query    string
+	LogGroup string
+	Start    time.Time
+	End      time.Time
+	Limit    int
+}
+
+// logsQueryResult holds the rows of a query. Fields are the columns, in the
+// order of the query.
+type logsQueryResult struct {
+	Fields []string
+	Rows   []map[string]string
+}
+
+// queryLogGroup returns the identifier of the application or instances log
+// group of the stack.
+func (c *RunsOnConfig) queryLogGroup(logGroup string) (string, error) {
+	switch logGroup {
+	case queryLogGroupApplication:
+		if err := c.validateStackLogs(); err != nil {
+			return "", err
+		}
+		return c.ServiceLogGroupName, nil
+	case queryLogGroupInstances:
+		if c.EC2InstanceLogGroupArn == "" {
+			return "", fmt.Errorf("EC2 instance log group not found for stack %q", c.StackName)
+		}
+		return normalizeCloudWatchLogGroupIdentifier(c.EC2InstanceLogGroupArn), nil
+	default:
+		return "", validateQueryLogGroup(logGroup)
+	}
+}
+
+func validateQueryLogGroup(logGroup string) error {
+	switch logGroup {
+	case queryLogGroupApplication, queryLogGroupInstances:
+		return nil
+	default:
+		return fmt.Errorf("invalid --log-group %q (valid: %s, %s)", logGroup, queryLogGroupApplication, queryLogGroupInstances)
+	}
+}
+
+// runLogsQuery starts the query and polls for its results until it
+// completes. The query is stopped when ctx is cancelled.
+func runLogsQuery(ctx context.Context, client logsInsightsAPI, logGroupIdentifier string, query logsQuery) (*logsQueryResult, error) {
+	input := &cloudwatchlogs.StartQueryInput{
+		LogGroupIdentifiers: []string{logGroupIdentifier},
+		QueryString:         aws.String(query.Query),
+		StartTime:           aws.Int64(query.Start.Unix()),
+		EndTime:             aws.Int64(query.End.Unix()),
+	}
+	if query.Limit > 0 {
+		input.Limit = aws.Int32(int32(query.Limit))
+	}
+	started, err := client.StartQuery(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start query: %w", err)
+	}
+	queryID := aws.ToString(started.QueryId)
+
+	ticker := time.NewTicker(queryPollInterval)
+	defer ticker.Stop()
+	for {
+		output, err := client.GetQueryResults(ctx, &cloudwatchlogs.GetQueryResultsInput{QueryId: aws.String(queryID)})
+		if err != nil {
+			stopLogsQuery(ctx, client, queryID)
+			return nil, fmt.Errorf("failed to get query results: %w", err)
+		}
+		switch output.Status {
+		case cwltypes.QueryStatusComplete:
+			return logsQueryResultFromFields(output.Results), nil
+		case cwltypes.QueryStatusFailed, cwltypes.QueryStatusCancelled, cwltypes.QueryStatusTimeout:
+			return nil, fmt.Errorf("query %s: %s", queryID, strings.ToLower(string(output.Status)))
+		}
+
+		select {
+		case <-ctx.Done():
+			stopLogsQuery(ctx, client, queryID)
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// stopLogsQuery stops a query that is no longer waited for, so that it
+// doesn't count against the concurrent queries of the account.
+func stopLogsQuery(ctx context.Context, client logsInsightsAPI, queryID string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	_, _ = client.StopQuery(ctx, &cloudwatchlogs.StopQueryInput{QueryId: aws.String(queryID)})
+}
+
+// logsQueryResultFromFields converts the rows of GetQueryResults. The @ptr
+// field, only meant for GetLogRecord, is dropped.
+func logsQueryResultFromFields(results [][]cwltypes.ResultField) *logsQueryResult {
+	result := &logsQueryResult{Rows: make([]map[string]string, 0, len(results))}
+	for _, fields := range results {
+		row := make(map[string]string, len(fields))
+		for _, field := range fields {
+			name := aws.ToString(field.Field)
+			if name == "@ptr" {
+				continue
+			}
+			if !slices.Contains(result.Fields, name) {
+				result.Fields = append(result.Fields, name)
+			}
+			row[name] = aws.ToString(field.Value)
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result
+}
+
+// queryStacks runs the query against every stack concurrently. When several
+// stacks are queried, their rows are labeled with stack and region columns.
+func queryStacks(ctx context.Context, configs []*RunsOnConfig, clientFor func(*RunsOnConfig) logsInsightsAPI, query logsQuery) (*logsQueryResult, error) {
+	results := make([]*logsQueryResult, len(configs))
+	errs := make([]error, len(configs))
+	var wg sync.WaitGroup
+	for i, config := range configs {
+		wg.Go(func() {
+			logGroup, err := config.queryLogGroup(query.LogGroup)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			results[i], errs[i] = runLogsQuery(ctx, clientFor(config), logGroup, query)
+		})
+	}
+	wg.Wait()
+
+	if len(configs) == 1 {
+		return results[0], errs[0]
+	}
+	merged := &logsQueryResult{Fields: []string{"stack", "region"}}
+	for i, config := range configs {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("%s: %w", config.label(), errs[i])
+		}
+		for _, field := range results[i].Fields {
+			if !slices.Contains(merged.Fields, field) {
+				merged.Fields = append(merged.Fields, field)
+			}
+		}
+		for _, row := range results[i].Rows {
+			row["stack"] = config.StackName
+			row["region"] = config.AWSConfig.Region
+			merged.Rows = append(merged.Rows, row)
+		}
+	}
+	return merged, nil
+}
+
+// writeLogsQueryResult prints the rows of a query as a table, JSON or CSV.
+func writeLogsQueryResult(w io.Writer, result *logsQueryResult, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result.Rows); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	case "csv":
+		writer := csv.NewWriter(w)
+		_ = writer.Write(result.Fields)
+		for _, row := range result.Rows {
+			_ = writer.Write(logsQueryRow(result.Fields, row, ""))
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		if len(result.Rows) == 0 {
+			_, err := fmt.Fprintln(w, "No results")
+			return err
+		}
+		writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.Join(result.Fields, "\t"))
+		for _, row := range result.Rows {
+			fmt.Fprintln(writer, strings.Join(logsQueryRow(result.Fields, row, "-"), "\t"))
+		}
+		return writer.Flush()
+	}
+}
+
+func logsQueryRow(fields []string, row map[string]string, empty string) []string {
+	values := make([]string, len(fields))
+	for i, field := range fields {
+		values[i] = row[field]
+		if values[i] == "" {
+			values[i] = empty
+		}
+	}
+	return values
+}
+
+func NewStackQueryCmd(stack *Stack) *cobra.Command {
+	var (
+		preset   string
+		logGroup string
+		since    string
+		until    string
+		format   string
+		limit    int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "query [QUERY]",
+		Short: "Run a CloudWatch Logs Insights query against the stack logs",
+		Long: `Run a CloudWatch Logs Insights query against the application logs of the
+stack, or its EC2 instance logs with --log-group instances, and print the
+results once the query completes.
+
+The fields of the JSON application logs, such as level, repo and job_id, can
+be used in the query. Instead of a query, --preset runs one of these:
+
+` + logsQueryPresetsHelp() + `
+When several stacks or regions are selected, the query runs against each of
+them and the rows are labeled with their stack and region.`,
+		Example: `  roc stack query 'fields @timestamp, msg | filter level="error" | stats count() by repo'
+  roc stack query --preset errors-by-repo --since 24h
+  roc stack query --preset instance-errors --format csv`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateTableFormat(format); err != nil {
+				return err
+			}
+			structured := !cmd.Flags().Changed("format") && structuredOutput(cmd)
+
+			query := logsQuery{LogGroup: logGroup, Limit: limit}
+			switch {
+			case preset != "" && len(args) > 0:
+				return fmt.Errorf("a query cannot be combined with --preset")
+			case preset != "":
+				canned, err := findLogsQueryPreset(preset)
+				if err != nil {
+					return err
+				}
+				query.Query = canned.Query
+				if !cmd.Flags().Changed("log-group") {
+					query.LogGroup = canned.LogGroup
+				}
+			case len(args) > 0 && strings.TrimSpace(args[0]) != "":
+				query.Query = args[0]
+			default:
+				return fmt.Errorf("a query or --preset is required")
+			}
+			if err := validateQueryLogGroup(query.LogGroup); err != nil {
+				return err
+			}
+			if limit < 0 || limit > maxQueryLimit {
+				return fmt.Errorf("invalid --limit %d: must be between 0 and %d", limit, maxQueryLimit)
+			}
+
+			now := time.Now()
+			var err error
+			if query.Start, err = parseTimeFlag("since", since, now); err != nil {
+				return err
+			}
+			if query.End, err = parseTimeFlag("until", until, now); err != nil {
+				return err
+			}
+			if query.End.IsZero() {
+				query.End = now
+			}
+			if query.End.Before(query.Start) {
+				return fmt.Errorf("--until must not be before --since")
+			}
+
+			configs, err := stack.getAllStackOutputs(cmd)
+			if err != nil {
+				return err
+			}
+			for _, config := range configs {
+				if _, err := config.queryLogGroup(query.LogGroup); err != nil {
+					return err
+				}
+			}
+
+			result, err := queryStacks(cmd.Context(), configs, func(config *RunsOnConfig) logsInsightsAPI {
+				return cloudwatchlogs.NewFromConfig(config.AWSConfig)
+			}, query)
+			if err != nil {
+				return err
+			}
+			if structured {
+				return writeStructured(cmd.OutOrStdout(), outputFormat(cmd), result.Rows)
+			}
+			return writeLogsQueryResult(cmd.OutOrStdout(), result, format)
+		},
+	}
+
+	cmd.Flags().StringVar(&preset, "preset", "", "Run a canned query instead of QUERY (see above)")
+	cmd.Flags().StringVar(&logGroup, "log-group", queryLogGroupApplication, "Log group to query: application or instances")
+	cmd.Flags().StringVarP(&since, "since", "s", "1h", "Query logs since this duration ago or RFC3339 time (e.g. 24h, 2026-05-08T12:00:00Z)")
+	cmd.Flags().StringVar(&until, "until", "", "Query logs until this duration ago or RFC3339 time (default: now)")
+	cmd.Flags().IntVar(&limit, "limit", 0, "Maximum number of rows to return (default: the limit of the query, or 1000)")
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format: table, json, or csv (--output json|yaml applies when unset)")
+
+	return cmd
+}
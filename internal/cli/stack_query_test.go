@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// mockLogsInsightsClient completes every query after one poll, with the rows
+// of results.
+type mockLogsInsightsClient struct {
+	mu      sync.Mutex
+	inputs  []*cloudwatchlogs.StartQueryInput
+	polls   int
+	status  cwltypes.QueryStatus
+	results [][]cwltypes.ResultField
+	stopped []string
+}
+
+func (m *mockLogsInsightsClient) StartQuery(ctx context.Context, params *cloudwatchlogs.StartQueryInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StartQueryOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inputs = append(m.inputs, params)
+	return &cloudwatchlogs.StartQueryOutput{QueryId: aws.String("query-1")}, nil
+}
+
+func (m *mockLogsInsightsClient) GetQueryResults(ctx context.Context, params *cloudwatchlogs.GetQueryResultsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetQueryResultsOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.polls++
+	if m.polls == 1 {
+		return &cloudwatchlogs.GetQueryResultsOutput{Status: cwltypes.QueryStatusRunning}, nil
+	}
+	status := m.status
+	if status == "" {
+		status = cwltypes.QueryStatusComplete
+	}
+	return &cloudwatchlogs.GetQueryResultsOutput{Status: status, Results: m.results}, nil
+}
+
+func (m *mockLogsInsightsClient) StopQuery(ctx context.Context, params *cloudwatchlogs.StopQueryInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StopQueryOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopped = append(m.stopped, aws.ToString(params.QueryId))
+	return &cloudwatchlogs.StopQueryOutput{}, nil
+}
+
+func queryResultRow(fields ...string) []cwltypes.ResultField {
+	row := make([]cwltypes.ResultField, 0, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		row = append(row, cwltypes.ResultField{Field: aws.String(fields[i]), Value: aws.String(fields[i+1])})
+	}
+	return row
+}
+
+func TestRunLogsQueryPollsUntilComplete(t *testing.T) {
+	queryPollInterval = time.Millisecond
+	defer func() { queryPollInterval = time.Second }()
+
+	client := &mockLogsInsightsClient{results: [][]cwltypes.ResultField{
+		queryResultRow("repo", "runs-on/app", "errors", "12", "@ptr", "abc"),
+		queryResultRow("repo", "runs-on/cli", "errors", "3"),
+	}}
+	start := time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC)
+	result, err := runLogsQuery(context.Background(), client, "/aws/ecs/runs-on/flexd", logsQuery{
+		Query: "stats count(*) as errors by repo",
+		Start: start,
+		End:   start.Add(time.Hour),
+		Limit: 50,
+	})
+	if err != nil {
+		t.Fatalf("runLogsQuery returned error: %v", err)
+	}
+
+	input := client.inputs[0]
+	if input.LogGroupIdentifiers[0] != "/aws/ecs/runs-on/flexd" || aws.ToInt64(input.StartTime) != start.Unix() || aws.ToInt64(input.EndTime) != start.Add(time.Hour).Unix() || aws.ToInt32(input.Limit) != 50 {
+		t.Fatalf("unexpected query %+v", input)
+	}
+	if client.polls != 2 {
+		t.Fatalf("expected the results to be polled until the query completed, got %d polls", client.polls)
+	}
+	if strings.Join(result.Fields, ",") != "repo,errors" || len(result.Rows) != 2 || result.Rows[1]["errors"] != "3" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestRunLogsQueryFails(t *testing.T) {
+	queryPollInterval = time.Millisecond
+	defer func() { queryPollInterval = time.Second }()
+
+	client := &mockLogsInsightsClient{status: cwltypes.QueryStatusTimeout}
+	_, err := runLogsQuery(context.Background(), client, "/aws/ecs/runs-on/flexd", logsQuery{Query: "fields @message"})
+	if err == nil || err.Error() != "query query-1: timeout" {
+		t.Fatalf("expected the query to time out, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client = &mockLogsInsightsClient{}
+	if _, err := runLogsQuery(ctx, client, "/aws/ecs/runs-on/flexd", logsQuery{Query: "fields @message"}); err == nil {
+		t.Fatal("expected a cancelled query to fail")
+	}
+	if len(client.stopped) != 1 {
+		t.Fatalf("expected the cancelled query to be stopped, got %v", client.stopped)
+	}
+}
+
+func TestQueryStacksLabelsRows(t *testing.T) {
+	queryPollInterval = time.Millisecond
+	defer func() { queryPollInterval = time.Second }()
+
+	configs := []*RunsOnConfig{
+		{StackName: "runs-on", AWSConfig: aws.Config{Region: "us-east-1"}, EC2InstanceLogGroupArn: "arn:aws:logs:us-east-1:123456789012:log-group:runs-on/ec2:*"},
+		{StackName: "runs-on", AWSConfig: aws.Config{Region: "eu-west-1"}, EC2InstanceLogGroupArn: "arn:aws:logs:eu-west-1:123456789012:log-group:runs-on/ec2"},
+	}
+	clients := map[string]*mockLogsInsightsClient{}
+	for _, config := range configs {
+		clients[config.AWSConfig.Region] = &mockLogsInsightsClient{results: [][]cwltypes.ResultField{
+			queryResultRow("@message", "error in "+config.AWSConfig.Region),
+		}}
+	}
+
+	result, err := queryStacks(context.Background(), configs, func(config *RunsOnConfig) logsInsightsAPI {
+		return clients[config.AWSConfig.Region]
+	}, logsQuery{Query: "fields @message", LogGroup: queryLogGroupInstances})
+	if err != nil {
+		t.Fatalf("queryStacks returned error: %v", err)
+	}
+	if got := clients["us-east-1"].inputs[0].LogGroupIdentifiers[0]; got != "arn:aws:logs:us-east-1:123456789012:log-group:runs-on/ec2" {
+		t.Fatalf("expected the instance log group ARN without the wildcard, got %q", got)
+	}
+
+	var output bytes.Buffer
+	if err := writeLogsQueryResult(&output, result, "csv"); err != nil {
+		t.Fatalf("writeLogsQueryResult returned error: %v", err)
+	}
+	want := "stack,region,@message\nruns-on,us-east-1,error in us-east-1\nruns-on,eu-west-1,error in eu-west-1\n"
+	if output.String() != want {
+		t.Fatalf("unexpected CSV output:\nwant: %q\n got: %q", want, output.String())
+	}
+}
+
+func TestWriteLogsQueryResultTable(t *testing.T) {
+	result := logsQueryResultFromFields([][]cwltypes.ResultField{
+		queryResultRow("repo", "runs-on/app", "errors", "12"),
+		queryResultRow("errors", "3"),
+	})
+	var output bytes.Buffer
+	if err := writeLogsQueryResult(&output, result, "table"); err != nil {
+		t.Fatalf("writeLogsQueryResult returned error: %v", err)
+	}
+	want := "repo         errors\nruns-on/app  12\n-            3\n"
+	if output.String() != want {
+		t.Fatalf("unexpected table output:\nwant: %q\n got: %q", want, output.String())
+	}
+}
+
+func TestStackQueryFlagValidation(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "a query or --preset is required"},
+		{[]string{"fields @message", "--preset", "errors"}, "a query cannot be combined with --preset"},
+		{[]string{"--preset", "nope"}, `unknown --preset "nope" (valid: errors, errors-by-repo`},
+		{[]string{"fields @message", "--log-group", "nope"}, `invalid --log-group "nope"`},
+		{[]string{"fields @message", "--limit", "20000"}, "invalid --limit 20000"},
+		{[]string{"fields @message", "--since", "1h", "--until", "2h"}, "--until must not be before --since"},
+	} {
+		cmd := NewStackQueryCmd(&Stack{})
+		cmd.SetArgs(test.args)
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Fatalf("%v: error %v, want %q", test.args, err, test.want)
+		}
+	}
+}
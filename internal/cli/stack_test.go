@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestExpandStackTargetsPairsEveryStackWithEveryRegion(t *testing.T) {
+	targets, err := expandStackTargets([]string{"runs-on", " runs-on-dev ", "runs-on", ""}, []string{"us-east-1", "eu-west-1"}, "us-west-2")
+	if err != nil {
+		t.Fatalf("expandStackTargets returned error: %v", err)
+	}
+	var got []string
+	for _, target := range targets {
+		got = append(got, target.String())
+	}
+	want := "runs-on/us-east-1,runs-on/eu-west-1,runs-on-dev/us-east-1,runs-on-dev/eu-west-1"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("unexpected targets %q", got)
+	}
+
+	targets, err = expandStackTargets([]string{"runs-on"}, nil, "us-west-2")
+	if err != nil {
+		t.Fatalf("expandStackTargets returned error: %v", err)
+	}
+	if len(targets) != 1 || targets[0].Region != "us-west-2" {
+		t.Fatalf("expected AWS config region by default, got %+v", targets)
+	}
+
+	if _, err := expandStackTargets([]string{" "}, nil, "us-west-2"); err == nil {
+		t.Fatal("expected an error without stack names")
+	}
+}
+
+func TestLoadStackTargetConfigsSkipsFailedTargets(t *testing.T) {
+	targets := []stackTarget{
+		{StackName: "runs-on", Region: "us-east-1"},
+		{StackName: "runs-on", Region: "eu-west-1"},
+		{StackName: "runs-on", Region: "ap-south-1"},
+	}
+	results := loadStackTargetConfigs(context.Background(), targets, func(ctx context.Context, target stackTarget) (*RunsOnConfig, error) {
+		if target.Region == "eu-west-1" {
+			return nil, fmt.Errorf("secret not found")
+		}
+		return &RunsOnConfig{StackName: target.StackName, AWSConfig: aws.Config{Region: target.Region}}, nil
+	})
+
+	var warnings bytes.Buffer
+	configs, err := usableStackConfigs(results, &warnings)
+	if err != nil {
+		t.Fatalf("usableStackConfigs returned error: %v", err)
+	}
+	if len(configs) != 2 || configs[0].label() != "runs-on/us-east-1" || configs[1].label() != "runs-on/ap-south-1" {
+		t.Fatalf("expected loaded configs in target order, got %+v", configs)
+	}
+	if !strings.Contains(warnings.String(), "skipping runs-on/eu-west-1: secret not found") {
+		t.Fatalf("expected a warning for the failed target, got %q", warnings.String())
+	}
+
+	if _, err := usableStackConfigs(results[1:2], &warnings); err == nil || err.Error() != "secret not found" {
+		t.Fatalf("expected a single target to keep its error, got %v", err)
+	}
+}
+
+func TestFindWorkflowJobStackSearchesStacksInOrder(t *testing.T) {
+	east := &RunsOnConfig{StackName: "runs-on", WorkflowJobsTable: "jobs-east", AWSConfig: aws.Config{Region: "us-east-1"}}
+	west := &RunsOnConfig{StackName: "runs-on", WorkflowJobsTable: "jobs-west", AWSConfig: aws.Config{Region: "eu-west-1"}}
+	clients := map[string]*mockWorkflowJobsLookupClient{
+		"jobs-east": {t: t, records: []workflowJobFactsRecord{{JobID: 1, RunID: 10}}},
+		"jobs-west": {t: t, records: []workflowJobFactsRecord{{JobID: 2, RunID: 20}, {JobID: 3, RunID: 20}}},
+	}
+	clientFor := func(config *RunsOnConfig) workflowJobsLookupAPI {
+		return clients[config.WorkflowJobsTable]
+	}
+	configs := []*RunsOnConfig{east, west}
+
+	for ref, want := range map[string]*RunsOnConfig{
+		"1": east,
+		"2": west,
+		"https://github.com/runs-on/runs-on/actions/runs/20":       west,
+		"https://github.com/runs-on/runs-on/actions/runs/10/job/1": east,
+	} {
+		got, err := findWorkflowJobStack(context.Background(), configs, clientFor, ref, false, false)
+		if err != nil {
+			t.Fatalf("findWorkflowJobStack(%q) returned error: %v", ref, err)
+		}
+		if got != want {
+			t.Fatalf("findWorkflowJobStack(%q) = %s, want %s", ref, got.label(), want.label())
+		}
+	}
+
+	got, err := findWorkflowJobStack(context.Background(), configs, clientFor, "20", true, false)
+	if err != nil || got != west {
+		t.Fatalf("expected --run to find run 20 in %s, got %v (%v)", west.label(), got, err)
+	}
+
+	_, err = findWorkflowJobStack(context.Background(), configs, clientFor, "99", false, false)
+	if err == nil || !strings.Contains(err.Error(), "runs-on/us-east-1, runs-on/eu-west-1") {
+		t.Fatalf("expected not found error listing searched stacks, got %v", err)
+	}
+	if clients["jobs-east"].scans+clients["jobs-west"].scans != 4 {
+		t.Fatalf("expected only run references to scan, got %d and %d scans", clients["jobs-east"].scans, clients["jobs-west"].scans)
+	}
+}
+
+func TestFindWorkflowJobStackRefusesSeveralStacksForSingle(t *testing.T) {
+	east := &RunsOnConfig{StackName: "runs-on", WorkflowJobsTable: "jobs-east", AWSConfig: aws.Config{Region: "us-east-1"}}
+	west := &RunsOnConfig{StackName: "runs-on", WorkflowJobsTable: "jobs-west", AWSConfig: aws.Config{Region: "eu-west-1"}}
+	clients := map[string]*mockWorkflowJobsLookupClient{
+		"jobs-east": {t: t, records: []workflowJobFactsRecord{{JobID: 1, RunID: 10}}},
+		"jobs-west": {t: t, records: []workflowJobFactsRecord{{JobID: 1, RunID: 10}, {JobID: 2, RunID: 20}}},
+	}
+	clientFor := func(config *RunsOnConfig) workflowJobsLookupAPI {
+		return clients[config.WorkflowJobsTable]
+	}
+	configs := []*RunsOnConfig{east, west}
+
+	got, err := findWorkflowJobStack(context.Background(), configs, clientFor, "2", false, true)
+	if err != nil || got != west {
+		t.Fatalf("expected job 2 to be found in %s only, got %v (%v)", west.label(), got, err)
+	}
+
+	_, err = findWorkflowJobStack(context.Background(), configs, clientFor, "1", false, true)
+	if err == nil || !strings.Contains(err.Error(), "several stacks (runs-on/us-east-1, runs-on/eu-west-1)") {
+		t.Fatalf("expected a job known to both stacks to be refused, got %v", err)
+	}
+
+	if got, err := findWorkflowJobStack(context.Background(), configs, clientFor, "1", false, false); err != nil || got != east {
+		t.Fatalf("expected read-only lookups to use the first stack, got %v (%v)", got, err)
+	}
+}
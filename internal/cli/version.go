@@ -2,7 +2,6 @@ package cli
 
 import (
 	"fmt"
-	"os"
 
 	"roc/internal/version"
 
@@ -11,10 +10,15 @@ import (
 
 func NewVersionCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "version",
-		Short: "Display the version of roc",
-		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Fprintf(os.Stdout, "%s\n", version.String())
+		Use:         "version",
+		Short:       "Display the version of roc",
+		Annotations: map[string]string{noContextAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if structuredOutput(cmd) {
+				return writeStructured(cmd.OutOrStdout(), outputFormat(cmd), map[string]string{"version": version.String()})
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\n", version.String())
+			return nil
 		},
 	}
 
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"roc/internal/version"
+
+	"github.com/spf13/cobra"
+)
+
+// stackVersionCheckTimeout bounds the readiness request made before a command
+// talks to a stack. Stacks that don't answer in time are not checked. The
+// outcome is cached with the stack config, so a stack is checked at most once
+// per stackConfigCacheTTL.
+const stackVersionCheckTimeout = 3 * time.Second
+
+const installScriptURL = "https://raw.githubusercontent.com/runs-on/cli/main/install.sh"
+
+var majorMinorVersionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)(?:\.\d+)?(?:[-+].*)?$`)
+
+func parseMajorMinorVersion(value string) (int, int, bool) {
+	match := majorMinorVersionPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return 0, 0, false
+	}
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	return major, minor, true
+}
+
+// stackVersionMismatch reports whether roc and the stack differ in their major
+// or minor version. Development builds and versions that can't be parsed are
+// never reported.
+func stackVersionMismatch(cliVersion, appTag string) bool {
+	cliMajor, cliMinor, ok := parseMajorMinorVersion(cliVersion)
+	if !ok {
+		return false
+	}
+	stackMajor, stackMinor, ok := parseMajorMinorVersion(appTag)
+	if !ok {
+		return false
+	}
+	return cliMajor != stackMajor || cliMinor != stackMinor
+}
+
+func stackVersionMessage(label, cliVersion, appTag string) string {
+	release := "v" + strings.TrimPrefix(strings.TrimSpace(appTag), "v")
+	return fmt.Sprintf("roc %s doesn't match stack %s, which runs RunsOn %s. Install the matching release with:\n  %s", cliVersion, label, appTag, installCommand(release, runtime.GOOS))
+}
+
+// installCommand returns the install.sh invocation that installs the given
+// release into ~/.local/bin. install.sh is written for GitHub Actions, so the
+// runner variables it relies on are set explicitly.
+func installCommand(release, goos string) string {
+	runnerOS := "Linux"
+	switch goos {
+	case "darwin":
+		runnerOS = "macOS"
+	case "windows":
+		runnerOS = "Windows"
+	}
+	return fmt.Sprintf("curl -fsSL %s | RUNNER_OS=%s GITHUB_PATH=/dev/null bash -s -- %s", installScriptURL, runnerOS, release)
+}
+
+// fetchStackAppTag returns the app_tag reported by the service's readiness
+// endpoint.
+func fetchStackAppTag(ctx context.Context, client *http.Client, ingressURL string) (string, error) {
+	if strings.TrimSpace(ingressURL) == "" {
+		return "", fmt.Errorf("ingress URL not available")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, doctorReadinessURL(ingressURL), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var readiness doctorReadinessResponse
+	if err := json.NewDecoder(resp.Body).Decode(&readiness); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("readiness endpoint returned HTTP %d", resp.StatusCode)
+		}
+		return "", fmt.Errorf("parse readiness response: %w", err)
+	}
+	if readiness.AppTag == "" {
+		return "", fmt.Errorf("readiness response has no app_tag")
+	}
+	return readiness.AppTag, nil
+}
+
+func strictVersion(cmd *cobra.Command) bool {
+	strict, _ := cmd.Flags().GetBool("strict-version")
+	return strict
+}
+
+// checkStackVersions compares the roc version with the version each stack
+// reports on its readiness endpoint. Mismatches are warnings, or an error with
+// --strict-version.
+func checkStackVersions(cmd *cobra.Command, configs []*RunsOnConfig) error {
+	cliVersion := version.String()
+	if _, _, ok := parseMajorMinorVersion(cliVersion); !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), stackVersionCheckTimeout)
+	defer cancel()
+	client := &http.Client{Timeout: stackVersionCheckTimeout}
+	appTags, errs := stackAppTags(ctx, newStackConfigCache(), configs, refreshStackConfig(cmd), func(ctx context.Context, ingressURL string) (string, error) {
+		return fetchStackAppTag(ctx, client, ingressURL)
+	})
+
+	return reportStackVersions(cmd.ErrOrStderr(), configs, appTags, errs, cliVersion, strictVersion(cmd))
+}
+
+// stackAppTags returns the app_tag of each stack, or why it couldn't be read.
+// For the stacks whose account is known, app tags are read from the cache
+// unless refresh is set, and successful checks are written to it.
+func stackAppTags(ctx context.Context, cache *stackConfigCache, configs []*RunsOnConfig, refresh bool, fetch func(ctx context.Context, ingressURL string) (string, error)) ([]string, []error) {
+	appTags := make([]string, len(configs))
+	errs := make([]error, len(configs))
+	var wg sync.WaitGroup
+	for i, config := range configs {
+		cached := cache != nil && config.AccountID != ""
+		if cached && !refresh {
+			if entry, ok := cache.getAppTag(config.AccountID, config.AWSConfig.Region, config.StackName); ok {
+				appTags[i] = entry.AppTag
+				continue
+			}
+		}
+		wg.Go(func() {
+			appTags[i], errs[i] = fetch(ctx, config.IngressURL)
+			if cached && errs[i] == nil {
+				// A check that can't be cached is only made again next time.
+				_ = cache.putAppTag(config.AccountID, config.AWSConfig.Region, config.StackName, appTags[i])
+			}
+		})
+	}
+	wg.Wait()
+	return appTags, errs
+}
+
+// reportStackVersions warns about every stack whose version doesn't match
+// cliVersion. With strict set, the first mismatch is returned as an error
+// instead, and stacks whose version couldn't be read are reported.
+func reportStackVersions(w io.Writer, configs []*RunsOnConfig, appTags []string, errs []error, cliVersion string, strict bool) error {
+	for i, config := range configs {
+		if errs[i] != nil {
+			if strict {
+				fmt.Fprintf(w, "Warning: couldn't check the RunsOn version of stack %s: %v\n", config.label(), errs[i])
+			}
+			continue
+		}
+		if !stackVersionMismatch(cliVersion, appTags[i]) {
+			continue
+		}
+		message := stackVersionMessage(config.label(), cliVersion, appTags[i])
+		if strict {
+			return errors.New(message)
+		}
+		fmt.Fprintf(w, "Warning: %s\n", message)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestStackVersionMismatch(t *testing.T) {
+	for _, tc := range []struct {
+		cli, app string
+		mismatch bool
+	}{
+		{"v2.12.5", "v2.12.5", false},
+		{"v2.12.5", "v2.12.1", false},
+		{"2.12.5", "v2.12.0-rc1", false},
+		{"v2.12.5", "v2.13.0", true},
+		{"v3.0.7", "v2.12.5", true},
+		{"dev", "v2.13.0", false},
+		{"v2.12.5", "", false},
+		{"v2.12.5", "main-abc123", false},
+	} {
+		if got := stackVersionMismatch(tc.cli, tc.app); got != tc.mismatch {
+			t.Fatalf("stackVersionMismatch(%q, %q) = %v, want %v", tc.cli, tc.app, got, tc.mismatch)
+		}
+	}
+}
+
+func TestReportStackVersions(t *testing.T) {
+	configs := []*RunsOnConfig{
+		{StackName: "runs-on", AWSConfig: aws.Config{Region: "us-east-1"}},
+		{StackName: "runs-on", AWSConfig: aws.Config{Region: "eu-west-1"}},
+		{StackName: "runs-on-private", AWSConfig: aws.Config{Region: "us-east-1"}},
+	}
+	appTags := []string{"v2.12.1", "v2.13.0", ""}
+	errs := []error{nil, nil, errors.New("timeout")}
+
+	var output bytes.Buffer
+	if err := reportStackVersions(&output, configs, appTags, errs, "v2.12.5", false); err != nil {
+		t.Fatalf("expected a warning only, got %v", err)
+	}
+	if got := output.String(); strings.Count(got, "Warning:") != 1 || !strings.Contains(got, "stack runs-on/eu-west-1, which runs RunsOn v2.13.0") || !strings.Contains(got, "bash -s -- v2.13.0") {
+		t.Fatalf("unexpected warnings:\n%s", got)
+	}
+
+	output.Reset()
+	err := reportStackVersions(&output, configs, appTags, errs, "v2.12.5", true)
+	if err == nil || !strings.Contains(err.Error(), "install.sh | RUNNER_OS=") {
+		t.Fatalf("expected --strict-version to fail with the install command, got %v", err)
+	}
+	if output.Len() != 0 {
+		t.Fatalf("expected no warnings before the strict failure:\n%s", output.String())
+	}
+
+	if err := reportStackVersions(&output, configs[2:], appTags[2:], errs[2:], "v2.12.5", true); err != nil {
+		t.Fatalf("expected an unreachable stack not to fail, got %v", err)
+	}
+	if !strings.Contains(output.String(), "Warning: couldn't check the RunsOn version of stack runs-on-private/us-east-1: timeout") {
+		t.Fatalf("expected --strict-version to report unchecked stacks:\n%s", output.String())
+	}
+}
+
+func TestInstallCommand(t *testing.T) {
+	got := installCommand("v2.12.5", "darwin")
+	want := "curl -fsSL https://raw.githubusercontent.com/runs-on/cli/main/install.sh | RUNNER_OS=macOS GITHUB_PATH=/dev/null bash -s -- v2.12.5"
+	if got != want {
+		t.Fatalf("unexpected install command:\nwant: %s\n got: %s", want, got)
+	}
+}
+
+func TestStackAppTagsAreCheckedOncePerCacheTTL(t *testing.T) {
+	now := time.Date(2026, 5, 8, 12, 0, 0, 0, time.UTC)
+	cache := &stackConfigCache{dir: t.TempDir(), ttl: time.Hour, now: func() time.Time { return now }}
+	configs := []*RunsOnConfig{
+		{StackName: "runs-on", AccountID: "123456789012", IngressURL: "https://runs-on.example.com", AWSConfig: aws.Config{Region: "us-east-1"}},
+		{StackName: "runs-on", AccountID: "123456789012", IngressURL: "https://unreachable.example.com", AWSConfig: aws.Config{Region: "eu-west-1"}},
+		{StackName: "runs-on", IngressURL: "https://runs-on.example.com", AWSConfig: aws.Config{Region: "us-west-2"}},
+	}
+	var mu sync.Mutex
+	fetches := map[string]int{}
+	fetch := func(ctx context.Context, ingressURL string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetches[ingressURL]++
+		if strings.Contains(ingressURL, "unreachable") {
+			return "", errors.New("context deadline exceeded")
+		}
+		return "v2.13.0", nil
+	}
+
+	for range 2 {
+		appTags, errs := stackAppTags(context.Background(), cache, configs, false, fetch)
+		if appTags[0] != "v2.13.0" || errs[0] != nil || appTags[2] != "v2.13.0" {
+			t.Fatalf("unexpected app tags %q (%v)", appTags, errs)
+		}
+		if errs[1] == nil || errs[1].Error() != "context deadline exceeded" {
+			t.Fatalf("expected the failed check to be reported, got %v", errs[1])
+		}
+	}
+	if fetches["https://runs-on.example.com"] != 3 || fetches["https://unreachable.example.com"] != 2 {
+		t.Fatalf("expected stacks with a known account to be checked once and failed checks to be retried, got %v", fetches)
+	}
+
+	stackAppTags(context.Background(), cache, configs[:1], true, fetch)
+	now = now.Add(2 * time.Hour)
+	stackAppTags(context.Background(), cache, configs[:1], false, fetch)
+	if fetches["https://runs-on.example.com"] != 5 {
+		t.Fatalf("expected refresh and expired checks to fetch again, got %v", fetches)
+	}
+}
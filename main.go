@@ -15,18 +15,23 @@ import (
 func main() {
 	ctx := context.Background()
 
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithRetryer(func() aws.Retryer {
-			return retry.AddWithMaxAttempts(retry.NewStandard(), 10)
-		}),
-	)
+	loadConfig := func(ctx context.Context, optFns ...func(*config.LoadOptions) error) (aws.Config, error) {
+		optFns = append([]func(*config.LoadOptions) error{
+			config.WithRetryer(func() aws.Retryer {
+				return retry.AddWithMaxAttempts(retry.NewStandard(), 10)
+			}),
+		}, optFns...)
+		return config.LoadDefaultConfig(ctx, optFns...)
+	}
+
+	cfg, err := loadConfig(ctx)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "unable to load SDK config: %v\n", err)
 		os.Exit(1)
 	}
 
-	if err := cli.NewRootCmd(cli.NewStack(cfg)).ExecuteContext(ctx); err != nil {
+	if err := cli.NewRootCmd(cli.NewStack(cfg, loadConfig)).ExecuteContext(ctx); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(cli.ExitCode(err))
 	}
 }